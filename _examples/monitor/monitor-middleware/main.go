@@ -19,8 +19,11 @@ func main() {
 	})
 	// Manually stop monitoring on CMD/CTRL+C.
 	iris.RegisterOnInterrupt(m.Stop)
+	// Track the per-route request rate, error rate and latency percentiles.
+	app.UseRouter(m.Handler)
 
-	// Serve the actual server's process and operating system statistics as JSON.
+	// Serve the actual server's process and operating system statistics,
+	// the per-route statistics and, e.g. with "?history=5m", the history as JSON.
 	app.Post("/monitor", m.Stats)
 	// Render with the default page.
 	app.Get("/monitor", m.View)
@@ -430,6 +430,16 @@ var (
 	//
 	// A shortcut for the `context#NewConditionalHandler`.
 	NewConditionalHandler = context.NewConditionalHandler
+	// NewSlogHandler returns a log/slog Handler which writes to a golog logger,
+	// e.g. the Application's Logger.
+	//
+	// A shortcut for the `context#NewSlogHandler`.
+	NewSlogHandler = context.NewSlogHandler
+	// NewGologHandler returns a golog Handler which sends the logs to a log/slog Handler.
+	// Register it through Application.Logger().Handle to back the Application's Logger by any slog Handler.
+	//
+	// A shortcut for the `context#NewGologHandler`.
+	NewGologHandler = context.NewGologHandler
 	// FileServer returns a Handler which serves files from a specific system, phyisical, directory
 	// or an embedded one.
 	// The first parameter is the directory, relative to the executable program.
@@ -9,9 +9,11 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kataras/iris/v12/context"
+	jwtmiddleware "github.com/kataras/iris/v12/middleware/jwt"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/securecookie"
@@ -54,8 +56,18 @@ type (
 		transformer Transformer[T]
 		// Not nil if a custom claims provider is registered.
 		claimsProvider ClaimsProvider
+		// Not nil if a provider which completes the MFAProvider is registered.
+		mfaProvider MFAProvider[T]
+		// The verification attempts of the "mfa-pending" tokens, by token id.
+		// The lock guards the MFA updates too, see VerifyMFA.
+		mfaAttempts map[string]*mfaAttempt
+		mfaMu       sync.Mutex
 		// True if KIDRefresh on config.Keys.
 		refreshEnabled bool
+		// Not nil if refresh token rotation is enabled, see SetRefreshRotation.
+		refreshStore   jwtmiddleware.Blocklist
+		onRefreshReuse RefreshReuseFunc[T]
+		rotateMu       sync.Mutex
 	}
 
 	// VerifyUserFunc is passed on Verify and VerifyHandler method
@@ -74,8 +86,11 @@ type (
 	// to the client on the SignHandler. It contains a pair of the access token
 	// and the refresh token if the refresh jwt token id exists in the configuration.
 	SigninResponse struct {
-		AccessToken  string `json:"access_token"`
+		AccessToken  string `json:"access_token,omitempty"`
 		RefreshToken string `json:"refresh_token,omitempty"`
+		// MFAToken is the "mfa-pending" token which is sent instead of the
+		// access and refresh tokens when the user has to verify a second factor.
+		MFAToken string `json:"mfa_token,omitempty"`
 	}
 
 	// RefreshRequest is the request body the server expects
@@ -189,6 +204,12 @@ func (s *Auth[T]) AddProvider(providers ...Provider[T]) *Auth[T] {
 				s.claimsProvider = claimsProvider
 			}
 		}
+
+		if s.mfaProvider == nil {
+			if mfaProvider, ok := p.(MFAProvider[T]); ok {
+				s.mfaProvider = mfaProvider
+			}
+		}
 	}
 
 	s.providers = append(s.providers, providers...)
@@ -220,7 +241,38 @@ func (s *Auth[T]) SetTransformerFunc(transfermerFunc func(ctx stdContext.Context
 //
 // Signin calls the Provider.Signin method to check if a user
 // is authenticated by the given username and password combination.
+//
+// If the user has enrolled a second factor (see `MFAProvider`)
+// it returns an error of `ErrMFARequired`, use the
+// SigninHandler and VerifyMFAHandler instead.
 func (s *Auth[T]) Signin(ctx stdContext.Context, username, password string) ([]byte, []byte, error) {
+	t, err := s.signin(ctx, username, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.mfaProvider != nil {
+		enrolled, err := s.mfaEnrolled(ctx, t)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auth: signin: %w", err)
+		}
+
+		if enrolled {
+			return nil, nil, fmt.Errorf("auth: signin: %w", ErrMFARequired)
+		}
+	}
+
+	// sign the tokens.
+	accessToken, refreshToken, err := s.sign(t)
+	if err != nil {
+		return nil, nil, fmt.Errorf("auth: signin: %w", err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// signin calls the Provider.Signin method to find the user.
+func (s *Auth[T]) signin(ctx stdContext.Context, username, password string) (T, error) {
 	var t T
 
 	// get "t" from a valid provider.
@@ -231,7 +283,7 @@ func (s *Auth[T]) Signin(ctx stdContext.Context, username, password string) ([]b
 			v, err := p.Signin(ctx, username, password)
 			if err != nil {
 				if i == n-1 { // last provider errored.
-					return nil, nil, fmt.Errorf("auth: signin: %w", err)
+					return t, fmt.Errorf("auth: signin: %w", err)
 				}
 				// keep searching.
 				continue
@@ -242,19 +294,19 @@ func (s *Auth[T]) Signin(ctx stdContext.Context, username, password string) ([]b
 			break
 		}
 	} else {
-		return nil, nil, fmt.Errorf("auth: signin: no provider")
-	}
-
-	// sign the tokens.
-	accessToken, refreshToken, err := s.sign(t)
-	if err != nil {
-		return nil, nil, fmt.Errorf("auth: signin: %w", err)
+		return t, fmt.Errorf("auth: signin: no provider")
 	}
 
-	return accessToken, refreshToken, nil
+	return t, nil
 }
 
 func (s *Auth[T]) sign(t T) ([]byte, []byte, error) {
+	return s.signFamily(t, "")
+}
+
+// signFamily is like sign but it signs the tokens under the given token family
+// when refresh token rotation is enabled. An empty family starts a new one.
+func (s *Auth[T]) signFamily(t T, family string) ([]byte, []byte, error) {
 	// sign the tokens.
 	var (
 		accessStdClaims  StandardClaims
@@ -291,14 +343,27 @@ func (s *Auth[T]) sign(t T) ([]byte, []byte, error) {
 		refreshStdClaims.OriginID = accessStdClaims.ID
 	}
 
-	accessToken, err := s.keys.SignToken(KIDAccess, t, accessStdClaims)
+	var claims any = t
+	if s.refreshStore != nil && s.refreshEnabled {
+		if family == "" {
+			family = uuid.NewString()
+		}
+
+		familyClaimsBytes, err := jwt.Merge(t, familyClaims{Family: family})
+		if err != nil {
+			return nil, nil, fmt.Errorf("family: %w", err)
+		}
+		claims = familyClaimsBytes
+	}
+
+	accessToken, err := s.keys.SignToken(KIDAccess, claims, accessStdClaims)
 	if err != nil {
 		return nil, nil, fmt.Errorf("access: %w", err)
 	}
 
 	var refreshToken []byte
 	if s.refreshEnabled {
-		refreshToken, err = s.keys.SignToken(KIDRefresh, t, refreshStdClaims)
+		refreshToken, err = s.keys.SignToken(KIDRefresh, claims, refreshStdClaims)
 		if err != nil {
 			return nil, nil, fmt.Errorf("refresh: %w", err)
 		}
@@ -309,6 +374,9 @@ func (s *Auth[T]) sign(t T) ([]byte, []byte, error) {
 
 // SignHandler generates and sends a pair of access and refresh token to the client
 // as JSON body of `SigninResponse` and cookie (if cookie setting was provided).
+// If the user has enrolled a second factor then the response contains
+// only a short-lived MFAToken which should be sent to the VerifyMFAHandler
+// along with a TOTP or a recovery code to receive the tokens.
 // See `Signin` method for more.
 func (s *Auth[T]) SigninHandler(ctx *context.Context) {
 	// No, let the developer decide it based on a middleware, e.g. iris.LimitRequestBodySize.
@@ -335,13 +403,39 @@ func (s *Auth[T]) SigninHandler(ctx *context.Context) {
 		req.Username = req.Email
 	}
 
-	accessTokenBytes, refreshTokenBytes, err := s.Signin(ctx, req.Username, req.Password)
+	t, err := s.signin(ctx, req.Username, req.Password)
 	if err != nil {
 		s.tryRemoveCookie(ctx) // remove cookie on invalidated.
 
 		s.errorHandler.Unauthenticated(ctx, err)
 		return
 	}
+
+	if s.mfaProvider != nil {
+		// the second factor should be verified first, see VerifyMFAHandler.
+		mfaToken, err := s.mfaPendingToken(ctx, t)
+		if err != nil {
+			s.tryRemoveCookie(ctx)
+
+			s.errorHandler.Unauthenticated(ctx, err)
+			return
+		}
+
+		if mfaToken != "" {
+			s.tryRemoveCookie(ctx)
+
+			ctx.JSON(SigninResponse{MFAToken: mfaToken})
+			return
+		}
+	}
+
+	accessTokenBytes, refreshTokenBytes, err := s.sign(t)
+	if err != nil {
+		s.tryRemoveCookie(ctx)
+
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: signin: %w", err))
+		return
+	}
 	accessToken := jwt.BytesToString(accessTokenBytes)
 	refreshToken := jwt.BytesToString(refreshTokenBytes)
 
@@ -376,24 +470,40 @@ func (s *Auth[T]) Verify(ctx stdContext.Context, token []byte, verifyFuncs ...Ve
 }
 
 func (s *Auth[T]) verify(ctx stdContext.Context, token []byte) (T, StandardClaims, error) {
+	t, verifiedToken, err := s.verifyToken(ctx, token)
+	if err != nil {
+		return t, StandardClaims{}, err
+	}
+
+	return t, verifiedToken.StandardClaims, nil
+}
+
+func (s *Auth[T]) verifyToken(ctx stdContext.Context, token []byte) (T, *VerifiedToken, error) {
 	var t T
 
 	if len(token) == 0 { // should never happen at this state.
-		return t, StandardClaims{}, jwt.ErrMissing
+		return t, nil, jwt.ErrMissing
 	}
 
 	verifiedToken, err := jwt.VerifyWithHeaderValidator(nil, nil, token, s.keys.ValidateHeader, jwt.Future(time.Minute), jwt.Leeway(time.Minute))
 	if err != nil {
-		return t, StandardClaims{}, err
+		return t, nil, err
 	}
 
 	if s.transformer != nil {
 		if t, err = s.transformer.Transform(ctx, verifiedToken); err != nil {
-			return t, StandardClaims{}, err
+			return t, nil, err
 		}
 	} else {
 		if err = verifiedToken.Claims(&t); err != nil {
-			return t, StandardClaims{}, err
+			return t, nil, err
+		}
+	}
+
+	if s.refreshStore != nil {
+		// reject the tokens of a revoked family.
+		if err = s.validateFamily(tokenFamily(verifiedToken)); err != nil {
+			return t, nil, err
 		}
 	}
 
@@ -406,7 +516,7 @@ func (s *Auth[T]) verify(ctx stdContext.Context, token []byte) (T, StandardClaim
 			err := p.ValidateToken(ctx, standardClaims, t)
 			if err != nil {
 				if i == n-1 { // last provider errored.
-					return t, StandardClaims{}, err
+					return t, nil, err
 				}
 				// keep searching.
 				continue
@@ -416,10 +526,10 @@ func (s *Auth[T]) verify(ctx stdContext.Context, token []byte) (T, StandardClaim
 			break
 		}
 	} else {
-		// return t, StandardClaims{}, fmt.Errorf("no provider")
+		// return t, nil, fmt.Errorf("no provider")
 	}
 
-	return t, standardClaims, nil
+	return t, verifiedToken, nil
 }
 
 // VerifyHandler verifies and sets the necessary information about the user(claims) and
@@ -471,19 +581,31 @@ func (s *Auth[T]) ExtractAccessToken(ctx *context.Context) string {
 
 // Refresh accepts a previously generated refresh token (from SigninHandler) and
 // returns a new access and refresh token pair.
+//
+// If refresh token rotation is enabled (see `SetRefreshRotation`) then the
+// given refresh token can be used only once, a second use revokes its token family
+// and returns an ErrRefreshTokenReused error.
 func (s *Auth[T]) Refresh(ctx stdContext.Context, refreshToken []byte) ([]byte, []byte, error) {
 	if !s.refreshEnabled {
 		return nil, nil, fmt.Errorf("auth: refresh: disabled")
 	}
 
-	t, _, err := s.verify(ctx, refreshToken)
+	t, verifiedToken, err := s.verifyToken(ctx, refreshToken)
 	if err != nil {
 		return nil, nil, fmt.Errorf("auth: refresh: %w", err)
 	}
 
+	var family string
+	if s.refreshStore != nil {
+		family = tokenFamily(verifiedToken)
+		if err = s.rotate(ctx, t, verifiedToken, family); err != nil {
+			return nil, nil, fmt.Errorf("auth: refresh: %w", err)
+		}
+	}
+
 	// refresh the tokens, both refresh & access tokens will be renew to prevent
 	// malicious 😈 users that may hold a refresh token.
-	accessTok, refreshTok, err := s.sign(t)
+	accessTok, refreshTok, err := s.signFamily(t, family)
 	if err != nil {
 		return nil, nil, fmt.Errorf("auth: refresh: %w", err)
 	}
@@ -526,11 +648,19 @@ func (s *Auth[T]) RefreshHandler(ctx *context.Context) {
 // the signout should be applied to all tokens generated for a specific user (logout from all devices)
 // or just the provided token's one.
 // It calls the Provider's InvalidateToken(all=false) or InvalidateTokens (all=true).
+// If refresh token rotation is enabled then the token's family is revoked too.
 func (s *Auth[T]) Signout(ctx stdContext.Context, token []byte, all bool) error {
-	t, standardClaims, err := s.verify(ctx, token)
+	t, verifiedToken, err := s.verifyToken(ctx, token)
 	if err != nil {
 		return fmt.Errorf("auth: signout: verify: %w", err)
 	}
+	standardClaims := verifiedToken.StandardClaims
+
+	if s.refreshStore != nil {
+		if err = s.revokeFamily(token, tokenFamily(verifiedToken)); err != nil {
+			return fmt.Errorf("auth: signout: %w", err)
+		}
+	}
 
 	for i, n := 0, len(s.providers)-1; i <= n; i++ {
 		p := s.providers[i]
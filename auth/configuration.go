@@ -40,6 +40,9 @@ type (
 		// Keys MUST define the jwt keys configuration for access,
 		// and optionally, for refresh tokens signing and verification.
 		Keys jwt.KeysConfiguration `json:"keys" yaml:"Keys" toml:"Keys" ini:"keys"`
+		// MFA optional configuration for the TOTP two-factor authentication.
+		// It's used when a registered Provider completes the MFAProvider interface.
+		MFA MFAConfiguration `json:"mfa" yaml:"MFA" toml:"MFA" ini:"mfa"`
 	}
 
 	// CookieConfiguration holds the necessary information for cookie client storage.
@@ -83,6 +86,8 @@ func (c *Configuration) validate() (jwt.Keys, error) {
 		return nil, fmt.Errorf("auth: configuration: %s access token is missing from the configuration", KIDAccess)
 	}
 
+	c.MFA.setDefaults()
+
 	// Let's keep refresh optional.
 	// if _, ok := keys[KIDRefresh]; !ok {
 	// 	return nil, fmt.Errorf("auth: configuration: %s refresh token is missing from the configuration", KIDRefresh)
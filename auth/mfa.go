@@ -0,0 +1,536 @@
+//go:build go1.18
+// +build go1.18
+
+package auth
+
+import (
+	stdContext "context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+
+	"github.com/google/uuid"
+	"github.com/kataras/jwt"
+)
+
+// ErrMFARequired is returned by the Signin method
+// when the user has to verify a second factor.
+var ErrMFARequired = errors.New("mfa required")
+
+// errMFAInvalidCode is returned by useMFACode when the code is not valid,
+// it counts as a failed attempt.
+var errMFAInvalidCode = errors.New("invalid code")
+
+type (
+	// MFA holds the TOTP second factor information of a user.
+	// It's stored and loaded by the MFAProvider.
+	MFA struct {
+		// Secret is the base32 encoded TOTP secret.
+		// An empty secret means that the user has not enrolled a second factor.
+		Secret string `json:"secret"`
+		// RecoveryCodes holds the hashes of the unused one-time recovery codes.
+		RecoveryCodes []string `json:"recovery_codes"`
+		// LastCounter is the time step counter of the last accepted TOTP code,
+		// it protects from code replays.
+		LastCounter int64 `json:"last_counter"`
+	}
+
+	// MFAProvider is an optional interface which can be implemented by a Provider
+	// to enable the TOTP two-factor authentication step.
+	// When it's implemented, the SigninHandler sends a short-lived "mfa-pending" token,
+	// instead of the access and refresh tokens, to the users who have enrolled a second factor.
+	// The tokens are sent by the VerifyMFAHandler after a valid TOTP or recovery code.
+	//
+	// The first input argument standard context can be
+	// casted to iris.Context if executed through the Auth's handlers.
+	MFAProvider[T User] interface {
+		// GetMFA should return the stored MFA information of the "t" user.
+		// It should return an empty MFA value if the user has not enrolled yet.
+		GetMFA(ctx stdContext.Context, t T) (MFA, error)
+		// SetMFA should store the MFA information of the "t" user.
+		// It's called on enrollment and every time a code is used.
+		SetMFA(ctx stdContext.Context, t T, mfa MFA) error
+	}
+
+	// VerifyMFARequest is the request body the server expects on VerifyMFAHandler.
+	// The MFAToken and a Code or a RecoveryCode should be filled.
+	VerifyMFARequest struct {
+		MFAToken     string `json:"mfa_token" form:"mfa_token"`
+		Code         string `json:"code" form:"code,omitempty"`
+		RecoveryCode string `json:"recovery_code" form:"recovery_code,omitempty"`
+	}
+
+	// EnrollMFARequest is the request body the server expects on EnrollMFAHandler
+	// when the user has already enrolled a second factor.
+	// A Code or a RecoveryCode of the current second factor should be filled to replace it.
+	EnrollMFARequest struct {
+		Code         string `json:"code" form:"code,omitempty"`
+		RecoveryCode string `json:"recovery_code" form:"recovery_code,omitempty"`
+	}
+
+	// EnrollMFAResponse is the response body the server sends on EnrollMFAHandler.
+	// The URI is usually rendered as a QR code. The EnrollToken should be sent
+	// back to the ConfirmMFAHandler, along with a code, to complete the enrollment.
+	EnrollMFAResponse struct {
+		Secret      string `json:"secret"`
+		URI         string `json:"uri"`
+		EnrollToken string `json:"enroll_token"`
+	}
+
+	// ConfirmMFARequest is the request body the server expects on ConfirmMFAHandler.
+	ConfirmMFARequest struct {
+		EnrollToken string `json:"enroll_token" form:"enroll_token"`
+		Code        string `json:"code" form:"code"`
+	}
+
+	// ConfirmMFAResponse is the response body the server sends on ConfirmMFAHandler.
+	// The recovery codes are shown only once.
+	ConfirmMFAResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	// mfaPending is the encrypted value of the "mfa-pending" token.
+	mfaPending struct {
+		ID      string // the attempts are counted by id.
+		User    []byte
+		Expires int64
+	}
+
+	// mfaEnroll is the encrypted value of the enrollment token.
+	mfaEnroll struct {
+		Secret   string
+		TokenID  string // the access token's id, it binds the enrollment to the user.
+		Replaces string // the hash of the current secret, empty if the user has not enrolled yet.
+		Expires  int64
+	}
+
+	// mfaAttempt holds the verification attempts of an "mfa-pending" token
+	// or of the re-enrollments of an access token.
+	mfaAttempt struct {
+		failures int
+		used     bool
+		expires  int64
+	}
+)
+
+const (
+	mfaPendingTokenName = "iris_auth_mfa_pending"
+	mfaEnrollTokenName  = "iris_auth_mfa_enroll"
+)
+
+func (s *Auth[T]) mfaEnrolled(ctx stdContext.Context, t T) (bool, error) {
+	mfa, err := s.mfaProvider.GetMFA(ctx, t)
+	if err != nil {
+		return false, fmt.Errorf("mfa: %w", err)
+	}
+
+	return mfa.Secret != "", nil
+}
+
+// mfaPendingToken returns the encrypted "mfa-pending" token of "t",
+// or an empty string if the user has not enrolled a second factor.
+// The token can be only decrypted by the server and it's not a valid access token.
+func (s *Auth[T]) mfaPendingToken(ctx stdContext.Context, t T) (string, error) {
+	enrolled, err := s.mfaEnrolled(ctx, t)
+	if err != nil || !enrolled {
+		return "", err
+	}
+
+	user, err := json.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("mfa: %w", err)
+	}
+
+	token, err := s.securecookie.Encode(mfaPendingTokenName, mfaPending{
+		ID:      uuid.NewString(),
+		User:    user,
+		Expires: time.Now().Add(s.config.MFA.PendingMaxAge).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("mfa: pending token (cookie hash and block are required): %w", err)
+	}
+
+	return token, nil
+}
+
+// VerifyMFA accepts the "mfa-pending" token sent by the SigninHandler
+// and a TOTP code or a one-time recovery code
+// and returns a new access and refresh token pair.
+// The token is rejected after its first successful verification
+// or after MFA.MaxAttempts invalid codes.
+func (s *Auth[T]) VerifyMFA(ctx stdContext.Context, mfaToken, code, recoveryCode string) ([]byte, []byte, error) {
+	if s.mfaProvider == nil {
+		return nil, nil, fmt.Errorf("auth: mfa: no provider")
+	}
+
+	var pending mfaPending
+	if err := s.securecookie.Decode(mfaPendingTokenName, mfaToken, &pending); err != nil {
+		return nil, nil, fmt.Errorf("auth: mfa: invalid token: %w", err)
+	}
+
+	now := time.Now().Unix()
+	if pending.ID == "" || now > pending.Expires {
+		return nil, nil, fmt.Errorf("auth: mfa: token expired")
+	}
+
+	var t T
+	if err := json.Unmarshal(pending.User, &t); err != nil {
+		return nil, nil, fmt.Errorf("auth: mfa: %w", err)
+	}
+
+	s.mfaMu.Lock()
+	defer s.mfaMu.Unlock()
+
+	attempt := s.mfaAttempt(pending.ID, pending.Expires, now)
+	if attempt.used {
+		return nil, nil, fmt.Errorf("auth: mfa: token already used")
+	}
+
+	if attempt.failures >= s.config.MFA.MaxAttempts {
+		return nil, nil, fmt.Errorf("auth: mfa: too many attempts")
+	}
+
+	if err := s.useMFACode(ctx, t, code, recoveryCode); err != nil {
+		if errors.Is(err, errMFAInvalidCode) {
+			attempt.failures++
+		}
+
+		return nil, nil, fmt.Errorf("auth: mfa: %w", err)
+	}
+	attempt.used = true
+
+	accessToken, refreshToken, err := s.sign(t)
+	if err != nil {
+		return nil, nil, fmt.Errorf("auth: mfa: %w", err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// useMFACode validates a TOTP or a recovery code of the "t" user's second factor
+// and stores its new state, so the same code is not accepted twice.
+// The caller should hold the mfaMu lock.
+func (s *Auth[T]) useMFACode(ctx stdContext.Context, t T, code, recoveryCode string) error {
+	mfa, err := s.mfaProvider.GetMFA(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	if mfa.Secret == "" {
+		return fmt.Errorf("not enrolled")
+	}
+
+	switch {
+	case code != "":
+		counter, ok := s.config.MFA.ValidateTOTP(mfa.Secret, code, time.Now(), mfa.LastCounter)
+		if !ok {
+			return errMFAInvalidCode
+		}
+		mfa.LastCounter = counter
+	case recoveryCode != "":
+		if !mfa.useRecoveryCode(recoveryCode) {
+			return errMFAInvalidCode
+		}
+	default:
+		return fmt.Errorf("code is missing")
+	}
+
+	// store the new counter or the remaining recovery codes.
+	return s.mfaProvider.SetMFA(ctx, t, mfa)
+}
+
+// mfaAttempt returns the attempts of the given id and
+// removes the expired ones. The caller should hold the mfaMu lock.
+func (s *Auth[T]) mfaAttempt(id string, expires, now int64) *mfaAttempt {
+	if s.mfaAttempts == nil {
+		s.mfaAttempts = make(map[string]*mfaAttempt)
+	}
+
+	for k, a := range s.mfaAttempts {
+		if now > a.expires {
+			delete(s.mfaAttempts, k)
+		}
+	}
+
+	attempt, ok := s.mfaAttempts[id]
+	if !ok {
+		attempt = &mfaAttempt{expires: expires}
+		s.mfaAttempts[id] = attempt
+	}
+
+	return attempt
+}
+
+// mfaSecretHash returns the hash of a TOTP secret, it's stored in the enrollment token
+// to detect a change of the second factor before the enrollment is confirmed.
+func mfaSecretHash(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// useRecoveryCode removes the given recovery code and reports whether it was found.
+func (mfa *MFA) useRecoveryCode(code string) bool {
+	hash := []byte(HashRecoveryCode(code))
+
+	for i, h := range mfa.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), hash) == 1 {
+			mfa.RecoveryCodes = append(mfa.RecoveryCodes[:i:i], mfa.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyMFAHandler reads the request body which should include data for `VerifyMFARequest` structure
+// and sends a new access and refresh token pair as JSON body of `SigninResponse`,
+// also sets the cookie to the new encrypted access token value.
+// The "mfa-pending" token is rejected after MFA.MaxAttempts invalid codes.
+// See `VerifyMFA` method for more.
+func (s *Auth[T]) VerifyMFAHandler(ctx *context.Context) {
+	var (
+		req VerifyMFARequest
+		err error
+	)
+
+	switch ctx.GetContentTypeRequested() {
+	case context.ContentFormHeaderValue, context.ContentFormMultipartHeaderValue:
+		err = ctx.ReadForm(&req)
+	default:
+		err = ctx.ReadJSON(&req)
+	}
+
+	if err != nil {
+		s.errorHandler.InvalidArgument(ctx, err)
+		return
+	}
+
+	accessTokenBytes, refreshTokenBytes, err := s.VerifyMFA(ctx, req.MFAToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		s.errorHandler.Unauthenticated(ctx, err)
+		return
+	}
+
+	accessToken := jwt.BytesToString(accessTokenBytes)
+	refreshToken := jwt.BytesToString(refreshTokenBytes)
+
+	s.trySetCookie(ctx, accessToken)
+
+	resp := SigninResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+	ctx.JSON(resp)
+}
+
+// EnrollMFAHandler generates a new TOTP secret for the current user
+// and sends it as JSON body of `EnrollMFAResponse`.
+// If the user has already enrolled a second factor, the request body should include
+// data for `EnrollMFARequest` structure with a code of the current one,
+// the access token is rejected after MFA.MaxAttempts invalid codes.
+// The enrollment is completed through the ConfirmMFAHandler.
+// It should be registered after the VerifyHandler.
+func (s *Auth[T]) EnrollMFAHandler(ctx *context.Context) {
+	if s.mfaProvider == nil {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: no provider"))
+		return
+	}
+
+	claims := GetStandardClaims(ctx)
+	if claims.ID == "" {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: enroll: unauthenticated"))
+		return
+	}
+
+	t := s.GetUser(ctx)
+	mfa, err := s.mfaProvider.GetMFA(ctx, t)
+	if err != nil {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: enroll: %w", err))
+		return
+	}
+
+	if mfa.Secret != "" {
+		// A stolen access token should not be enough to replace the second factor.
+		var req EnrollMFARequest
+
+		switch ctx.GetContentTypeRequested() {
+		case context.ContentFormHeaderValue, context.ContentFormMultipartHeaderValue:
+			err = ctx.ReadForm(&req)
+		default:
+			err = ctx.ReadJSON(&req)
+		}
+
+		if err != nil {
+			s.errorHandler.InvalidArgument(ctx, err)
+			return
+		}
+
+		if err = s.verifyMFAReplacement(ctx, t, claims, req); err != nil {
+			s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: enroll: %w", err))
+			return
+		}
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: enroll: %w", err))
+		return
+	}
+
+	enrollToken, err := s.securecookie.Encode(mfaEnrollTokenName, mfaEnroll{
+		Secret:   secret,
+		TokenID:  claims.ID,
+		Replaces: mfaSecretHash(mfa.Secret),
+		Expires:  time.Now().Add(s.config.MFA.PendingMaxAge).Unix(),
+	})
+	if err != nil {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: enroll: %w", err))
+		return
+	}
+
+	resp := EnrollMFAResponse{
+		Secret:      secret,
+		URI:         s.config.MFA.URI(mfaAccountName(ctx, claims), secret),
+		EnrollToken: enrollToken,
+	}
+	ctx.JSON(resp)
+}
+
+// verifyMFAReplacement validates a code of the current second factor of "t"
+// before a new one is enrolled. The invalid codes are counted by the access token's id.
+func (s *Auth[T]) verifyMFAReplacement(ctx stdContext.Context, t T, claims StandardClaims, req EnrollMFARequest) error {
+	now := time.Now().Unix()
+	expires := claims.Expiry
+	if expires <= 0 {
+		expires = now + int64(s.config.MFA.PendingMaxAge/time.Second)
+	}
+
+	s.mfaMu.Lock()
+	defer s.mfaMu.Unlock()
+
+	attempt := s.mfaAttempt("enroll:"+claims.ID, expires, now)
+	if attempt.failures >= s.config.MFA.MaxAttempts {
+		return fmt.Errorf("too many attempts")
+	}
+
+	if err := s.useMFACode(ctx, t, req.Code, req.RecoveryCode); err != nil {
+		if errors.Is(err, errMFAInvalidCode) {
+			attempt.failures++
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmMFAHandler reads the request body which should include data for `ConfirmMFARequest` structure,
+// validates the code of the new secret, stores the second factor through the MFAProvider
+// and sends the one-time recovery codes as JSON body of `ConfirmMFAResponse`.
+// It should be registered after the VerifyHandler.
+func (s *Auth[T]) ConfirmMFAHandler(ctx *context.Context) {
+	if s.mfaProvider == nil {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: no provider"))
+		return
+	}
+
+	var (
+		req ConfirmMFARequest
+		err error
+	)
+
+	switch ctx.GetContentTypeRequested() {
+	case context.ContentFormHeaderValue, context.ContentFormMultipartHeaderValue:
+		err = ctx.ReadForm(&req)
+	default:
+		err = ctx.ReadJSON(&req)
+	}
+
+	if err != nil {
+		s.errorHandler.InvalidArgument(ctx, err)
+		return
+	}
+
+	var enroll mfaEnroll
+	if err = s.securecookie.Decode(mfaEnrollTokenName, req.EnrollToken, &enroll); err != nil {
+		s.errorHandler.InvalidArgument(ctx, fmt.Errorf("auth: mfa: confirm: invalid token: %w", err))
+		return
+	}
+
+	claims := GetStandardClaims(ctx)
+	if claims.ID == "" || claims.ID != enroll.TokenID || time.Now().Unix() > enroll.Expires {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: confirm: token expired"))
+		return
+	}
+
+	counter, ok := s.config.MFA.ValidateTOTP(enroll.Secret, req.Code, time.Now(), 0)
+	if !ok {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: confirm: invalid code"))
+		return
+	}
+
+	codes, hashes, err := GenerateRecoveryCodes(s.config.MFA.RecoveryCodes)
+	if err != nil {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: confirm: %w", err))
+		return
+	}
+
+	mfa := MFA{
+		Secret:        enroll.Secret,
+		RecoveryCodes: hashes,
+		LastCounter:   counter,
+	}
+
+	if err = s.confirmMFA(ctx, s.GetUser(ctx), enroll, mfa); err != nil {
+		s.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: mfa: confirm: %w", err))
+		return
+	}
+
+	resp := ConfirmMFAResponse{
+		RecoveryCodes: codes,
+	}
+	ctx.JSON(resp)
+}
+
+// confirmMFA stores the new second factor of "t" if the current one
+// is still the one the enrollment was started with.
+func (s *Auth[T]) confirmMFA(ctx stdContext.Context, t T, enroll mfaEnroll, mfa MFA) error {
+	s.mfaMu.Lock()
+	defer s.mfaMu.Unlock()
+
+	current, err := s.mfaProvider.GetMFA(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(mfaSecretHash(current.Secret)), []byte(enroll.Replaces)) != 1 {
+		return fmt.Errorf("second factor has been changed")
+	}
+
+	return s.mfaProvider.SetMFA(ctx, t, mfa)
+}
+
+// mfaAccountName returns the account name of the otpauth:// URI.
+func mfaAccountName(ctx *context.Context, claims StandardClaims) string {
+	if u := ctx.User(); u != nil {
+		if username, err := u.GetUsername(); err == nil && username != "" {
+			return username
+		}
+
+		if email, err := u.GetEmail(); err == nil && email != "" {
+			return email
+		}
+	}
+
+	return claims.Subject
+}
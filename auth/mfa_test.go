@@ -0,0 +1,214 @@
+//go:build go1.18
+// +build go1.18
+
+package auth_test
+
+import (
+	stdContext "context"
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/auth"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 Appendix B, SHA1.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	config := auth.MFAConfiguration{Digits: 8, Period: 30 * time.Second}
+
+	tests := map[int64]string{
+		59:         "94287082",
+		1111111109: "07081804",
+		1234567890: "89005924",
+		2000000000: "69279037",
+	}
+
+	for unix, expected := range tests {
+		code, err := config.TOTP(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != expected {
+			t.Fatalf("[%d] expected code %s but got %s", unix, expected, code)
+		}
+	}
+
+	now := time.Unix(1111111109, 0)
+	counter, ok := config.ValidateTOTP(secret, "07081804", now.Add(30*time.Second), 0)
+	if !ok {
+		t.Fatalf("expected previous period code to be valid")
+	}
+
+	if _, ok = config.ValidateTOTP(secret, "07081804", now, counter); ok {
+		t.Fatalf("expected code replay to be invalid")
+	}
+}
+
+type mfaUser struct {
+	Username string `json:"username"`
+}
+
+type mfaProvider struct {
+	mfa map[string]auth.MFA
+}
+
+func (p *mfaProvider) Signin(_ stdContext.Context, username, password string) (mfaUser, error) {
+	if password != "password" {
+		return mfaUser{}, errors.New("invalid credentials")
+	}
+
+	return mfaUser{Username: username}, nil
+}
+
+func (p *mfaProvider) ValidateToken(stdContext.Context, auth.StandardClaims, mfaUser) error {
+	return nil
+}
+
+func (p *mfaProvider) InvalidateToken(stdContext.Context, auth.StandardClaims, mfaUser) error {
+	return nil
+}
+
+func (p *mfaProvider) InvalidateTokens(stdContext.Context, mfaUser) error {
+	return nil
+}
+
+func (p *mfaProvider) GetMFA(_ stdContext.Context, u mfaUser) (auth.MFA, error) {
+	return p.mfa[u.Username], nil
+}
+
+func (p *mfaProvider) SetMFA(_ stdContext.Context, u mfaUser, mfa auth.MFA) error {
+	p.mfa[u.Username] = mfa
+	return nil
+}
+
+func TestMFA(t *testing.T) {
+	config := auth.MustGenerateConfiguration()
+	for i := range config.Keys {
+		config.Keys[i].EncryptionKey = ""
+	}
+	config.MFA.MaxAttempts = 3
+
+	s, err := auth.New[mfaUser](config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &mfaProvider{mfa: make(map[string]auth.MFA)}
+	s.AddProvider(provider)
+
+	app := iris.New()
+	app.Post("/signin", s.SigninHandler)
+	app.Post("/signin/mfa", s.VerifyMFAHandler)
+	protected := app.Party("/", s.VerifyHandler())
+	protected.Post("/mfa/enroll", s.EnrollMFAHandler)
+	protected.Post("/mfa/confirm", s.ConfirmMFAHandler)
+
+	e := httptest.New(t, app)
+
+	credentials := iris.Map{"username": "kataras", "password": "password"}
+
+	// Not enrolled yet.
+	var signin auth.SigninResponse
+	e.POST("/signin").WithJSON(credentials).Expect().Status(httptest.StatusOK).JSON().Decode(&signin)
+	if signin.AccessToken == "" || signin.MFAToken != "" {
+		t.Fatalf("expected an access token without mfa token but got: %#+v", signin)
+	}
+
+	var enroll auth.EnrollMFAResponse
+	e.POST("/mfa/enroll").WithHeader("Authorization", "Bearer "+signin.AccessToken).Expect().
+		Status(httptest.StatusOK).JSON().Decode(&enroll)
+
+	code, err := config.MFA.TOTP(enroll.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var confirm auth.ConfirmMFAResponse
+	e.POST("/mfa/confirm").WithHeader("Authorization", "Bearer "+signin.AccessToken).
+		WithJSON(auth.ConfirmMFARequest{EnrollToken: enroll.EnrollToken, Code: code}).Expect().
+		Status(httptest.StatusOK).JSON().Decode(&confirm)
+	if expected, got := 10, len(confirm.RecoveryCodes); expected != got {
+		t.Fatalf("expected %d recovery codes but got %d", expected, got)
+	}
+
+	// Enrolled, the second factor is required.
+	signin = auth.SigninResponse{}
+	e.POST("/signin").WithJSON(credentials).Expect().Status(httptest.StatusOK).JSON().Decode(&signin)
+	if signin.AccessToken != "" || signin.MFAToken == "" {
+		t.Fatalf("expected a mfa token without access token but got: %#+v", signin)
+	}
+
+	// The mfa token is not an access token.
+	e.POST("/mfa/enroll").WithHeader("Authorization", "Bearer "+signin.MFAToken).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	// The code was already used on confirmation.
+	e.POST("/signin/mfa").WithJSON(auth.VerifyMFARequest{MFAToken: signin.MFAToken, Code: code}).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	var verified auth.SigninResponse
+	e.POST("/signin/mfa").WithJSON(auth.VerifyMFARequest{MFAToken: signin.MFAToken, RecoveryCode: confirm.RecoveryCodes[0]}).Expect().
+		Status(httptest.StatusOK).JSON().Decode(&verified)
+	if verified.AccessToken == "" {
+		t.Fatalf("expected an access token")
+	}
+
+	// The mfa token is used once.
+	e.POST("/signin/mfa").WithJSON(auth.VerifyMFARequest{MFAToken: signin.MFAToken, RecoveryCode: confirm.RecoveryCodes[1]}).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	// A recovery code is used once.
+	signin = auth.SigninResponse{}
+	e.POST("/signin").WithJSON(credentials).Expect().Status(httptest.StatusOK).JSON().Decode(&signin)
+	e.POST("/signin/mfa").WithJSON(auth.VerifyMFARequest{MFAToken: signin.MFAToken, RecoveryCode: confirm.RecoveryCodes[0]}).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	// The mfa token is rejected after too many invalid codes.
+	for i := 1; i < config.MFA.MaxAttempts; i++ {
+		e.POST("/signin/mfa").WithJSON(auth.VerifyMFARequest{MFAToken: signin.MFAToken, RecoveryCode: "invalid"}).Expect().
+			Status(httptest.StatusUnauthorized)
+	}
+	e.POST("/signin/mfa").WithJSON(auth.VerifyMFARequest{MFAToken: signin.MFAToken, RecoveryCode: confirm.RecoveryCodes[1]}).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	// Replacing the second factor requires a code of the current one.
+	e.POST("/mfa/enroll").WithHeader("Authorization", "Bearer "+verified.AccessToken).WithJSON(auth.EnrollMFARequest{}).Expect().
+		Status(httptest.StatusUnauthorized)
+	e.POST("/mfa/enroll").WithHeader("Authorization", "Bearer "+verified.AccessToken).
+		WithJSON(auth.EnrollMFARequest{RecoveryCode: "invalid"}).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	enroll = auth.EnrollMFAResponse{}
+	e.POST("/mfa/enroll").WithHeader("Authorization", "Bearer "+verified.AccessToken).
+		WithJSON(auth.EnrollMFARequest{RecoveryCode: confirm.RecoveryCodes[1]}).Expect().
+		Status(httptest.StatusOK).JSON().Decode(&enroll)
+
+	var staleEnroll auth.EnrollMFAResponse
+	e.POST("/mfa/enroll").WithHeader("Authorization", "Bearer "+verified.AccessToken).
+		WithJSON(auth.EnrollMFARequest{RecoveryCode: confirm.RecoveryCodes[2]}).Expect().
+		Status(httptest.StatusOK).JSON().Decode(&staleEnroll)
+
+	code, err = config.MFA.TOTP(enroll.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.POST("/mfa/confirm").WithHeader("Authorization", "Bearer "+verified.AccessToken).
+		WithJSON(auth.ConfirmMFARequest{EnrollToken: enroll.EnrollToken, Code: code}).Expect().
+		Status(httptest.StatusOK)
+
+	// The second factor has been changed since the enrollment token was issued.
+	code, err = config.MFA.TOTP(staleEnroll.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.POST("/mfa/confirm").WithHeader("Authorization", "Bearer "+verified.AccessToken).
+		WithJSON(auth.ConfirmMFARequest{EnrollToken: staleEnroll.EnrollToken, Code: code}).Expect().
+		Status(httptest.StatusUnauthorized)
+}
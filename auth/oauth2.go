@@ -0,0 +1,686 @@
+//go:build go1.18
+// +build go1.18
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+
+	"github.com/kataras/jwt"
+)
+
+type (
+	// OAuth2Configuration holds the necessary information for
+	// the OAuth2 authorization code flow against an external provider.
+	// If Issuer is set then the provider is treated as an OpenID Connect one
+	// and its endpoints are discovered through the
+	// Issuer + "/.well-known/openid-configuration" document.
+	//
+	// See `Auth.NewOAuth2` method and the `GitHubOAuth2Configuration` and
+	// `GoogleOAuth2Configuration` package-level functions.
+	OAuth2Configuration struct {
+		// Name is the unique name of the provider, e.g. "google".
+		// It's used to name the state cookie.
+		Name string `json:"name" yaml:"Name" toml:"Name" ini:"name"`
+		// ClientID is the application's ID.
+		ClientID string `json:"client_id" yaml:"ClientID" toml:"ClientID" ini:"client_id"`
+		// ClientSecret is the application's secret.
+		ClientSecret string `json:"client_secret" yaml:"ClientSecret" toml:"ClientSecret" ini:"client_secret"`
+		// RedirectURL is the URL of the callback handler, e.g. https://example.com/auth/google/callback.
+		RedirectURL string `json:"redirect_url" yaml:"RedirectURL" toml:"RedirectURL" ini:"redirect_url"`
+		// Scopes specifies optional requested permissions.
+		// The "openid" scope is always sent to OpenID Connect providers.
+		Scopes []string `json:"scopes" yaml:"Scopes" toml:"Scopes" ini:"scopes"`
+
+		// Issuer is the OpenID Connect issuer URL, e.g. https://accounts.google.com.
+		Issuer string `json:"issuer" yaml:"Issuer" toml:"Issuer" ini:"issuer"`
+		// AuthURL is the authorization endpoint. Discovered when Issuer is set.
+		AuthURL string `json:"auth_url" yaml:"AuthURL" toml:"AuthURL" ini:"auth_url"`
+		// TokenURL is the token endpoint. Discovered when Issuer is set.
+		TokenURL string `json:"token_url" yaml:"TokenURL" toml:"TokenURL" ini:"token_url"`
+		// UserInfoURL is the optional user info endpoint. When the provider does not
+		// send an ID token (plain OAuth2, e.g. GitHub) the user info response
+		// is used as the claims of the user instead.
+		UserInfoURL string `json:"user_info_url" yaml:"UserInfoURL" toml:"UserInfoURL" ini:"user_info_url"`
+		// JWKSURL is the URL of the provider's JSON Web Key Set which is used to
+		// verify the ID tokens. Discovered when Issuer is set.
+		JWKSURL string `json:"jwks_url" yaml:"JWKSURL" toml:"JWKSURL" ini:"jwks_url"`
+
+		// DisablePKCE disables the Proof Key for Code Exchange (RFC 7636),
+		// for providers which do not support it.
+		DisablePKCE bool `json:"disable_pkce" yaml:"DisablePKCE" toml:"DisablePKCE" ini:"disable_pkce"`
+		// SigninRedirect is an optional URL to redirect the client after a successful sign in,
+		// instead of sending the SigninResponse JSON.
+		SigninRedirect string `json:"signin_redirect" yaml:"SigninRedirect" toml:"SigninRedirect" ini:"signin_redirect"`
+	}
+
+	// OAuth2 holds the authorization code flow handlers of an external
+	// OAuth2 or OpenID Connect provider for an Auth of T instance.
+	// The claims of the external user are converted to T through
+	// the Auth's Transformer (or the json claims when a transformer is missing)
+	// and then Auth signs its own pair of access and refresh tokens.
+	//
+	// Initialize with the `Auth.NewOAuth2` method.
+	OAuth2[T User] struct {
+		// HTTPClient is the client which talks to the provider.
+		// Defaults to a client with 15 seconds timeout.
+		HTTPClient *http.Client
+
+		auth   *Auth[T]
+		config OAuth2Configuration
+
+		mu               sync.RWMutex
+		discovered       bool
+		keys             jwt.Keys
+		keysLastFetched  time.Time
+		keysMinFetchTime time.Duration
+	}
+
+	// oauth2State is the value of the state cookie.
+	oauth2State struct {
+		State        string `json:"state"`
+		Nonce        string `json:"nonce,omitempty"`
+		CodeVerifier string `json:"code_verifier,omitempty"`
+	}
+
+	// oauth2TokenResponse is the response of the token endpoint.
+	oauth2TokenResponse struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	// openIDConfiguration is the OpenID Connect discovery document.
+	openIDConfiguration struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+)
+
+// GitHubOAuth2Configuration returns an OAuth2Configuration for GitHub.
+// The claims of the user are the response of the https://api.github.com/user endpoint.
+func GitHubOAuth2Configuration(clientID, clientSecret, redirectURL string) OAuth2Configuration {
+	return OAuth2Configuration{
+		Name:         "github",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"read:user", "user:email"},
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		UserInfoURL:  "https://api.github.com/user",
+	}
+}
+
+// GoogleOAuth2Configuration returns an OpenID Connect OAuth2Configuration for Google.
+// The claims of the user are the claims of the ID token.
+func GoogleOAuth2Configuration(clientID, clientSecret, redirectURL string) OAuth2Configuration {
+	return OAuth2Configuration{
+		Name:         "google",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		Issuer:       "https://accounts.google.com",
+	}
+}
+
+// NewOAuth2 returns a new OAuth2 authorization code flow for the given provider's configuration.
+// The Auth's Configuration.Cookie.Hash and Block fields are required
+// because the state, nonce and PKCE code verifier are stored to an encrypted cookie.
+//
+// Usage:
+//
+//	google, err := s.NewOAuth2(auth.GoogleOAuth2Configuration(id, secret, "https://example.com/auth/google/callback"))
+//	app.Get("/auth/google", google.LoginHandler)
+//	app.Get("/auth/google/callback", google.CallbackHandler)
+func (s *Auth[T]) NewOAuth2(config OAuth2Configuration) (*OAuth2[T], error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("auth: oauth2: name is required")
+	}
+
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("auth: oauth2: %s: client id and redirect url are required", config.Name)
+	}
+
+	if config.Issuer == "" && (config.AuthURL == "" || config.TokenURL == "") {
+		return nil, fmt.Errorf("auth: oauth2: %s: issuer or auth and token urls are required", config.Name)
+	}
+
+	if s.config.Cookie.Hash == "" || s.config.Cookie.Block == "" {
+		return nil, fmt.Errorf("auth: oauth2: %s: cookie block and cookie hash are required to store the state", config.Name)
+	}
+
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	o := &OAuth2[T]{
+		HTTPClient:       &http.Client{Timeout: 15 * time.Second},
+		auth:             s,
+		config:           config,
+		discovered:       config.Issuer == "",
+		keysMinFetchTime: time.Minute,
+	}
+
+	return o, nil
+}
+
+// Config returns the provider's configuration.
+func (o *OAuth2[T]) Config() OAuth2Configuration {
+	o.mu.RLock()
+	config := o.config
+	o.mu.RUnlock()
+	return config
+}
+
+func (o *OAuth2[T]) stateCookieName() string {
+	return "iris_auth_oauth2_" + o.config.Name
+}
+
+// AuthCodeURL returns the URL of the provider's consent page
+// for the given state, nonce and PKCE code verifier.
+func (o *OAuth2[T]) AuthCodeURL(state, nonce, codeVerifier string) (string, error) {
+	if err := o.discover(); err != nil {
+		return "", err
+	}
+
+	config := o.Config()
+
+	scopes := config.Scopes
+	if config.Issuer != "" && !containsString(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {config.ClientID},
+		"redirect_uri":  {config.RedirectURL},
+		"state":         {state},
+	}
+
+	if len(scopes) > 0 {
+		query.Set("scope", strings.Join(scopes, " "))
+	}
+
+	if nonce != "" {
+		query.Set("nonce", nonce)
+	}
+
+	if codeVerifier != "" {
+		query.Set("code_challenge", codeChallengeS256(codeVerifier))
+		query.Set("code_challenge_method", "S256")
+	}
+
+	authURL := config.AuthURL
+	if strings.Contains(authURL, "?") {
+		authURL += "&"
+	} else {
+		authURL += "?"
+	}
+
+	return authURL + query.Encode(), nil
+}
+
+// LoginHandler redirects the client to the provider's consent page.
+// The state, nonce and PKCE code verifier are stored to an encrypted cookie
+// which is validated and removed by the CallbackHandler.
+func (o *OAuth2[T]) LoginHandler(ctx *context.Context) {
+	st := oauth2State{
+		State: randomString(),
+	}
+
+	if o.config.Issuer != "" {
+		st.Nonce = randomString()
+	}
+
+	if !o.config.DisablePKCE {
+		st.CodeVerifier = randomString()
+	}
+
+	redirectURL, err := o.AuthCodeURL(st.State, st.Nonce, st.CodeVerifier)
+	if err != nil {
+		o.auth.errorHandler.Unauthenticated(ctx, err)
+		return
+	}
+
+	value, err := json.Marshal(st)
+	if err != nil {
+		o.auth.errorHandler.Unauthenticated(ctx, err)
+		return
+	}
+
+	cookie := &http.Cookie{
+		Name:     o.stateCookieName(),
+		Value:    base64.RawURLEncoding.EncodeToString(value),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   o.auth.config.Cookie.Secure || ctx.IsSSL(),
+		// Lax so the cookie is sent on the top-level redirect back from the provider.
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int((10 * time.Minute).Seconds()),
+	}
+	ctx.SetCookie(cookie, context.CookieEncoding(o.auth.securecookie))
+
+	ctx.Redirect(redirectURL, http.StatusFound)
+}
+
+// CallbackHandler validates the state, exchanges the authorization code with tokens,
+// verifies the ID token (OpenID Connect) or fetches the user info (OAuth2),
+// converts the claims to T and signs a new access and refresh token pair.
+// The tokens are sent as JSON body of `SigninResponse` (or the client is redirected
+// to the configured SigninRedirect) and the access token cookie is set.
+// If the user has enrolled a second factor, the "mfa-pending" token is sent instead,
+// as JSON body of `SigninResponse`, see VerifyMFAHandler.
+func (o *OAuth2[T]) CallbackHandler(ctx *context.Context) {
+	if errCode := ctx.URLParam("error"); errCode != "" {
+		o.auth.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: oauth2: %s: %s: %s", o.config.Name, errCode, ctx.URLParam("error_description")))
+		return
+	}
+
+	cookieName := o.stateCookieName()
+	cookieValue := ctx.GetCookie(cookieName, context.CookieEncoding(o.auth.securecookie))
+	ctx.RemoveCookie(cookieName)
+
+	var st oauth2State
+	if err := decodeOAuth2State(cookieValue, &st); err != nil {
+		o.auth.errorHandler.InvalidArgument(ctx, fmt.Errorf("auth: oauth2: %s: state: %w", o.config.Name, err))
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(st.State), []byte(ctx.URLParam("state"))) != 1 {
+		o.auth.errorHandler.InvalidArgument(ctx, fmt.Errorf("auth: oauth2: %s: state mismatch", o.config.Name))
+		return
+	}
+
+	code := ctx.URLParam("code")
+	if code == "" {
+		o.auth.errorHandler.InvalidArgument(ctx, fmt.Errorf("auth: oauth2: %s: code is missing", o.config.Name))
+		return
+	}
+
+	t, err := o.Exchange(ctx, code, st.Nonce, st.CodeVerifier)
+	if err != nil {
+		o.auth.errorHandler.Unauthenticated(ctx, err)
+		return
+	}
+
+	if o.auth.mfaProvider != nil {
+		// the second factor should be verified first, see VerifyMFAHandler.
+		mfaToken, err := o.auth.mfaPendingToken(ctx, t)
+		if err != nil {
+			o.auth.tryRemoveCookie(ctx)
+
+			o.auth.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: oauth2: %s: %w", o.config.Name, err))
+			return
+		}
+
+		if mfaToken != "" {
+			o.auth.tryRemoveCookie(ctx)
+
+			ctx.JSON(SigninResponse{MFAToken: mfaToken})
+			return
+		}
+	}
+
+	accessTokenBytes, refreshTokenBytes, err := o.auth.sign(t)
+	if err != nil {
+		o.auth.errorHandler.Unauthenticated(ctx, fmt.Errorf("auth: oauth2: %s: %w", o.config.Name, err))
+		return
+	}
+
+	accessToken := jwt.BytesToString(accessTokenBytes)
+	refreshToken := jwt.BytesToString(refreshTokenBytes)
+
+	o.auth.trySetCookie(ctx, accessToken)
+
+	if o.config.SigninRedirect != "" {
+		ctx.Redirect(o.config.SigninRedirect, http.StatusFound)
+		return
+	}
+
+	resp := SigninResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+	ctx.JSON(resp)
+}
+
+// Exchange exchanges the authorization code with the provider's tokens
+// and returns the T value of the external user.
+// The "nonce" and "codeVerifier" can be empty.
+func (o *OAuth2[T]) Exchange(ctx *context.Context, code, nonce, codeVerifier string) (T, error) {
+	var t T
+
+	if err := o.discover(); err != nil {
+		return t, err
+	}
+
+	tokenResp, err := o.exchange(code, codeVerifier)
+	if err != nil {
+		return t, fmt.Errorf("auth: oauth2: %s: exchange: %w", o.config.Name, err)
+	}
+
+	var verifiedToken *VerifiedToken
+	if tokenResp.IDToken != "" {
+		verifiedToken, err = o.verifyIDToken([]byte(tokenResp.IDToken), nonce)
+		if err != nil {
+			return t, fmt.Errorf("auth: oauth2: %s: id token: %w", o.config.Name, err)
+		}
+	} else {
+		userInfoURL := o.Config().UserInfoURL
+		if userInfoURL == "" {
+			return t, fmt.Errorf("auth: oauth2: %s: id token is missing and user info url is empty", o.config.Name)
+		}
+
+		verifiedToken, err = o.userInfo(userInfoURL, tokenResp.AccessToken)
+		if err != nil {
+			return t, fmt.Errorf("auth: oauth2: %s: user info: %w", o.config.Name, err)
+		}
+	}
+
+	if o.auth.transformer != nil {
+		t, err = o.auth.transformer.Transform(ctx, verifiedToken)
+	} else {
+		err = verifiedToken.Claims(&t)
+	}
+
+	if err != nil {
+		return t, fmt.Errorf("auth: oauth2: %s: %w", o.config.Name, err)
+	}
+
+	return t, nil
+}
+
+// discover fetches the OpenID Connect configuration once.
+func (o *OAuth2[T]) discover() error {
+	o.mu.RLock()
+	discovered := o.discovered
+	o.mu.RUnlock()
+	if discovered {
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.discovered {
+		return nil
+	}
+
+	var doc openIDConfiguration
+	if err := o.getJSON(o.config.Issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return fmt.Errorf("auth: oauth2: %s: discovery: %w", o.config.Name, err)
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != o.config.Issuer {
+		return fmt.Errorf("auth: oauth2: %s: discovery: issuer mismatch: %s", o.config.Name, doc.Issuer)
+	}
+
+	if o.config.AuthURL == "" {
+		o.config.AuthURL = doc.AuthorizationEndpoint
+	}
+
+	if o.config.TokenURL == "" {
+		o.config.TokenURL = doc.TokenEndpoint
+	}
+
+	if o.config.UserInfoURL == "" {
+		o.config.UserInfoURL = doc.UserInfoEndpoint
+	}
+
+	if o.config.JWKSURL == "" {
+		o.config.JWKSURL = doc.JWKSURI
+	}
+
+	o.discovered = true
+	return nil
+}
+
+func (o *OAuth2[T]) exchange(code, codeVerifier string) (*oauth2TokenResponse, error) {
+	config := o.Config()
+
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {config.RedirectURL},
+		"client_id":    {config.ClientID},
+	}
+
+	if config.ClientSecret != "" {
+		form.Set("client_secret", config.ClientSecret)
+	}
+
+	if codeVerifier != "" {
+		form.Set("code_verifier", codeVerifier)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResp oauth2TokenResponse
+	if err = o.doJSON(req, &tokenResp); err != nil {
+		return nil, err
+	}
+
+	if tokenResp.Error != "" {
+		return nil, fmt.Errorf("%s: %s", tokenResp.Error, tokenResp.ErrorDescription)
+	}
+
+	if tokenResp.AccessToken == "" && tokenResp.IDToken == "" {
+		return nil, fmt.Errorf("empty token response")
+	}
+
+	return &tokenResp, nil
+}
+
+// verifyIDToken verifies the signature, the issuer, the audience,
+// the expiration and the nonce of an OpenID Connect ID token.
+func (o *OAuth2[T]) verifyIDToken(token []byte, nonce string) (*VerifiedToken, error) {
+	config := o.Config()
+
+	verifiedToken, err := jwt.VerifyWithHeaderValidator(nil, nil, token, o.validateHeader,
+		jwt.Expected{Issuer: config.Issuer}, jwt.Future(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+
+	if !containsString(verifiedToken.StandardClaims.Audience, config.ClientID) {
+		return nil, fmt.Errorf("%w: aud", jwt.ErrExpected)
+	}
+
+	if nonce != "" {
+		var claims struct {
+			Nonce string `json:"nonce"`
+		}
+
+		if err = verifiedToken.Claims(&claims); err != nil {
+			return nil, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+			return nil, fmt.Errorf("%w: nonce", jwt.ErrExpected)
+		}
+	}
+
+	return verifiedToken, nil
+}
+
+// validateHeader completes the jwt.HeaderValidator,
+// it fetches the provider's keys on first use and on unknown key ids.
+func (o *OAuth2[T]) validateHeader(alg string, headerDecoded []byte) (jwt.Alg, jwt.PublicKey, jwt.InjectFunc, error) {
+	o.mu.RLock()
+	keys := o.keys
+	o.mu.RUnlock()
+
+	if keys != nil {
+		verifyAlg, publicKey, decrypt, err := keys.ValidateHeader(alg, headerDecoded)
+		if !errors.Is(err, jwt.ErrUnknownKid) {
+			return verifyAlg, publicKey, decrypt, err
+		}
+	}
+
+	keys, err := o.fetchKeys()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return keys.ValidateHeader(alg, headerDecoded)
+}
+
+func (o *OAuth2[T]) fetchKeys() (jwt.Keys, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// Protect the provider from too many requests on unknown key ids.
+	if o.keys != nil && time.Since(o.keysLastFetched) < o.keysMinFetchTime {
+		return o.keys, nil
+	}
+
+	if o.config.JWKSURL == "" {
+		return nil, fmt.Errorf("jwks url is missing")
+	}
+
+	set, err := jwt.FetchJWKS(o.HTTPClient, o.config.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+
+	o.keys = set.PublicKeys()
+	o.keysLastFetched = time.Now()
+	return o.keys, nil
+}
+
+// userInfo fetches the user info of a plain OAuth2 provider and returns
+// a token which its payload is the user info response.
+func (o *OAuth2[T]) userInfo(userInfoURL, accessToken string) (*VerifiedToken, error) {
+	var payload json.RawMessage
+	if err := o.getJSON(userInfoURL, accessToken, &payload); err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Subject any `json:"sub"`
+		ID      any `json:"id"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+
+	subject := claims.Subject
+	if subject == nil {
+		subject = claims.ID
+	}
+
+	verifiedToken := &VerifiedToken{
+		Token:   []byte(accessToken),
+		Payload: payload,
+		StandardClaims: StandardClaims{
+			Issuer: o.config.Name,
+		},
+	}
+
+	if subject != nil {
+		verifiedToken.StandardClaims.Subject = fmt.Sprintf("%v", subject)
+	}
+
+	return verifiedToken, nil
+}
+
+func (o *OAuth2[T]) getJSON(url, accessToken string, dest any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	return o.doJSON(req, dest)
+}
+
+func (o *OAuth2[T]) doJSON(req *http.Request, dest any) error {
+	resp, err := o.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s: %s", req.URL.String(), resp.Status, b)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+func decodeOAuth2State(value string, st *oauth2State) error {
+	if value == "" {
+		return http.ErrNoCookie
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(b, st); err != nil {
+		return err
+	}
+
+	if st.State == "" {
+		return fmt.Errorf("empty state")
+	}
+
+	return nil
+}
+
+// randomString returns a random, url-safe, string of 32 bytes of entropy,
+// it's used for state, nonce and PKCE code verifier values.
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // should never happen.
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// codeChallengeS256 returns the S256 PKCE code challenge of the verifier.
+func codeChallengeS256(codeVerifier string) string {
+	h := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+func containsString(s []string, v string) bool {
+	for _, item := range s {
+		if item == v {
+			return true
+		}
+	}
+
+	return false
+}
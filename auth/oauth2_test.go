@@ -0,0 +1,234 @@
+//go:build go1.18
+// +build go1.18
+
+package auth_test
+
+import (
+	stdContext "context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	stdhttptest "net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/auth"
+	"github.com/kataras/iris/v12/httptest"
+
+	"github.com/iris-contrib/httpexpect/v2"
+
+	"github.com/kataras/jwt"
+)
+
+type oauth2User struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email"`
+}
+
+// oauth2MFAProvider stores the second factor of the external users.
+type oauth2MFAProvider struct {
+	mfa map[string]auth.MFA
+}
+
+func (p *oauth2MFAProvider) Signin(stdContext.Context, string, string) (oauth2User, error) {
+	return oauth2User{}, errors.New("invalid credentials")
+}
+
+func (p *oauth2MFAProvider) ValidateToken(stdContext.Context, auth.StandardClaims, oauth2User) error {
+	return nil
+}
+
+func (p *oauth2MFAProvider) InvalidateToken(stdContext.Context, auth.StandardClaims, oauth2User) error {
+	return nil
+}
+
+func (p *oauth2MFAProvider) InvalidateTokens(stdContext.Context, oauth2User) error {
+	return nil
+}
+
+func (p *oauth2MFAProvider) GetMFA(_ stdContext.Context, u oauth2User) (auth.MFA, error) {
+	return p.mfa[u.Subject], nil
+}
+
+func (p *oauth2MFAProvider) SetMFA(_ stdContext.Context, u oauth2User, mfa auth.MFA) error {
+	p.mfa[u.Subject] = mfa
+	return nil
+}
+
+func TestOAuth2OpenIDConnect(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerKeys := make(jwt.Keys)
+	issuerKeys.Register(jwt.EdDSA, "issuer-key", publicKey, privateKey)
+
+	var (
+		mu        sync.Mutex
+		challenge string
+		nonce     string
+	)
+
+	mux := http.NewServeMux()
+	issuer := stdhttptest.NewServer(mux)
+	defer issuer.Close()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.URL,
+			"authorization_endpoint": issuer.URL + "/authorize",
+			"token_endpoint":         issuer.URL + "/token",
+			"jwks_uri":               issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		set, err := issuerKeys.JWKS()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(set)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+
+		h := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "the_code" || base64.RawURLEncoding.EncodeToString(h[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idToken, err := issuerKeys.SignToken("issuer-key", map[string]any{
+			"iss":   issuer.URL,
+			"aud":   []string{"client_id"},
+			"sub":   "user_id",
+			"email": "user@example.com",
+			"nonce": nonce,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "provider_access_token",
+			"token_type":   "Bearer",
+			"id_token":     string(idToken),
+		})
+	})
+
+	config := auth.MustGenerateConfiguration()
+	for i := range config.Keys {
+		config.Keys[i].EncryptionKey = ""
+	}
+
+	s, err := auth.New[oauth2User](config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mfaProvider := &oauth2MFAProvider{mfa: make(map[string]auth.MFA)}
+	s.AddProvider(mfaProvider)
+
+	provider, err := s.NewOAuth2(auth.OAuth2Configuration{
+		Name:        "test",
+		ClientID:    "client_id",
+		RedirectURL: "http://localhost/callback",
+		Issuer:      issuer.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := iris.New()
+	app.Get("/login", provider.LoginHandler)
+	app.Get("/callback", provider.CallbackHandler)
+	app.Post("/signin/mfa", s.VerifyMFAHandler)
+	app.Get("/protected", s.VerifyHandler(), func(ctx iris.Context) {
+		user := s.GetUser(ctx)
+		ctx.WriteString(user.Email)
+	})
+
+	e := httptest.New(t, app)
+
+	signin := func() *httpexpect.Response {
+		resp := e.GET("/login").WithRedirectPolicy(httpexpect.DontFollowRedirects).Expect().Status(httptest.StatusFound)
+		location, err := url.Parse(resp.Header("Location").Raw())
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := location.Query()
+		if expected, got := issuer.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path; expected != got {
+			t.Fatalf("expected redirect to %q but got %q", expected, got)
+		}
+		if expected, got := "openid", query.Get("scope"); expected != got {
+			t.Fatalf("expected scope %q but got %q", expected, got)
+		}
+
+		mu.Lock()
+		challenge = query.Get("code_challenge")
+		nonce = query.Get("nonce")
+		mu.Unlock()
+
+		stateCookie := resp.Cookie("iris_auth_oauth2_test").Value().Raw()
+
+		// Invalid state.
+		e.GET("/callback").WithQuery("code", "the_code").WithQuery("state", "invalid").
+			WithCookie("iris_auth_oauth2_test", stateCookie).Expect().Status(httptest.StatusBadRequest)
+
+		return e.GET("/callback").WithQuery("code", "the_code").WithQuery("state", query.Get("state")).
+			WithCookie("iris_auth_oauth2_test", stateCookie).Expect().Status(httptest.StatusOK)
+	}
+
+	var resp auth.SigninResponse
+	signin().JSON().Decode(&resp)
+	if resp.AccessToken == "" {
+		t.Fatalf("expected an access token")
+	}
+
+	e.GET("/protected").WithHeader("Authorization", "Bearer "+resp.AccessToken).Expect().
+		Status(httptest.StatusOK).Body().IsEqual("user@example.com")
+
+	// Enrolled, the second factor is required.
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfaProvider.mfa["user_id"] = auth.MFA{Secret: secret}
+
+	resp = auth.SigninResponse{}
+	callback := signin()
+	callback.Cookie("iris_auth_cookie").Value().IsEmpty() // removed.
+	callback.JSON().Decode(&resp)
+	if resp.AccessToken != "" || resp.MFAToken == "" {
+		t.Fatalf("expected a mfa token without access token but got: %#+v", resp)
+	}
+
+	e.GET("/protected").WithHeader("Authorization", "Bearer "+resp.MFAToken).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	code, err := config.MFA.TOTP(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mfaToken := resp.MFAToken
+	resp = auth.SigninResponse{}
+	e.POST("/signin/mfa").WithJSON(auth.VerifyMFARequest{MFAToken: mfaToken, Code: code}).Expect().
+		Status(httptest.StatusOK).JSON().Decode(&resp)
+
+	e.GET("/protected").WithHeader("Authorization", "Bearer "+resp.AccessToken).Expect().
+		Status(httptest.StatusOK).Body().IsEqual("user@example.com")
+}
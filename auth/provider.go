@@ -19,8 +19,8 @@ type VerifiedToken = jwt.VerifiedToken
 // by a custom value type to provide user information to the Auth's
 // JWT Token Signer and Verifier.
 //
-// A provider can optionally complete the Transformer, ClaimsProvider and
-// ErrorHandler all in once when necessary.
+// A provider can optionally complete the Transformer, ClaimsProvider,
+// ErrorHandler and MFAProvider all in once when necessary.
 // Set a provider using the AddProvider method of Auth type.
 //
 // Example can be found at: https://github.com/kataras/iris/tree/main/_examples/auth/auth/user_provider.go.
@@ -0,0 +1,170 @@
+//go:build go1.18
+// +build go1.18
+
+package auth
+
+import (
+	stdContext "context"
+	"errors"
+	"time"
+
+	jwtmiddleware "github.com/kataras/iris/v12/middleware/jwt"
+
+	"github.com/kataras/jwt"
+)
+
+// ErrRefreshTokenReused is returned by the Refresh method when an already
+// used refresh token is presented again. Its token family is revoked.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+const (
+	// familyKeyPrefix is the prefix of the revoked token families keys in the refresh store.
+	familyKeyPrefix = "iris.auth.family."
+	// noExpiryMaxAge is the lifetime of the refresh store entries
+	// when the refresh tokens do not expire (zero MaxAge).
+	// The stores remove the entries with a zero expiration on their GC,
+	// so a long one is used instead.
+	noExpiryMaxAge = 10 * 365 * 24 * time.Hour
+)
+
+type (
+	// RefreshReuseFunc is the type of the function which is called
+	// when an already used refresh token is presented again,
+	// which means that the token was, most likely, stolen.
+	// The "claims" are the standard claims of the reused refresh token.
+	// See `SetRefreshRotation` method of Auth type.
+	RefreshReuseFunc[T User] func(ctx stdContext.Context, t T, claims StandardClaims)
+
+	// familyClaims holds the token family claim, it's merged
+	// with the T claims of the access and refresh tokens when rotation is enabled.
+	familyClaims struct {
+		Family string `json:"fam,omitempty"`
+	}
+)
+
+// SetRefreshRotation enables refresh token rotation with reuse detection
+// and returns itself.
+//
+// Each successful signin starts a new token family. Every Refresh invalidates
+// the presented refresh token and issues a new pair in the same family.
+// If an already used refresh token is presented again then the whole family,
+// including its access tokens, is revoked, the "onReuse" function is called
+// (e.g. to notify the user or log the incident) and the Refresh fails with ErrRefreshTokenReused.
+// Signout revokes the family of the access token too.
+//
+// The "store" keeps the used refresh tokens and the revoked families,
+// it can be any middleware/jwt Blocklist which extracts the keys by the token ID (the default),
+// e.g. the jwt.NewBlocklist in-memory one or the redis one for multiple server instances.
+// A store which needs a connection should be connected before use.
+// If "store" is nil then a new in-memory Blocklist is used.
+// The "onReuse" can be nil.
+//
+// Note that the tokens are not rotated when the refresh token id is missing from the configuration.
+func (s *Auth[T]) SetRefreshRotation(store jwtmiddleware.Blocklist, onReuse RefreshReuseFunc[T]) *Auth[T] {
+	if store == nil {
+		gcEvery := s.refreshMaxAge()
+		if gcEvery <= 0 {
+			gcEvery = time.Hour
+		}
+
+		store = jwt.NewBlocklist(gcEvery)
+	}
+
+	s.refreshStore = store
+	s.onRefreshReuse = onReuse
+	return s
+}
+
+// rotate marks the verified refresh token as used.
+// It revokes the token's family when the token was already used.
+func (s *Auth[T]) rotate(ctx stdContext.Context, t T, verifiedToken *VerifiedToken, family string) error {
+	claims := verifiedToken.StandardClaims
+
+	// The store interface has no atomic "set if not exists" operation,
+	// protect the check and mark steps on this server instance at least.
+	s.rotateMu.Lock()
+	used, err := s.refreshStore.Has(claims.ID)
+	if err == nil && !used {
+		usedClaims := claims
+		if usedClaims.Expiry == 0 {
+			usedClaims.Expiry = jwt.Clock().Add(noExpiryMaxAge).Unix()
+		}
+
+		err = s.refreshStore.InvalidateToken(verifiedToken.Token, usedClaims)
+	}
+	s.rotateMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if used {
+		if err = s.revokeFamily(verifiedToken.Token, family); err != nil {
+			return err
+		}
+
+		if s.onRefreshReuse != nil {
+			s.onRefreshReuse(ctx, t, claims)
+		}
+
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+// revokeFamily revokes all the tokens of a family, until the
+// last possible refresh token of that family is expired.
+func (s *Auth[T]) revokeFamily(token []byte, family string) error {
+	if family == "" {
+		return nil
+	}
+
+	maxAge := s.refreshMaxAge()
+	if maxAge <= 0 {
+		maxAge = noExpiryMaxAge
+	}
+
+	claims := StandardClaims{
+		ID:     familyKeyPrefix + family,
+		Expiry: jwt.Clock().Add(maxAge).Unix(),
+	}
+
+	return s.refreshStore.InvalidateToken(token, claims)
+}
+
+func (s *Auth[T]) refreshMaxAge() time.Duration {
+	if k, ok := s.keys[KIDRefresh]; ok {
+		return k.MaxAge
+	}
+
+	return 0
+}
+
+// validateFamily reports an ErrBlocked error when the token's family was revoked.
+func (s *Auth[T]) validateFamily(family string) error {
+	if family == "" {
+		return nil
+	}
+
+	revoked, err := s.refreshStore.Has(familyKeyPrefix + family)
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return jwt.ErrBlocked
+	}
+
+	return nil
+}
+
+// tokenFamily returns the token family claim of a verified token, if any.
+func tokenFamily(verifiedToken *VerifiedToken) string {
+	var c familyClaims
+	if err := verifiedToken.Claims(&c); err != nil {
+		return ""
+	}
+
+	return c.Family
+}
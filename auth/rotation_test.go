@@ -0,0 +1,147 @@
+//go:build go1.18
+// +build go1.18
+
+package auth_test
+
+import (
+	stdContext "context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/auth"
+	"github.com/kataras/iris/v12/httptest"
+
+	"github.com/kataras/jwt"
+)
+
+func TestRefreshRotation(t *testing.T) {
+	config := auth.MustGenerateConfiguration()
+	for i := range config.Keys {
+		config.Keys[i].EncryptionKey = ""
+	}
+
+	s, err := auth.New[mfaUser](config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reused []string
+	s.AddProvider(&mfaProvider{mfa: make(map[string]auth.MFA)}).
+		SetRefreshRotation(nil, func(_ stdContext.Context, u mfaUser, claims auth.StandardClaims) {
+			reused = append(reused, u.Username)
+		})
+
+	app := iris.New()
+	app.Post("/signin", s.SigninHandler)
+	app.Post("/refresh", s.RefreshHandler)
+	protected := app.Party("/", s.VerifyHandler())
+	protected.Get("/me", func(ctx iris.Context) {
+		ctx.WriteString(auth.GetUser[mfaUser](ctx).Username)
+	})
+	protected.Post("/signout", s.SignoutHandler)
+
+	e := httptest.New(t, app)
+
+	signin := func() auth.SigninResponse {
+		var resp auth.SigninResponse
+		e.POST("/signin").WithJSON(iris.Map{"username": "kataras", "password": "password"}).Expect().
+			Status(httptest.StatusOK).JSON().Decode(&resp)
+		return resp
+	}
+
+	refresh := func(refreshToken string, expectedStatus int) auth.SigninResponse {
+		var resp auth.SigninResponse
+		r := e.POST("/refresh").WithJSON(auth.RefreshRequest{RefreshToken: refreshToken}).Expect().Status(expectedStatus)
+		if expectedStatus == httptest.StatusOK {
+			r.JSON().Decode(&resp)
+		}
+		return resp
+	}
+
+	me := func(accessToken string, expectedStatus int) {
+		e.GET("/me").WithHeader("Authorization", "Bearer "+accessToken).Expect().Status(expectedStatus)
+	}
+
+	first := signin()
+	second := refresh(first.RefreshToken, httptest.StatusOK)
+	me(first.AccessToken, httptest.StatusOK)
+	me(second.AccessToken, httptest.StatusOK)
+
+	third := refresh(second.RefreshToken, httptest.StatusOK)
+	if len(reused) != 0 {
+		t.Fatalf("expected no reuse reports but got: %v", reused)
+	}
+
+	// Reuse of the first (rotated) refresh token revokes the whole family.
+	refresh(first.RefreshToken, httptest.StatusUnauthorized)
+	if len(reused) != 1 || reused[0] != "kataras" {
+		t.Fatalf("expected a single reuse report but got: %v", reused)
+	}
+
+	refresh(third.RefreshToken, httptest.StatusUnauthorized)
+	me(third.AccessToken, httptest.StatusUnauthorized)
+	me(first.AccessToken, httptest.StatusUnauthorized)
+
+	// A new signin starts a new family.
+	other := signin()
+	me(other.AccessToken, httptest.StatusOK)
+	refresh(other.RefreshToken, httptest.StatusOK)
+
+	_, _, err = s.Refresh(stdContext.Background(), []byte(other.RefreshToken))
+	if !errors.Is(err, auth.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused but got: %v", err)
+	}
+
+	// Signout revokes the family too.
+	last := signin()
+	e.POST("/signout").WithHeader("Authorization", "Bearer "+last.AccessToken).Expect().Status(httptest.StatusOK)
+	refresh(last.RefreshToken, httptest.StatusUnauthorized)
+}
+
+func TestRefreshRotationNoMaxAge(t *testing.T) {
+	config := auth.MustGenerateConfiguration()
+	for i := range config.Keys {
+		config.Keys[i].EncryptionKey = ""
+		if config.Keys[i].ID == auth.KIDRefresh {
+			config.Keys[i].MaxAge = 0 // refresh tokens without expiration.
+		}
+	}
+
+	s, err := auth.New[mfaUser](config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := jwt.NewBlocklist(0)
+	s.AddProvider(&mfaProvider{mfa: make(map[string]auth.MFA)}).SetRefreshRotation(store, nil)
+
+	ctx := stdContext.Background()
+	_, first, err := s.Signin(ctx, "kataras", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, second, err := s.Refresh(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = s.Refresh(ctx, first); !errors.Is(err, auth.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused but got: %v", err)
+	}
+
+	// The revoked family and the used tokens must survive the store's GC.
+	store.Clock = func() time.Time { return time.Now().Add(365 * 24 * time.Hour) }
+	if n := store.GC(); n != 0 {
+		t.Fatalf("expected no removed entries but got: %d", n)
+	}
+
+	if _, _, err = s.Refresh(ctx, second); err == nil {
+		t.Fatalf("expected the revoked family's refresh token to fail")
+	}
+	if _, _, err = s.Refresh(ctx, first); err == nil {
+		t.Fatalf("expected the used refresh token to fail")
+	}
+}
@@ -0,0 +1,225 @@
+//go:build go1.18
+// +build go1.18
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MFAConfiguration holds the necessary information for
+// the RFC 6238 Time-Based One-Time Password (TOTP) second factor.
+// The zero values are replaced with the defaults on New.
+type MFAConfiguration struct {
+	// Issuer is the name of the application which is shown
+	// to the authenticator apps, e.g. "Admin".
+	// Defaults to "Iris".
+	Issuer string `json:"issuer" yaml:"Issuer" toml:"Issuer" ini:"issuer"`
+	// Digits is the number of digits of a code, 6 or 8.
+	// Defaults to 6.
+	Digits int `json:"digits" yaml:"Digits" toml:"Digits" ini:"digits"`
+	// Period is the time step of a code.
+	// Defaults to 30 seconds.
+	Period time.Duration `json:"period" yaml:"Period" toml:"Period" ini:"period"`
+	// Skew is the number of periods before and after the current one
+	// that a code is still accepted, it allows clock drift between
+	// the server and the authenticator devices.
+	// Defaults to 1, set a negative value to accept only the current period.
+	Skew int `json:"skew" yaml:"Skew" toml:"Skew" ini:"skew"`
+	// PendingMaxAge is the lifetime of the "mfa-pending" token that
+	// the SigninHandler sends when the user has to verify the second factor,
+	// it's the lifetime of the enrollment token too.
+	// Defaults to 5 minutes.
+	PendingMaxAge time.Duration `json:"pending_max_age" yaml:"PendingMaxAge" toml:"PendingMaxAge" ini:"pending_max_age"`
+	// RecoveryCodes is the number of the one-time recovery codes
+	// which are generated on enrollment.
+	// Defaults to 10.
+	RecoveryCodes int `json:"recovery_codes" yaml:"RecoveryCodes" toml:"RecoveryCodes" ini:"recovery_codes"`
+	// MaxAttempts is the number of the invalid codes that
+	// an "mfa-pending" token accepts before it's rejected.
+	// The token is rejected after its first successful verification too.
+	// The attempts are kept in the memory of the Auth instance.
+	// Defaults to 5.
+	MaxAttempts int `json:"max_attempts" yaml:"MaxAttempts" toml:"MaxAttempts" ini:"max_attempts"`
+}
+
+func (c *MFAConfiguration) setDefaults() {
+	if c.Issuer == "" {
+		c.Issuer = "Iris"
+	}
+
+	if c.Digits != 8 {
+		c.Digits = 6
+	}
+
+	if c.Period <= 0 {
+		c.Period = 30 * time.Second
+	}
+
+	if c.Skew == 0 {
+		c.Skew = 1
+	}
+
+	if c.PendingMaxAge <= 0 {
+		c.PendingMaxAge = 5 * time.Minute
+	}
+
+	if c.RecoveryCodes <= 0 {
+		c.RecoveryCodes = 10
+	}
+
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+}
+
+// URI returns the otpauth:// key URI of a TOTP secret, it's
+// usually rendered as a QR code to be scanned by the authenticator apps.
+// The "account" is the user's name or email.
+func (c MFAConfiguration) URI(account, secret string) string {
+	c.setDefaults()
+
+	label := url.PathEscape(c.Issuer) + ":" + url.PathEscape(account)
+
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {c.Issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {strconv.Itoa(c.Digits)},
+		"period":    {strconv.Itoa(int(c.Period.Seconds()))},
+	}
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTP returns the code of a TOTP secret at the given time.
+func (c MFAConfiguration) TOTP(secret string, t time.Time) (string, error) {
+	c.setDefaults()
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpCode(key, c.counter(t), c.Digits), nil
+}
+
+// ValidateTOTP reports whether the "code" is a valid code of the TOTP secret at the given time,
+// within the configured Skew. A code is accepted only once: its time step counter
+// should be greater than the "lastCounter" (zero on first use)
+// and the new counter is returned to be stored for the next validation.
+func (c MFAConfiguration) ValidateTOTP(secret, code string, t time.Time, lastCounter int64) (int64, bool) {
+	c.setDefaults()
+
+	if len(code) != c.Digits {
+		return lastCounter, false
+	}
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return lastCounter, false
+	}
+
+	skew := c.Skew
+	if skew < 0 {
+		skew = 0
+	}
+
+	current := int64(c.counter(t))
+	for i := -skew; i <= skew; i++ {
+		counter := current + int64(i)
+		if counter <= lastCounter {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(counter), c.Digits)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return lastCounter, false
+}
+
+func (c MFAConfiguration) counter(t time.Time) uint64 {
+	return uint64(t.UnixNano() / int64(c.Period))
+}
+
+// totpCode implements the RFC 4226 HOTP algorithm with HMAC-SHA1.
+func totpCode(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random, base32 encoded, TOTP secret of 160 bits.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(b), nil
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimRight(secret, "="), " ", ""))
+	return totpEncoding.DecodeString(secret)
+}
+
+// GenerateRecoveryCodes returns "n" new random one-time recovery codes
+// and their hashes. The codes are shown to the user once and
+// only the hashes should be stored, see `HashRecoveryCode`.
+func GenerateRecoveryCodes(n int) (codes []string, hashes []string, err error) {
+	codes = make([]string, 0, n)
+	hashes = make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		b := make([]byte, 6)
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := hex.EncodeToString(b)
+		code = code[:6] + "-" + code[6:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+
+	return
+}
+
+// HashRecoveryCode returns the hash of a recovery code.
+// The dashes, spaces and letter case of the code are ignored.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,183 @@
+package context
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/kataras/golog"
+)
+
+// NewSlogHandler returns a log/slog Handler which writes the records to the golog "logger",
+// e.g. the Application's Logger.
+// The slog levels are mapped to the golog debug, info, warn and error levels
+// and the attributes to golog Fields, the group names are prefixed to the keys, e.g. "request.method".
+//
+// Do not use it with a logger which sends its logs to the same Handler
+// through a `NewGologHandler`, it would loop forever.
+//
+// See `Context.Logger` too.
+func NewSlogHandler(logger *golog.Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+type slogHandler struct {
+	logger *golog.Logger
+	fields golog.Fields
+	group  string
+}
+
+var _ slog.Handler = (*slogHandler)(nil)
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Level >= gologLevel(level)
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	if len(h.fields) == 0 && r.NumAttrs() == 0 {
+		h.logger.Log(gologLevel(r.Level), r.Message)
+		return nil
+	}
+
+	fields := make(golog.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(fields, h.group, attr)
+		return true
+	})
+
+	h.logger.Log(gologLevel(r.Level), r.Message, fields)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make(golog.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, attr := range attrs {
+		addSlogAttr(fields, h.group, attr)
+	}
+
+	return &slogHandler{logger: h.logger, fields: fields, group: h.group}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{logger: h.logger, fields: h.fields, group: h.group + name + "."}
+}
+
+func addSlogAttr(fields golog.Fields, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+
+		for _, groupAttr := range attr.Value.Group() {
+			addSlogAttr(fields, prefix, groupAttr)
+		}
+		return
+	}
+
+	fields[prefix+attr.Key] = attr.Value.Any()
+}
+
+func gologLevel(level slog.Level) golog.Level {
+	switch {
+	case level >= slog.LevelError:
+		return golog.ErrorLevel
+	case level >= slog.LevelWarn:
+		return golog.WarnLevel
+	case level >= slog.LevelInfo:
+		return golog.InfoLevel
+	default:
+		return golog.DebugLevel
+	}
+}
+
+// NewGologHandler returns a golog Handler which sends the logs to the log/slog "h" Handler
+// instead of the golog logger's output. The Fields are converted to attributes, sorted by key.
+//
+// Example Code:
+//
+//	app.Logger().Handle(iris.NewGologHandler(slog.NewJSONHandler(os.Stdout, nil)))
+func NewGologHandler(h slog.Handler) golog.Handler {
+	return func(log *golog.Log) bool {
+		ctx := context.Background()
+		level := slogLevel(log.Level)
+		if !h.Enabled(ctx, level) {
+			return true
+		}
+
+		r := slog.NewRecord(log.Time, level, log.Message, 0)
+		if len(log.Fields) > 0 {
+			keys := make([]string, 0, len(log.Fields))
+			for k := range log.Fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				r.AddAttrs(slog.Any(k, log.Fields[k]))
+			}
+		}
+
+		h.Handle(ctx, r)
+		return true
+	}
+}
+
+func slogLevel(level golog.Level) slog.Level {
+	switch level {
+	case golog.FatalLevel:
+		return slog.LevelError + 4
+	case golog.ErrorLevel:
+		return slog.LevelError
+	case golog.WarnLevel:
+		return slog.LevelWarn
+	case golog.DebugLevel:
+		return slog.LevelDebug
+	default: // info and the Print functions.
+		return slog.LevelInfo
+	}
+}
+
+// Logger returns a log/slog Logger which writes to the Application's logger,
+// see `NewSlogHandler`. Its records include, if available, the "request_id" (see `SetID`),
+// "route" (see `RouteName`), "remote_ip" (see `RemoteAddr`) and "user_id" (see `User`) attributes.
+//
+// To send all logs, including these, to a slog Handler
+// register a `NewGologHandler` to the Application's logger.
+func (ctx *Context) Logger() *slog.Logger {
+	attrs := make([]slog.Attr, 0, 4)
+	if id := ctx.GetID(); id != nil {
+		attrs = append(attrs, slog.Any("request_id", id))
+	}
+	if name := ctx.RouteName(); name != "" {
+		attrs = append(attrs, slog.String("route", name))
+	}
+	if ip := ctx.RemoteAddr(); ip != "" {
+		attrs = append(attrs, slog.String("remote_ip", ip))
+	}
+	if u := ctx.User(); u != nil {
+		if id, err := u.GetID(); err == nil && id != "" {
+			attrs = append(attrs, slog.String("user_id", id))
+		}
+	}
+
+	return slog.New(NewSlogHandler(ctx.Application().Logger()).WithAttrs(attrs))
+}
@@ -327,6 +327,16 @@ func (su *Supervisor) ListenAndServeTLS(certFileOrContents string, keyFileOrCont
 		getCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
 			return cert, nil
 		}
+	} else if cfg := su.Server.TLSConfig; len(cfg.Certificates) == 0 && cfg.GetCertificate == nil &&
+		cfg.GetConfigForClient == nil && (certFileOrContents != "" || keyFileOrContents != "") {
+		// tls.Config configured manually without certificates, e.g. to require client certificates,
+		// use the given ones.
+		cert, err := loadCertificate(certFileOrContents, keyFileOrContents)
+		if err != nil {
+			return err
+		}
+
+		cfg.Certificates = []tls.Certificate{*cert}
 	}
 
 	su.manuallyTLS = true
@@ -2,6 +2,7 @@ package netutil
 
 import (
 	"bytes"
+	"fmt"
 	"net"
 	"strings"
 )
@@ -22,6 +23,45 @@ func IPInRange(r IPRange, ipAddress net.IP) bool {
 	return bytes.Compare(ipAddress, net.ParseIP(r.Start)) >= 0 && bytes.Compare(ipAddress, net.ParseIP(r.End)) <= 0
 }
 
+// ParseIPRange parses an IPv4 or IPv6 CIDR notation (e.g. "10.0.0.0/8", "2001:db8::/32"),
+// a range of the form of "start-end" (e.g. "10.0.0.1-10.0.0.9") or
+// a single IP Address to an IPRange.
+func ParseIPRange(s string) (IPRange, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return IPRange{}, err
+		}
+
+		start := ipNet.IP
+		end := make(net.IP, len(start))
+		for i := range start {
+			end[i] = start[i] | ^ipNet.Mask[i]
+		}
+
+		return IPRange{Start: start.String(), End: end.String()}, nil
+	}
+
+	start, end, isRange := strings.Cut(s, "-")
+	if !isRange {
+		end = start
+	}
+
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	startIP, endIP := net.ParseIP(start), net.ParseIP(end)
+	if startIP == nil || endIP == nil {
+		return IPRange{}, fmt.Errorf("invalid IP range: %q", s)
+	}
+
+	if (startIP.To4() == nil) != (endIP.To4() == nil) || bytes.Compare(startIP, endIP) > 0 {
+		return IPRange{}, fmt.Errorf("invalid IP range: %q", s)
+	}
+
+	return IPRange{Start: startIP.String(), End: endIP.String()}, nil
+}
+
 // IPIsPrivateSubnet reports whether this "ipAddress" is in a private subnet.
 func IPIsPrivateSubnet(ipAddress net.IP, privateRanges []IPRange) bool {
 	// IPv4 for now.
@@ -1,6 +1,7 @@
 package netutil
 
 import (
+	"net"
 	"testing"
 )
 
@@ -89,3 +90,37 @@ func TestIP(t *testing.T) {
 		t.Logf("expected addr to not be matched")
 	}
 }
+
+func TestParseIPRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected IPRange
+		ok       bool
+	}{
+		{"10.0.0.0/8", IPRange{Start: "10.0.0.0", End: "10.255.255.255"}, true},
+		{"192.168.1.17/28", IPRange{Start: "192.168.1.16", End: "192.168.1.31"}, true},
+		{"2001:db8::/32", IPRange{Start: "2001:db8::", End: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"}, true},
+		{"10.0.0.1 - 10.0.0.9", IPRange{Start: "10.0.0.1", End: "10.0.0.9"}, true},
+		{"127.0.0.1", IPRange{Start: "127.0.0.1", End: "127.0.0.1"}, true},
+		{"::1", IPRange{Start: "::1", End: "::1"}, true},
+		{"10.0.0.9-10.0.0.1", IPRange{}, false},
+		{"10.0.0.1-::1", IPRange{}, false},
+		{"10.0.0.0/33", IPRange{}, false},
+		{"invalid", IPRange{}, false},
+	}
+
+	for i, tt := range tests {
+		r, err := ParseIPRange(tt.input)
+		if ok := err == nil; ok != tt.ok {
+			t.Fatalf("[%d] %s: expected ok: %v but got error: %v", i, tt.input, tt.ok, err)
+		}
+
+		if r != tt.expected {
+			t.Fatalf("[%d] %s: expected: %#+v but got: %#+v", i, tt.input, tt.expected, r)
+		}
+
+		if tt.ok && !IPInRange(r, net.ParseIP(tt.expected.End)) {
+			t.Fatalf("[%d] %s: expected the end address to be in range", i, tt.input)
+		}
+	}
+}
@@ -739,6 +739,8 @@ func (app *Application) Build() error {
 		rv := router.NewRoutePathReverser(app.APIBuilder)
 		app.view.AddFunc("urlpath", rv.Path)
 		// app.view.AddFunc("url", rv.URL)
+		// The helpers of the imported packages, e.g. {{ csrf_field . }} of the csrf middleware.
+		app.view.Funcs(view.RegisteredFuncs())
 		if err := app.view.Load(); err != nil {
 			return fmt.Errorf("build: view engine: %v", err)
 		}
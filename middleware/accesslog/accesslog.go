@@ -48,6 +48,7 @@ func GetFields(ctx *context.Context) (fields *Fields) {
 
 // Skip called when a specific route should be skipped from the logging process.
 // It's an easy to use alternative for iris.NewConditionalHandler.
+// See `AccessLog.SetRules` for declarative sampling and filtering.
 func Skip(ctx *context.Context) {
 	ctx.Values().Set(skipLogContextKey, struct{}{})
 }
@@ -224,6 +225,8 @@ type AccessLog struct {
 	// take the field key from the extractor itself.
 	formatter Formatter
 	broker    *Broker
+	// the log rules, see `SetRules`.
+	rules atomic.Pointer[[]Rule]
 
 	// the log instance for custom formatters.
 	logsPool *sync.Pool
@@ -320,6 +323,7 @@ func New(w io.Writer) *AccessLog {
 //
 // A call of its `Close` method to unlock the underline
 // file is required on program termination.
+// See `FileRotating` for a file with size and time based rotation.
 //
 // It panics on error.
 func File(path string) *AccessLog {
@@ -727,13 +731,15 @@ func (ac *AccessLog) Handler(ctx *context.Context) {
 		path   = ctx.Path()
 	)
 
+	ruleRequestBody, ruleResponseBody := ac.recordBodies(ctx, method, path)
+
 	// Enable response recording.
-	if ac.shouldReadResponseBody() {
+	if ac.shouldReadResponseBody() || ruleResponseBody {
 		ctx.Record()
 	}
 	// Enable reading the request body
 	// multiple times (route handler and this middleware).
-	if ac.shouldReadRequestBody() {
+	if ac.shouldReadRequestBody() || ruleRequestBody {
 		ctx.RecordRequestBody(true)
 	}
 
@@ -773,6 +779,10 @@ func (ac *AccessLog) Handler(ctx *context.Context) {
 
 	latency := time.Since(startTime).Round(ac.LatencyRound)
 
+	if !ac.applyRules(ctx, method, path, ctx.GetStatusCode(), latency) {
+		return
+	}
+
 	if ac.Async {
 		ctxCopy := ctx.Clone()
 		go ac.after(ctxCopy, latency, method, path)
@@ -789,9 +799,17 @@ func (ac *AccessLog) after(ctx *context.Context, lat time.Duration, method, path
 		responseBody  string
 		bytesReceived int // total or body, depends on the configuration.
 		bytesSent     int
+		// log the bodies, a matched rule may enable them.
+		logRequestBody  = ac.RequestBody
+		logResponseBody = ac.ResponseBody
 	)
 
-	if ac.shouldReadRequestBody() {
+	if rule := getRule(ctx); rule != nil {
+		logRequestBody = logRequestBody || rule.RequestBody
+		logResponseBody = logResponseBody || rule.ResponseBody
+	}
+
+	if ac.shouldReadRequestBody() || logRequestBody {
 		//	any error handler stored ( ctx.SetErr or StopWith(Plain)Error )
 		if ctxErr := ctx.GetErr(); ctxErr != nil {
 			// If there is an error here
@@ -804,12 +822,12 @@ func (ac *AccessLog) after(ctx *context.Context, lat time.Duration, method, path
 			if ac.BytesReceivedBody {
 				bytesReceived = requestBodyLength // store it, if the total is enabled then this will be overridden.
 			}
-			if err != nil && ac.RequestBody {
+			if err != nil && logRequestBody {
 				if err != http.ErrBodyReadAfterClose { // if body was already closed, don't send it as error.
 					requestBody = ac.getErrorText(err)
 				}
 			} else if requestBodyLength > 0 {
-				if ac.RequestBody {
+				if logRequestBody {
 					if ac.BodyMinify {
 						if minified, err := ctx.Application().Minifier().Bytes(ctx.GetContentTypeRequested(), requestData); err == nil {
 							requestBody = string(minified)
@@ -835,14 +853,14 @@ func (ac *AccessLog) after(ctx *context.Context, lat time.Duration, method, path
 		}
 	}
 
-	if ac.shouldReadResponseBody() {
+	if ac.shouldReadResponseBody() || logResponseBody {
 		actualResponseData := ctx.Recorder().Body()
 		responseBodyLength := len(actualResponseData)
 
 		if ac.BytesSentBody {
 			bytesSent = responseBodyLength
 		}
-		if ac.ResponseBody && responseBodyLength > 0 {
+		if logResponseBody && responseBodyLength > 0 {
 			if ac.BodyMinify {
 				// Copy response data as minifier now can change the back slice,
 				// fixes: https://github.com/kataras/iris-premium/issues/17.
@@ -1015,12 +1033,15 @@ func (ac *AccessLog) Print(ctx *context.Context,
 		builder.WriteByte(ac.Delim)
 	}
 
-	if ac.RequestBody {
+	// The bodies of a matched rule are printed even if the fields are disabled.
+	rule := getRule(ctx)
+
+	if ac.RequestBody || (rule != nil && rule.RequestBody) {
 		ac.writeText(builder, reqBody)
 		builder.WriteByte(ac.Delim)
 	}
 
-	if ac.ResponseBody {
+	if ac.ResponseBody || (rule != nil && rule.ResponseBody) {
 		ac.writeText(builder, respBody)
 		builder.WriteByte(ac.Delim)
 	}
@@ -0,0 +1,142 @@
+package accesslog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Combined is a Formatter type for the NCSA Combined Log Format,
+// as used by the Apache and NGINX web servers:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+//
+// The user is the ctx.User()'s username and the size is the BytesSent.
+// The quoted values are escaped like Apache does.
+type Combined struct {
+	// Common, if true, prints the Common Log Format instead,
+	// which does not contain the referer and the user agent.
+	Common bool
+	// Fields is a list of custom field names, see `GetFields` and `AddFields`,
+	// which are appended as quoted values, in the same order.
+	// A missing field is printed as "-".
+	Fields []string
+
+	ac      *AccessLog
+	bufPool sync.Pool
+}
+
+// combinedTimeFormat is the time format of the NCSA logs.
+const combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// SetOutput keeps the AccessLog output.
+func (f *Combined) SetOutput(dest io.Writer) {
+	f.ac, _ = dest.(*AccessLog)
+	f.bufPool.New = func() any { return new(bytes.Buffer) }
+}
+
+// Format prints the log in Combined (or Common) Log Format.
+func (f *Combined) Format(log *Log) (bool, error) {
+	buf := f.bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		f.bufPool.Put(buf)
+	}()
+
+	writeDash(buf, log.IP)
+	buf.WriteString(" - ")
+	writeDash(buf, escapeApache(log.Username()))
+
+	buf.WriteString(" [")
+	buf.WriteString(log.Now.Format(combinedTimeFormat))
+	buf.WriteString("] \"")
+
+	buf.WriteString(escapeApache(log.Method))
+	buf.WriteByte(' ')
+	buf.WriteString(escapeApache(log.RequestURI()))
+	buf.WriteByte(' ')
+	buf.WriteString(escapeApache(log.Proto()))
+	buf.WriteString("\" ")
+
+	buf.WriteString(strconv.Itoa(log.Code))
+	buf.WriteByte(' ')
+	if log.BytesSent > 0 {
+		buf.WriteString(strconv.Itoa(log.BytesSent))
+	} else {
+		buf.WriteByte('-')
+	}
+
+	if !f.Common {
+		writeQuoted(buf, escapeApache(log.Referer()))
+		writeQuoted(buf, escapeApache(log.UserAgent()))
+	}
+
+	for _, name := range f.Fields {
+		var value string
+		if entry, ok := log.Fields.GetEntry(name); ok {
+			value = fmt.Sprintf("%v", entry.ValueRaw)
+		}
+
+		writeQuoted(buf, escapeApache(value))
+	}
+
+	buf.WriteByte(newLine)
+
+	_, err := f.ac.Write(buf.Bytes())
+	return true, err
+}
+
+// writeDash writes the "s" or "-" if it's empty.
+func writeDash(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+
+	buf.WriteString(s)
+}
+
+// writeQuoted writes a space and the quoted "s" or "-" if it's empty.
+func writeQuoted(buf *bytes.Buffer, s string) {
+	buf.WriteString(` "`)
+	writeDash(buf, s)
+	buf.WriteByte('"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// escapeApache escapes the quotes, backslashes and
+// non-printable bytes (as \xhh) of "s" like the Apache logs do.
+func escapeApache(s string) string {
+	needsEscape := false
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '"' || c == '\\' || c < 0x20 || c >= 0x7f {
+			needsEscape = true
+			break
+		}
+	}
+
+	if !needsEscape {
+		return s
+	}
+
+	b := make([]byte, 0, len(s)+8)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c == '\n':
+			b = append(b, '\\', 'n')
+		case c == '\t':
+			b = append(b, '\\', 't')
+		case c < 0x20 || c >= 0x7f:
+			b = append(b, '\\', 'x', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+
+	return string(b)
+}
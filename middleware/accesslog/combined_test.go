@@ -0,0 +1,65 @@
+package accesslog
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/memstore"
+)
+
+func newFormatterTestContext(t *testing.T, target string) *context.Context {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Referer", "https://example.com/start?a=b")
+	req.Header.Set("User-Agent", `Mozilla/5.0 "quoted"`)
+
+	ctx := new(context.Context)
+	ctx.ResetRequest(req)
+	ctx.SetUser(&context.SimpleUser{Username: "frank"})
+	return ctx
+}
+
+func TestCombined(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ac := New(buf)
+	ac.Clock = TClock(time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600)))
+	ac.SetFormatter(&Combined{Fields: []string{"trace_id", "missing"}})
+
+	ctx := newFormatterTestContext(t, "/apache pb.gif?q=1")
+	ac.Print(ctx, time.Millisecond, "", 200, "GET", "/apache pb.gif", "127.0.0.1", "", "", 0, 2326,
+		nil, []memstore.StringEntry{{Key: "q", Value: "1"}}, memstore.Store{{Key: "trace_id", ValueRaw: "a\nb"}})
+	ac.Print(nil, time.Millisecond, "", 404, "GET", "/", "", "", "", 0, 0, nil, nil, nil)
+
+	expected := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache%20pb.gif?q=1 HTTP/1.1" 200 2326 "https://example.com/start?a=b" "Mozilla/5.0 \"quoted\"" "a\nb" "-"
+- - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" 404 - "-" "-" "-" "-"
+`
+
+	ac.Close()
+	if got := buf.String(); expected != got {
+		t.Fatalf("expected:\n%s\n\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestEscapeApache(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"plain", "plain"},
+		{`a"b\c`, `a\"b\\c`},
+		{"tab\tnl\n\x01", `tab\tnl\n\x01`},
+		{"é", `\xc3\xa9`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeApache(tt.input); got != tt.expected {
+			t.Fatalf("%q: expected: %s but got: %s", tt.input, tt.expected, got)
+		}
+	}
+}
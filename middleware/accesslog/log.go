@@ -3,6 +3,7 @@ package accesslog
 import (
 	"fmt"
 	"io"
+	"net/url"
 	"strings"
 	"time"
 
@@ -78,6 +79,76 @@ func (l *Log) RequestValuesLine() string {
 	return requestValues
 }
 
+// Referer returns the request's "Referer" header, if any.
+func (l *Log) Referer() string {
+	if l.Ctx == nil {
+		return ""
+	}
+
+	return l.Ctx.GetHeader("Referer")
+}
+
+// UserAgent returns the request's "User-Agent" header, if any.
+func (l *Log) UserAgent() string {
+	if l.Ctx == nil {
+		return ""
+	}
+
+	return l.Ctx.GetHeader("User-Agent")
+}
+
+// Username returns the authenticated user's username, if any.
+// See Context.SetUser.
+func (l *Log) Username() string {
+	if l.Ctx == nil {
+		return ""
+	}
+
+	if u := l.Ctx.User(); u != nil {
+		username, _ := u.GetUsername()
+		return username
+	}
+
+	return ""
+}
+
+// Proto returns the request's protocol, e.g. "HTTP/1.1".
+func (l *Log) Proto() string {
+	if l.Ctx == nil || l.Ctx.Request() == nil {
+		return "HTTP/1.1"
+	}
+
+	return l.Ctx.Request().Proto
+}
+
+// RawQuery returns the encoded URL query, without the '?'.
+func (l *Log) RawQuery() string {
+	if l.Ctx != nil && l.Ctx.Request() != nil {
+		return l.Ctx.Request().URL.RawQuery
+	}
+
+	if len(l.Query) == 0 {
+		return ""
+	}
+
+	values := make(url.Values, len(l.Query))
+	for _, entry := range l.Query {
+		values.Add(entry.Key, entry.Value)
+	}
+
+	return values.Encode()
+}
+
+// RequestURI returns the escaped path and the query of the request.
+func (l *Log) RequestURI() string {
+	uri := (&url.URL{Path: l.Path}).EscapedPath()
+	if query := l.RawQuery(); query != "" {
+		uri += "?" + query
+	}
+
+	return uri
+}
+
 // BytesReceivedLine returns the formatted bytes received length.
 func (l *Log) BytesReceivedLine() string {
 	if !l.Logger.BytesReceived && !l.Logger.BytesReceivedBody {
@@ -0,0 +1,129 @@
+package accesslog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Logfmt is a Formatter type for logfmt (key=value) logs, e.g.
+//
+//	time=2006-01-02T15:04:05Z07:00 method=GET path=/ code=200 latency=1ms ip=::1 user_agent="Mozilla/5.0 ..."
+//
+// The path parameters are prefixed by "param.", the URL query by "query."
+// and the custom fields are printed as they are. Empty values are omitted.
+type Logfmt struct {
+	// TimeFormat is the time format of the "time" key.
+	// Defaults to time.RFC3339.
+	TimeFormat string
+
+	ac      *AccessLog
+	bufPool sync.Pool
+}
+
+// SetOutput keeps the AccessLog output.
+func (f *Logfmt) SetOutput(dest io.Writer) {
+	f.ac, _ = dest.(*AccessLog)
+	f.bufPool.New = func() any { return new(bytes.Buffer) }
+
+	if f.TimeFormat == "" {
+		f.TimeFormat = time.RFC3339
+	}
+}
+
+// Format prints the log in logfmt.
+func (f *Logfmt) Format(log *Log) (bool, error) {
+	buf := f.bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		f.bufPool.Put(buf)
+	}()
+
+	writeLogfmt(buf, "time", log.Now.Format(f.TimeFormat))
+	writeLogfmt(buf, "method", log.Method)
+	writeLogfmt(buf, "path", log.Path)
+	writeLogfmt(buf, "code", strconv.Itoa(log.Code))
+	writeLogfmt(buf, "latency", log.Latency.String())
+	writeLogfmt(buf, "ip", log.IP)
+	writeLogfmt(buf, "user", log.Username())
+	writeLogfmt(buf, "referer", log.Referer())
+	writeLogfmt(buf, "user_agent", log.UserAgent())
+
+	if f.ac.BytesReceived || f.ac.BytesReceivedBody {
+		writeLogfmt(buf, "bytes_received", strconv.Itoa(log.BytesReceived))
+	}
+	if f.ac.BytesSent || f.ac.BytesSentBody {
+		writeLogfmt(buf, "bytes_sent", strconv.Itoa(log.BytesSent))
+	}
+
+	for _, entry := range log.PathParams {
+		writeLogfmt(buf, "param."+entry.Key, fmt.Sprintf("%v", entry.ValueRaw))
+	}
+	for _, entry := range log.Query {
+		writeLogfmt(buf, "query."+entry.Key, entry.Value)
+	}
+	for _, entry := range log.Fields {
+		writeLogfmt(buf, entry.Key, fmt.Sprintf("%v", entry.ValueRaw))
+	}
+
+	writeLogfmt(buf, "request", log.Request)
+	writeLogfmt(buf, "response", log.Response)
+
+	buf.WriteByte(newLine)
+
+	_, err := f.ac.Write(buf.Bytes())
+	return true, err
+}
+
+// writeLogfmt writes the key=value pair, it's separated by a space from the previous one.
+// The value is quoted if it contains spaces, equal signs, quotes or
+// non-printable characters. Empty values are skipped.
+func writeLogfmt(buf *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+
+	if buf.Len() > 0 {
+		buf.WriteByte(space)
+	}
+
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte(eq)
+
+	if needsLogfmtQuote(value) {
+		buf.WriteString(strconv.Quote(value))
+		return
+	}
+
+	buf.WriteString(value)
+}
+
+func needsLogfmtQuote(s string) bool {
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logfmtKey replaces the characters which are not allowed in a key with underscores.
+func logfmtKey(key string) string {
+	if !needsLogfmtQuote(key) {
+		return key
+	}
+
+	b := []byte(key)
+	for i, c := range b {
+		if c <= ' ' || c == '=' || c == '"' || c >= 0x7f {
+			b[i] = '_'
+		}
+	}
+
+	return string(b)
+}
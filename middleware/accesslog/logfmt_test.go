@@ -0,0 +1,31 @@
+package accesslog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/core/memstore"
+)
+
+func TestLogfmt(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ac := New(buf)
+	ac.RequestBody = false
+	ac.Clock = TClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	ac.SetFormatter(new(Logfmt))
+
+	ctx := newFormatterTestContext(t, "/users/42?q=a+b")
+	ac.Print(ctx, time.Millisecond, "", 200, "GET", "/users/42", "::1", "", "", 12, 345,
+		memstore.Store{{Key: "id", ValueRaw: 42}},
+		[]memstore.StringEntry{{Key: "q", Value: "a b"}},
+		memstore.Store{{Key: "user id", ValueRaw: "x=y"}, {Key: "empty", ValueRaw: ""}})
+	ac.Close()
+
+	expected := `time=2026-01-02T03:04:05Z method=GET path=/users/42 code=200 latency=1ms ip=::1 user=frank ` +
+		`referer="https://example.com/start?a=b" user_agent="Mozilla/5.0 \"quoted\"" bytes_received=12 bytes_sent=345 ` +
+		`param.id=42 query.q="a b" user_id="x=y"` + "\n"
+	if got := buf.String(); expected != got {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,601 @@
+package accesslog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kataras/golog"
+	"github.com/klauspost/compress/zstd"
+)
+
+// RotateSchedule is the time-based rotation schedule of a RotatingFile.
+type RotateSchedule uint8
+
+const (
+	// NoSchedule disables the time-based rotation.
+	NoSchedule RotateSchedule = iota
+	// Hourly rotates the file at the start of every hour.
+	Hourly
+	// Daily rotates the file at midnight.
+	Daily
+)
+
+// next returns the next rotation time after "t",
+// the zero time if the schedule is disabled.
+func (s RotateSchedule) next(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	switch s {
+	case Hourly:
+		return time.Date(year, month, day, t.Hour()+1, 0, 0, 0, t.Location())
+	case Daily:
+		return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// Compression is the compression algorithm of the rotated files.
+type Compression uint8
+
+const (
+	// NoCompression keeps the rotated files as they are.
+	NoCompression Compression = iota
+	// Gzip compresses the rotated files with gzip, the ".gz" extension is appended.
+	Gzip
+	// Zstd compresses the rotated files with zstandard, the ".zst" extension is appended.
+	Zstd
+)
+
+func (c Compression) ext() string {
+	switch c {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// rotatedTimeFormat is the time format of the rotated file names,
+// e.g. "access-2006-01-02T15-04-05.000.log".
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateOptions holds the configuration for a RotatingFile.
+type RotateOptions struct {
+	// MaxSize, if greater than zero, rotates the file
+	// before a write which would make it larger than "MaxSize" bytes.
+	MaxSize int64
+	// Schedule rotates the file hourly or daily.
+	// Defaults to NoSchedule.
+	Schedule RotateSchedule
+	// Compression compresses the rotated files in the background.
+	// Defaults to NoCompression.
+	Compression Compression
+	// MaxBackups, if greater than zero, is the maximum number of rotated files to keep,
+	// the oldest ones are removed.
+	MaxBackups int
+	// MaxAge, if greater than zero, is the maximum age of the rotated files to keep.
+	MaxAge time.Duration
+	// BufferSize, if greater than zero, buffers the writes,
+	// the buffer is flushed on AccessLog's Flush and Close and before rotation.
+	BufferSize int
+	// ReopenOnSIGHUP reopens the file when the process receives a SIGHUP signal,
+	// so external tools like logrotate can move the file away.
+	ReopenOnSIGHUP bool
+	// Clock overrides the time.Now for the Schedule and the rotated file names.
+	Clock Clock
+	// ErrorHandler is fired on background errors, e.g. compression and retention ones.
+	// Defaults to a golog error.
+	ErrorHandler func(err error)
+}
+
+// RotatingFile is an io.Writer which writes to a file
+// and rotates it based on its size and/or a time schedule.
+// The rotated files are named after the original one,
+// with the rotation time before the extension, e.g. "access-2006-01-02T15-04-05.000.log".
+//
+// It completes the Flusher and io.Closer interfaces,
+// so it's automatically flushed and closed by the AccessLog.
+// Initialize with the `NewRotatingFile` package-level function.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	size     int64
+	rotateAt time.Time
+	loc      *time.Location
+	// onNewFile is called when an empty file is opened, see OnNewFile.
+	onNewFile func(w io.Writer)
+
+	millCh   chan struct{}
+	millDone chan struct{}
+	sighup   chan os.Signal
+	closed   bool
+}
+
+var (
+	_ io.Writer = (*RotatingFile)(nil)
+	_ Flusher   = (*RotatingFile)(nil)
+	_ io.Closer = (*RotatingFile)(nil)
+)
+
+// NewRotatingFile opens or creates the file of "path" for appending
+// and returns a new RotatingFile.
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if opts.Clock == nil {
+		opts.Clock = clockFunc(time.Now)
+	}
+
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = func(err error) {
+			golog.Errorf("accesslog: rotate: %v", err)
+		}
+	}
+
+	f := &RotatingFile{
+		path:     path,
+		opts:     opts,
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	now := opts.Clock.Now()
+	f.rotateAt = opts.Schedule.next(now)
+	f.loc = now.Location() // of the rotated file names.
+
+	go f.runMill()
+
+	if opts.ReopenOnSIGHUP {
+		f.sighup = make(chan os.Signal, 1)
+		signal.Notify(f.sighup, syscall.SIGHUP)
+		go func() {
+			for range f.sighup {
+				if err := f.Reopen(); err != nil {
+					f.opts.ErrorHandler(err)
+				}
+			}
+		}()
+	}
+
+	return f, nil
+}
+
+// FileRotating returns a new AccessLog value which writes to
+// a RotatingFile of the given "path".
+// A call of its `Close` method is required on program termination.
+//
+// It panics on error.
+//
+// Example Code:
+//
+//	ac := accesslog.FileRotating("./access.log", accesslog.RotateOptions{
+//		MaxSize:     100 << 20, // 100MB
+//		Schedule:    accesslog.Daily,
+//		Compression: accesslog.Gzip,
+//		MaxBackups:  30,
+//	})
+//	defer ac.Close()
+func FileRotating(path string, opts RotateOptions) *AccessLog {
+	f, err := NewRotatingFile(path, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return New(f)
+}
+
+func (f *RotatingFile) open() error {
+	if dir := filepath.Dir(f.path); dir != "" {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	if f.opts.BufferSize > 0 {
+		f.buf = bufio.NewWriterSize(file, f.opts.BufferSize)
+	}
+
+	if f.size == 0 && f.onNewFile != nil {
+		f.onNewFile(rotatingFileWriter{f})
+	}
+
+	return nil
+}
+
+// OnNewFile registers a function which is called whenever an empty file is opened:
+// now, if the current file is empty, and after each rotation and reopen.
+// The "w" writes directly to the new file, e.g. the header of a log format,
+// the writes of "w" never trigger a rotation.
+// It replaces any previously registered function.
+func (f *RotatingFile) OnNewFile(cb func(w io.Writer)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.onNewFile = cb
+	if cb != nil && f.file != nil && f.size == 0 {
+		cb(rotatingFileWriter{f})
+	}
+}
+
+// rotatingFileWriter writes to the current file of a RotatingFile, its lock must be held.
+type rotatingFileWriter struct {
+	f *RotatingFile
+}
+
+func (w rotatingFileWriter) Write(p []byte) (int, error) {
+	return w.f.write(p)
+}
+
+func (f *RotatingFile) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+
+	var err error
+	if f.buf != nil {
+		err = f.buf.Flush()
+	}
+
+	if cErr := f.file.Close(); err == nil {
+		err = cErr
+	}
+
+	f.file, f.buf = nil, nil
+	return err
+}
+
+// Write writes "p" to the current file, it rotates the file first if necessary.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if f.file == nil { // a previous rotate or reopen failed.
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	return f.write(p)
+}
+
+// write writes "p" to the current file, without rotation.
+func (f *RotatingFile) write(p []byte) (int, error) {
+	var (
+		n   int
+		err error
+	)
+	if f.buf != nil {
+		n, err = f.buf.Write(p)
+	} else {
+		n, err = f.file.Write(p)
+	}
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+
+	return !f.rotateAt.IsZero() && !f.opts.Clock.Now().Before(f.rotateAt)
+}
+
+// Rotate rotates the file immediately.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	return f.rotate()
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		// the file is closed anyway, rotate it.
+		f.opts.ErrorHandler(err)
+	}
+
+	now := f.opts.Clock.Now()
+	f.rotateAt = f.opts.Schedule.next(now)
+
+	rotated := f.backupName(now)
+	if err := os.Rename(f.path, rotated); err != nil && !os.IsNotExist(err) {
+		// try to keep writing to the current file.
+		if oErr := f.open(); oErr != nil {
+			f.opts.ErrorHandler(oErr)
+		}
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	// notify the mill, it's already notified if the channel is full.
+	select {
+	case f.millCh <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// backupName returns the name of the rotated file, e.g. "access-2006-01-02T15-04-05.000.log".
+func (f *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := f.nameParts()
+	name := prefix + t.Format(rotatedTimeFormat)
+
+	// Do not overwrite a previous backup of the same millisecond.
+	rotated := name + ext
+	for i := 1; fileExists(rotated) || fileExists(rotated+f.opts.Compression.ext()); i++ {
+		rotated = name + "." + strconv.Itoa(i) + ext
+	}
+
+	return rotated
+}
+
+// nameParts returns the prefix, e.g. "logs/access-", and the extension, e.g. ".log",
+// of the rotated files.
+func (f *RotatingFile) nameParts() (string, string) {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-", ext
+}
+
+// runMill compresses the rotated files and removes the old backups
+// in the background, one rotation at a time.
+func (f *RotatingFile) runMill() {
+	for range f.millCh {
+		if err := f.mill(); err != nil {
+			f.opts.ErrorHandler(err)
+		}
+	}
+
+	close(f.millDone)
+}
+
+func (f *RotatingFile) mill() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	if f.opts.Compression != NoCompression {
+		for i, b := range backups {
+			if b.compressed {
+				continue
+			}
+
+			if err = compressFile(b.name, f.opts.Compression); err != nil {
+				f.opts.ErrorHandler(err)
+				continue
+			}
+
+			backups[i].name += f.opts.Compression.ext()
+		}
+	}
+
+	now := f.opts.Clock.Now()
+	for i, b := range backups {
+		expired := (f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups) ||
+			(f.opts.MaxAge > 0 && now.Sub(b.rotatedAt) > f.opts.MaxAge)
+		if !expired {
+			continue
+		}
+
+		if err = os.Remove(b.name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type backup struct {
+	name       string
+	rotatedAt  time.Time
+	compressed bool
+}
+
+// Backups returns the rotated files, newest first.
+func (f *RotatingFile) Backups() ([]string, error) {
+	backups, err := f.backups()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(backups))
+	for _, b := range backups {
+		names = append(names, b.name)
+	}
+
+	return names, nil
+}
+
+func (f *RotatingFile) backups() ([]backup, error) {
+	prefix, ext := f.nameParts()
+	dir := filepath.Dir(f.path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(name, Gzip.ext()), Zstd.ext())
+		if !strings.HasSuffix(trimmed, ext) {
+			continue
+		}
+
+		// Parse the time part, so files with a similar name are not touched.
+		timePart := strings.TrimSuffix(strings.TrimPrefix(trimmed, prefix), ext)
+		if len(timePart) < len(rotatedTimeFormat) {
+			continue
+		}
+
+		rotatedAt, err := time.ParseInLocation(rotatedTimeFormat, timePart[:len(rotatedTimeFormat)], f.loc)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backup{name: name, rotatedAt: rotatedAt, compressed: trimmed != name})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].rotatedAt.Equal(backups[j].rotatedAt) {
+			return backups[i].name > backups[j].name
+		}
+		return backups[i].rotatedAt.After(backups[j].rotatedAt)
+	})
+
+	return backups, nil
+}
+
+// Reopen closes and reopens the file of the path.
+// Call it after the file was moved by an external tool,
+// see the ReopenOnSIGHUP option too.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	if err := f.closeFile(); err != nil {
+		f.opts.ErrorHandler(err)
+	}
+
+	return f.open()
+}
+
+// Flush writes any buffered data to the file.
+func (f *RotatingFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.buf == nil {
+		return nil
+	}
+
+	return f.buf.Flush()
+}
+
+// Close flushes and closes the file and
+// waits for the background compression and retention to finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+
+	f.closed = true
+	err := f.closeFile()
+	f.mu.Unlock()
+
+	if f.sighup != nil {
+		signal.Stop(f.sighup)
+		close(f.sighup)
+	}
+
+	close(f.millCh)
+	<-f.millDone
+	return err
+}
+
+func compressFile(name string, c Compression) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+c.ext(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	var w io.WriteCloser
+	switch c {
+	case Zstd:
+		w, err = zstd.NewWriter(dst)
+		if err != nil {
+			dst.Close()
+			return err
+		}
+	default:
+		w = gzip.NewWriter(dst)
+	}
+
+	_, err = io.Copy(w, src)
+	if cErr := w.Close(); err == nil {
+		err = cErr
+	}
+	if cErr := dst.Close(); err == nil {
+		err = cErr
+	}
+
+	if err != nil {
+		os.Remove(name + c.ext())
+		return err
+	}
+
+	src.Close()
+	return os.Remove(name)
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,180 @@
+package accesslog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+	// step is added after each Now call.
+	step time.Duration
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch filepath.Ext(name) {
+	case ".gz":
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case ".zst":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestRotatingFileMaxSize(t *testing.T) {
+	for _, c := range []Compression{Gzip, Zstd} {
+		path := filepath.Join(t.TempDir(), "access.log")
+		ac := FileRotating(path, RotateOptions{
+			MaxSize:     20,
+			Compression: c,
+			MaxBackups:  2,
+			BufferSize:  64,
+			Clock:       &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), step: time.Second},
+		})
+
+		for _, line := range []string{"line 1....\n", "line 2....\n", "line 3....\n", "line 4....\n", "line 5....\n"} {
+			if _, err := ac.Write([]byte(line)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Flushes the buffer and waits for the compression.
+		if err := ac.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := readFile(t, path); got != "line 5....\n" {
+			t.Fatalf("[%d] expected current file to contain the last line but got: %q", c, got)
+		}
+
+		f, _ := NewRotatingFile(path, RotateOptions{})
+		backups, err := f.Backups()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(backups) != 2 {
+			t.Fatalf("[%d] expected 2 backups but got: %v", c, backups)
+		}
+
+		for i, expected := range []string{"line 4....\n", "line 3....\n"} {
+			if !strings.HasSuffix(backups[i], ".log"+c.ext()) {
+				t.Fatalf("[%d] expected a compressed backup but got: %s", c, backups[i])
+			}
+
+			if got := readFile(t, backups[i]); got != expected {
+				t.Fatalf("[%d] expected backup %s to contain %q but got: %q", c, backups[i], expected, got)
+			}
+		}
+	}
+}
+
+func TestRotatingFileSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	clock := &testClock{now: time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC)}
+
+	f, err := NewRotatingFile(path, RotateOptions{Schedule: Hourly, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("10:30\n"))
+	clock.Set(time.Date(2026, 1, 1, 10, 59, 59, 0, time.UTC))
+	f.Write([]byte("10:59\n"))
+	clock.Set(time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC))
+	f.Write([]byte("11:00\n"))
+
+	backup := filepath.Join(filepath.Dir(path), "access-2026-01-01T11-00-00.000.log")
+	if got := readFile(t, backup); got != "10:30\n10:59\n" {
+		t.Fatalf("expected backup to contain the previous hour's logs but got: %q", got)
+	}
+
+	if got := readFile(t, path); got != "11:00\n" {
+		t.Fatalf("expected current file to contain the current hour's logs but got: %q", got)
+	}
+
+	if expected, got := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Daily.next(clock.Now()); !got.Equal(expected) {
+		t.Fatalf("expected next daily rotation at: %s but got: %s", expected, got)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+
+	f, err := NewRotatingFile(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("before\n"))
+
+	// Like logrotate does.
+	moved := filepath.Join(dir, "access.log.1")
+	if err = os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Write([]byte("after\n"))
+
+	if got := readFile(t, moved); got != "before\n" {
+		t.Fatalf("expected moved file to contain: before but got: %q", got)
+	}
+	if got := readFile(t, path); got != "after\n" {
+		t.Fatalf("expected reopened file to contain: after but got: %q", got)
+	}
+}
@@ -0,0 +1,243 @@
+package accesslog
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+const ruleContextKey = "iris.accesslog.request.rule"
+
+// Rule is a declarative log rule, see the `AccessLog.SetRules` method.
+// A rule matches a request if all of its non-zero conditions match.
+//
+// Example Code:
+//
+//	ac.SetRules(
+//		// always log errors and slow requests, with their bodies.
+//		accesslog.Rule{MinCode: 500, RequestBody: true, ResponseBody: true},
+//		accesslog.Rule{MinLatency: time.Second},
+//		// drop health checks and static assets.
+//		accesslog.Rule{Paths: []string{"/health", "/static/*"}, Drop: true},
+//		// log 1% of the successful API requests.
+//		accesslog.Rule{Paths: []string{"/api/*"}, MinCode: 200, MaxCode: 299, Sample: 0.01},
+//		// log 10% of the successful requests of a route.
+//		accesslog.Rule{Routes: []string{"/users/{id:uint64}"}, MinCode: 200, MaxCode: 299, Sample: 0.1},
+//	)
+type Rule struct {
+	// Methods, if not empty, matches the request methods.
+	Methods []string
+	// Paths, if not empty, matches the request paths.
+	// A path which ends with "*" matches any path with that prefix,
+	// e.g. "/static/*".
+	Paths []string
+	// Routes, if not empty, matches the path templates of the routes,
+	// as they were registered, e.g. "/users/{id:uint64}".
+	// A route which ends with "*" matches any route with that prefix.
+	// The requests which did not match a route do not match.
+	//
+	// Note that when the AccessLog is registered through UseRouter the route
+	// is not known before the request is routed, so the bodies of
+	// a rule with Routes are recorded for all the requests which match its Methods and Paths.
+	Routes []string
+	// MinCode and MaxCode, if not zero, match the response status codes
+	// of the inclusive range, e.g. 500 to match the server errors.
+	MinCode int
+	MaxCode int
+	// MinLatency, if not zero, matches the requests which took at least that long.
+	MinLatency time.Duration
+
+	// Drop does not log the matched requests.
+	Drop bool
+	// Sample, if between zero and one, logs that fraction of the matched requests,
+	// e.g. 0.1 logs the 10% of them. Zero logs all of them.
+	Sample float64
+	// RequestBody and ResponseBody record and log the bodies of the matched requests,
+	// even if the AccessLog's RequestBody and ResponseBody fields are false.
+	// Note that the bodies are recorded for all the requests
+	// which match the Methods, Paths and Routes of the rule.
+	RequestBody  bool
+	ResponseBody bool
+}
+
+func (r *Rule) validate() error {
+	if r.Sample < 0 || r.Sample > 1 {
+		return fmt.Errorf("accesslog: rule: sample %v out of range [0, 1]", r.Sample)
+	}
+
+	if r.MinCode > 0 && r.MaxCode > 0 && r.MinCode > r.MaxCode {
+		return fmt.Errorf("accesslog: rule: min code %d greater than max code %d", r.MinCode, r.MaxCode)
+	}
+
+	for _, method := range r.Methods {
+		if method == "" {
+			return fmt.Errorf("accesslog: rule: empty method")
+		}
+	}
+
+	return nil
+}
+
+// matchRequest reports whether the method and the path match.
+func (r *Rule) matchRequest(method, path string) bool {
+	if len(r.Methods) > 0 {
+		matched := false
+		for _, m := range r.Methods {
+			if strings.EqualFold(m, method) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	if len(r.Paths) > 0 && !matchPath(r.Paths, path) {
+		return false
+	}
+
+	return true
+}
+
+// matchRoute reports whether the route's path template matches,
+// an empty route is a request which did not match a route.
+func (r *Rule) matchRoute(route string) bool {
+	if len(r.Routes) == 0 {
+		return true
+	}
+
+	return route != "" && matchPath(r.Routes, route)
+}
+
+// matchPath reports whether the path matches any of the patterns,
+// a pattern which ends with "*" matches any path with that prefix.
+func matchPath(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match reports whether the rule matches the request and its response.
+func (r *Rule) match(method, path, route string, code int, latency time.Duration) bool {
+	if r.MinCode > 0 && code < r.MinCode {
+		return false
+	}
+
+	if r.MaxCode > 0 && code > r.MaxCode {
+		return false
+	}
+
+	if r.MinLatency > 0 && latency < r.MinLatency {
+		return false
+	}
+
+	return r.matchRequest(method, path) && r.matchRoute(route)
+}
+
+// SetRules replaces the log rules, it's safe to call at serve-time.
+// The first rule which matches a request decides if and how it's logged,
+// requests which do not match any rule are logged as usual.
+// On error the previous rules are kept.
+func (ac *AccessLog) SetRules(rules ...Rule) error {
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	rules = append([]Rule(nil), rules...)
+	ac.rules.Store(&rules)
+	return nil
+}
+
+// Rules returns a copy of the current log rules.
+func (ac *AccessLog) Rules() []Rule {
+	if rules := ac.rules.Load(); rules != nil {
+		return append([]Rule(nil), *rules...)
+	}
+
+	return nil
+}
+
+// recordBodies reports whether a rule needs to record the request or the response body.
+func (ac *AccessLog) recordBodies(ctx *context.Context, method, path string) (request bool, response bool) {
+	rules := ac.rules.Load()
+	if rules == nil {
+		return
+	}
+
+	// The route is not known yet when registered through UseRouter.
+	currentRoute := ctx.GetCurrentRoute()
+
+	for i := range *rules {
+		r := &(*rules)[i]
+		if !r.RequestBody && !r.ResponseBody {
+			continue
+		}
+
+		if r.matchRequest(method, path) && (currentRoute == nil || r.matchRoute(currentRoute.Path())) {
+			request = request || r.RequestBody
+			response = response || r.ResponseBody
+		}
+	}
+
+	return
+}
+
+// applyRules reports whether the request should be logged,
+// the matched rule is stored to the context to log the bodies.
+func (ac *AccessLog) applyRules(ctx *context.Context, method, path string, code int, latency time.Duration) bool {
+	rules := ac.rules.Load()
+	if rules == nil {
+		return true
+	}
+
+	route := ""
+	if currentRoute := ctx.GetCurrentRoute(); currentRoute != nil {
+		route = currentRoute.Path()
+	}
+
+	for i := range *rules {
+		r := &(*rules)[i]
+		if !r.match(method, path, route, code, latency) {
+			continue
+		}
+
+		if r.Drop || (r.Sample > 0 && rand.Float64() >= r.Sample) {
+			return false
+		}
+
+		if r.RequestBody || r.ResponseBody {
+			ctx.Values().Set(ruleContextKey, r)
+		}
+
+		return true
+	}
+
+	return true
+}
+
+func getRule(ctx *context.Context) *Rule {
+	if ctx == nil {
+		return nil
+	}
+
+	if r, ok := ctx.Values().Get(ruleContextKey).(*Rule); ok {
+		return r
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package accesslog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestAccessLogRules(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ac := New(buf)
+	ac.RequestBody = false
+	ac.BytesReceivedBody = false
+	ac.BytesSentBody = false
+	ac.IP = false
+
+	err := ac.SetRules(
+		Rule{MinCode: 500, RequestBody: true},
+		Rule{Paths: []string{"/slow"}, MinLatency: 20 * time.Millisecond},
+		Rule{Paths: []string{"/health", "/static/*", "/slow"}, Drop: true},
+		Rule{Paths: []string{"/api/*"}, MinCode: 200, MaxCode: 299, Sample: 0.000001},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := iris.New()
+	app.UseRouter(ac.Handler)
+	ok := func(ctx iris.Context) { ctx.WriteString("OK") }
+	app.Get("/health", ok)
+	app.Get("/static/{file:path}", ok)
+	app.Get("/other", ok)
+	app.Get("/api/items", ok)
+	app.Get("/api/broken", func(ctx iris.Context) {
+		ctx.StatusCode(iris.StatusInternalServerError)
+	})
+	app.Post("/api/items", func(ctx iris.Context) {
+		ctx.StatusCode(iris.StatusServiceUnavailable)
+	})
+	app.Get("/slow", func(ctx iris.Context) {
+		time.Sleep(30 * time.Millisecond)
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/health").Expect().Status(httptest.StatusOK)
+	e.GET("/static/js/app.js").Expect().Status(httptest.StatusOK)
+	e.GET("/slow").Expect().Status(httptest.StatusOK)
+	e.GET("/other").Expect().Status(httptest.StatusOK)
+	for i := 0; i < 10; i++ {
+		e.GET("/api/items").Expect().Status(httptest.StatusOK)
+	}
+	e.GET("/api/broken").Expect().Status(httptest.StatusInternalServerError)
+	e.POST("/api/items").WithText("payload").Expect().Status(httptest.StatusServiceUnavailable)
+
+	logs := buf.String()
+	for _, expected := range []string{"|GET|/slow|", "|GET|/other|", "|GET|/api/broken|", "|POST|/api/items|payload|"} {
+		if !strings.Contains(logs, expected) {
+			t.Fatalf("expected logs to contain %q but got:\n%s", expected, logs)
+		}
+	}
+
+	for _, unexpected := range []string{"/health", "/static/js/app.js", "|GET|/api/items|"} {
+		if strings.Contains(logs, unexpected) {
+			t.Fatalf("expected logs to not contain %q but got:\n%s", unexpected, logs)
+		}
+	}
+
+	// Invalid rules keep the previous ones.
+	if err = ac.SetRules(Rule{Sample: 2}); err == nil {
+		t.Fatalf("expected an error on invalid sample")
+	}
+	if n := len(ac.Rules()); n != 4 {
+		t.Fatalf("expected 4 rules but got: %d", n)
+	}
+
+	// Runtime reload.
+	if err = ac.SetRules(); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	e.GET("/health").Expect().Status(httptest.StatusOK)
+	if logs = buf.String(); !strings.Contains(logs, "|GET|/health|") {
+		t.Fatalf("expected logs to contain /health after reload but got:\n%s", logs)
+	}
+}
+
+func TestAccessLogRulesRoutes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ac := New(buf)
+	ac.RequestBody = false
+	ac.BytesReceivedBody = false
+	ac.BytesSentBody = false
+	ac.IP = false
+
+	err := ac.SetRules(
+		Rule{Routes: []string{"/users/{id:uint64}"}, MinCode: 200, MaxCode: 299, Drop: true},
+		Rule{Routes: []string{"/*"}, Methods: []string{"POST"}, RequestBody: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := iris.New()
+	app.UseRouter(ac.Handler)
+	app.Get("/users/{id:uint64}", func(ctx iris.Context) {
+		ctx.WriteString("user")
+	})
+	app.Post("/echo", func(ctx iris.Context) {
+		body, _ := ctx.GetBody()
+		ctx.Write(body)
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/users/1").Expect().Status(httptest.StatusOK)
+	e.GET("/users/2").Expect().Status(httptest.StatusOK)
+	e.GET("/users/invalid").Expect().Status(httptest.StatusNotFound)
+	e.POST("/echo").WithText("payload").Expect().Status(httptest.StatusOK).Body().IsEqual("payload")
+	e.POST("/unknown").WithText("payload").Expect().Status(httptest.StatusNotFound)
+
+	logs := buf.String()
+	for _, expected := range []string{"|GET|/users/invalid|", "|POST|/echo|payload|", "|POST|/unknown|\n"} {
+		if !strings.Contains(logs, expected) {
+			t.Fatalf("expected logs to contain %q but got:\n%s", expected, logs)
+		}
+	}
+
+	for _, unexpected := range []string{"/users/1", "/users/2"} {
+		if strings.Contains(logs, unexpected) {
+			t.Fatalf("expected logs to not contain %q but got:\n%s", unexpected, logs)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package accesslog
+
+import (
+	stdContext "context"
+	"io"
+	"log/slog"
+)
+
+// Slog is a Formatter which emits the logs as log/slog records,
+// so the access logs can share the same structured pipeline as the rest of the logs.
+// The record's message is "access" and its attributes are the `Log.Attrs`.
+// Note that the AccessLog's output is not used.
+//
+// Example Code:
+//
+//	ac := accesslog.New(io.Discard)
+//	ac.SetFormatter(&accesslog.Slog{Handler: slog.NewJSONHandler(os.Stdout, nil)})
+//
+// Use the iris.NewSlogHandler(app.Logger()) to send them to the Application's logger instead.
+type Slog struct {
+	// Handler is the slog Handler of the records.
+	// Defaults to the slog.Default().Handler().
+	Handler slog.Handler
+	// Level returns the level of the record.
+	// Defaults to error for 5xx, warn for 4xx and info for the rest status codes.
+	Level func(log *Log) slog.Level
+}
+
+// SetOutput sets the default Handler and Level.
+func (f *Slog) SetOutput(dest io.Writer) {
+	if f.Handler == nil {
+		f.Handler = slog.Default().Handler()
+	}
+
+	if f.Level == nil {
+		f.Level = func(log *Log) slog.Level {
+			switch {
+			case log.Code >= 500:
+				return slog.LevelError
+			case log.Code >= 400:
+				return slog.LevelWarn
+			default:
+				return slog.LevelInfo
+			}
+		}
+	}
+}
+
+// Format emits the log as a slog record.
+func (f *Slog) Format(log *Log) (bool, error) {
+	ctx := stdContext.Background()
+	if log.Ctx != nil {
+		ctx = log.Ctx.Request().Context()
+	}
+
+	level := f.Level(log)
+	if !f.Handler.Enabled(ctx, level) {
+		return true, nil
+	}
+
+	r := slog.NewRecord(log.Now, level, "access", 0)
+	r.AddAttrs(log.Attrs()...)
+
+	return true, f.Handler.Handle(ctx, r)
+}
+
+// Attrs returns the log as log/slog attributes.
+// The path parameters and the URL query are grouped under the "params" and "query" keys,
+// the custom fields are added as they are. Empty values are omitted.
+func (l *Log) Attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", l.Method),
+		slog.String("path", l.Path),
+		slog.Int("code", l.Code),
+		slog.Duration("latency", l.Latency),
+	}
+
+	addString := func(key, value string) {
+		if value != "" {
+			attrs = append(attrs, slog.String(key, value))
+		}
+	}
+
+	addString("ip", l.IP)
+	addString("user", l.Username())
+	addString("referer", l.Referer())
+	addString("user_agent", l.UserAgent())
+
+	if l.BytesReceived > 0 {
+		attrs = append(attrs, slog.Int("bytes_received", l.BytesReceived))
+	}
+	if l.BytesSent > 0 {
+		attrs = append(attrs, slog.Int("bytes_sent", l.BytesSent))
+	}
+
+	if len(l.PathParams) > 0 {
+		params := make([]any, 0, len(l.PathParams))
+		for _, entry := range l.PathParams {
+			params = append(params, slog.Any(entry.Key, entry.ValueRaw))
+		}
+		attrs = append(attrs, slog.Group("params", params...))
+	}
+	if len(l.Query) > 0 {
+		query := make([]any, 0, len(l.Query))
+		for _, entry := range l.Query {
+			query = append(query, slog.String(entry.Key, entry.Value))
+		}
+		attrs = append(attrs, slog.Group("query", query...))
+	}
+	for _, entry := range l.Fields {
+		attrs = append(attrs, slog.Any(entry.Key, entry.ValueRaw))
+	}
+
+	addString("request", l.Request)
+	addString("response", l.Response)
+
+	return attrs
+}
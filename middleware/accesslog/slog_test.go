@@ -0,0 +1,79 @@
+package accesslog
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/memstore"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func newSlogTestHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	})
+}
+
+func TestSlog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ac := New(io.Discard)
+	ac.RequestBody = false
+	ac.SetFormatter(&Slog{Handler: newSlogTestHandler(buf)})
+
+	ctx := newFormatterTestContext(t, "/users/42?q=a+b")
+	ac.Print(ctx, time.Millisecond, "", 200, "GET", "/users/42", "::1", "", "", 12, 345,
+		memstore.Store{{Key: "id", ValueRaw: 42}},
+		[]memstore.StringEntry{{Key: "q", Value: "a b"}},
+		memstore.Store{{Key: "trace_id", ValueRaw: "abc"}})
+	ac.Print(ctx, time.Millisecond, "", 500, "GET", "/", "", "", "", 0, 0, nil, nil, nil)
+	ac.Close()
+
+	expected := `level=INFO msg=access method=GET path=/users/42 code=200 latency=1ms ip=::1 user=frank ` +
+		`referer="https://example.com/start?a=b" user_agent="Mozilla/5.0 \"quoted\"" bytes_received=12 bytes_sent=345 ` +
+		`params.id=42 query.q="a b" trace_id=abc
+level=ERROR msg=access method=GET path=/ code=500 latency=1ms user=frank referer="https://example.com/start?a=b" user_agent="Mozilla/5.0 \"quoted\""
+`
+	if got := buf.String(); expected != got {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestSlogPipeline(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	app := iris.New()
+	app.Logger().Handle(iris.NewGologHandler(newSlogTestHandler(buf)))
+
+	ac := New(io.Discard)
+	ac.LatencyRound = time.Hour
+	ac.SetFormatter(&Slog{Handler: iris.NewSlogHandler(app.Logger())})
+	app.UseRouter(ac.Handler)
+	app.Get("/users/{id}", func(ctx iris.Context) {
+		ctx.SetID("req-1")
+		ctx.SetUser(&context.SimpleUser{ID: "42"})
+		ctx.Logger().WithGroup("job").Info("hello", "count", 1)
+		ctx.Logger().Debug("hidden")
+	}).Name = "user"
+
+	e := httptest.New(t, app, httptest.LogLevel("info"))
+	e.GET("/users/7").Expect().Status(httptest.StatusOK)
+	ac.Close()
+
+	expected := `level=INFO msg=hello job.count=1 request_id=req-1 route=user user_id=42
+level=INFO msg=access code=200 latency=0s method=GET params.id=7 path=/users/7
+`
+	if got := buf.String(); expected != got {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,170 @@
+package accesslog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// W3C is a Formatter type for the W3C Extended Log File Format,
+// see https://www.w3.org/TR/WD-logfile.html.
+// The directives header, which contains the "#Fields:" one,
+// is written on SetOutput if the destination does not start with it.
+// When the destination is a RotatingFile the header is written
+// at the start of every new file instead.
+//
+// The fields are "date time c-ip cs-username cs-method cs-uri-stem cs-uri-query
+// sc-status cs-bytes sc-bytes time-taken cs-version cs(User-Agent) cs(Referer)"
+// plus the custom Fields, prefixed by "x-". The date and time are in UTC
+// and the time-taken is in seconds.
+type W3C struct {
+	// Software is the value of the "#Software:" directive.
+	// Defaults to "Iris".
+	Software string
+	// Fields is a list of custom field names, see `GetFields` and `AddFields`,
+	// which are appended as "x-{name}" fields, in the same order.
+	Fields []string
+
+	ac      *AccessLog
+	bufPool sync.Pool
+}
+
+const w3cFields = "date time c-ip cs-username cs-method cs-uri-stem cs-uri-query " +
+	"sc-status cs-bytes sc-bytes time-taken cs-version cs(User-Agent) cs(Referer)"
+
+// SetOutput writes the directives header, if it's missing.
+func (f *W3C) SetOutput(dest io.Writer) {
+	f.ac, _ = dest.(*AccessLog)
+	f.bufPool.New = func() any { return new(bytes.Buffer) }
+
+	if f.Software == "" {
+		f.Software = "Iris"
+	}
+
+	if f.ac != nil {
+		// Each rotated file needs its own header.
+		if rotatingFile, ok := f.ac.Writer.(*RotatingFile); ok {
+			rotatingFile.OnNewFile(f.writeHeader)
+			return
+		}
+
+		// If the destination is a reader, e.g. accesslog.File,
+		// check if the header already exists.
+		if destReader, ok := f.ac.Writer.(io.Reader); ok {
+			b := make([]byte, len("#Version:"))
+			if n, _ := io.ReadFull(destReader, b); n == len(b) && string(b) == "#Version:" {
+				return
+			}
+		}
+	}
+
+	f.writeHeader(dest)
+}
+
+// writeHeader writes the directives header.
+func (f *W3C) writeHeader(w io.Writer) {
+	fields := w3cFields
+	for _, name := range f.Fields {
+		fields += " x-" + name
+	}
+
+	fmt.Fprintf(w, "#Version: 1.0\n#Software: %s\n#Date: %s\n#Fields: %s\n",
+		f.Software, time.Now().UTC().Format("2006-01-02 15:04:05"), fields)
+}
+
+// Format prints the log in W3C Extended Log File Format.
+func (f *W3C) Format(log *Log) (bool, error) {
+	buf := f.bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		f.bufPool.Put(buf)
+	}()
+
+	now := log.Now.UTC()
+	buf.WriteString(now.Format("2006-01-02"))
+	buf.WriteByte(' ')
+	buf.WriteString(now.Format("15:04:05"))
+
+	writeW3C(buf, log.IP)
+	writeW3C(buf, log.Username())
+	writeW3C(buf, log.Method)
+	buf.WriteByte(' ')
+	buf.WriteString((&url.URL{Path: log.Path}).EscapedPath())
+	buf.WriteByte(' ')
+	writeDash(buf, log.RawQuery())
+
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(log.Code))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(log.BytesReceived))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(log.BytesSent))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.FormatFloat(log.Latency.Seconds(), 'f', 3, 64))
+
+	writeW3C(buf, log.Proto())
+	writeW3C(buf, log.UserAgent())
+	writeW3C(buf, log.Referer())
+
+	for _, name := range f.Fields {
+		var value string
+		if entry, ok := log.Fields.GetEntry(name); ok {
+			value = fmt.Sprintf("%v", entry.ValueRaw)
+		}
+
+		writeW3C(buf, value)
+	}
+
+	buf.WriteByte(newLine)
+
+	_, err := f.ac.Write(buf.Bytes())
+	return true, err
+}
+
+// writeW3C writes a space and the "s" or "-" if it's empty.
+// Values with spaces, quotes or control characters are quoted,
+// the quotes are doubled and the control characters are replaced with spaces.
+func writeW3C(buf *bytes.Buffer, s string) {
+	buf.WriteByte(' ')
+
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+
+	if !needsW3CQuote(s) {
+		buf.WriteString(s)
+		return
+	}
+
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			buf.WriteString(`""`)
+		case c < 0x20:
+			buf.WriteByte(' ')
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func needsW3CQuote(s string) bool {
+	if s == "-" {
+		return true
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == ' ' || c == '"' || c < 0x20 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package accesslog
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/core/memstore"
+)
+
+func TestW3C(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ac := New(buf)
+	ac.Clock = TClock(time.Date(2026, 1, 2, 5, 4, 3, 0, time.FixedZone("", 2*3600)))
+	ac.SetFormatter(&W3C{Fields: []string{"trace_id"}})
+
+	ctx := newFormatterTestContext(t, "/users/42?sort=name")
+	ac.Print(ctx, 1500*time.Millisecond, "", 200, "GET", "/users/42", "::1", "", "", 12, 345,
+		nil, nil, memstore.Store{{Key: "trace_id", ValueRaw: "abc"}})
+	ac.Print(nil, time.Millisecond, "", 404, "GET", "/a b", "", "", "", 0, 0, nil, nil, nil)
+	ac.Close()
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected 4 directives and 2 logs but got:\n%s", buf.String())
+	}
+
+	if !strings.HasPrefix(lines[0], "#Version: 1.0") || lines[1] != "#Software: Iris" || !strings.HasPrefix(lines[2], "#Date: ") {
+		t.Fatalf("unexpected directives:\n%s", buf.String())
+	}
+
+	expectedFields := "#Fields: date time c-ip cs-username cs-method cs-uri-stem cs-uri-query " +
+		"sc-status cs-bytes sc-bytes time-taken cs-version cs(User-Agent) cs(Referer) x-trace_id"
+	if lines[3] != expectedFields {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expectedFields, lines[3])
+	}
+
+	expected := `2026-01-02 03:04:03 ::1 frank GET /users/42 sort=name 200 12 345 1.500 HTTP/1.1 "Mozilla/5.0 ""quoted""" https://example.com/start?a=b abc`
+	if lines[4] != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, lines[4])
+	}
+
+	expected = `2026-01-02 03:04:03 - - GET /a%20b - 404 0 0 0.001 HTTP/1.1 - - -`
+	if lines[5] != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, lines[5])
+	}
+}
+
+func TestW3CRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), step: time.Second}
+	newAccessLog := func() *AccessLog {
+		ac := FileRotating(path, RotateOptions{Clock: clock})
+		ac.SetFormatter(&W3C{})
+		return ac
+	}
+
+	logPath := func(ac *AccessLog, path string) {
+		ac.Print(nil, time.Millisecond, "", 200, "GET", path, "", "", "", 0, 0, nil, nil, nil)
+	}
+
+	ac := newAccessLog()
+	logPath(ac, "/first")
+	if err := ac.Writer.(*RotatingFile).Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	logPath(ac, "/second")
+	ac.Close()
+
+	// Restart, the current file has a header already.
+	ac = newAccessLog()
+	logPath(ac, "/third")
+	ac.Close()
+
+	f, _ := NewRotatingFile(path, RotateOptions{})
+	backups, err := f.Backups()
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("expected a single backup but got: %v", backups)
+	}
+
+	for name, paths := range map[string][]string{backups[0]: {"/first"}, path: {"/second", "/third"}} {
+		contents := readFile(t, name)
+		if !strings.HasPrefix(contents, "#Version: 1.0\n") || strings.Count(contents, "#Fields: ") != 1 {
+			t.Fatalf("expected %s to start with a single header but got:\n%s", name, contents)
+		}
+
+		for _, p := range paths {
+			if !strings.Contains(contents, " GET "+p+" ") {
+				t.Fatalf("expected %s to contain %s but got:\n%s", name, p, contents)
+			}
+		}
+	}
+}
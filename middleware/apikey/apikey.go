@@ -0,0 +1,236 @@
+// Package apikey implements a static API key authentication middleware
+// for machine-to-machine clients. The keys are stored only as hashes
+// and they are looked up by their public prefixed identifier.
+package apikey
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/apikey.*", "iris.apikey")
+}
+
+const (
+	authorizationType = "API Key"
+	keyContextKey     = "iris.apikey.key"
+)
+
+var (
+	// ErrMissing is fired when the request does not contain an API key.
+	ErrMissing = errors.New("apikey: missing")
+	// ErrInvalid is fired when the API key is malformed, unknown or its hash does not match.
+	ErrInvalid = errors.New("apikey: invalid")
+	// ErrExpired is fired when the API key has been expired.
+	ErrExpired = errors.New("apikey: expired")
+	// ErrInsufficientScope is fired when the API key does not have the required scopes.
+	ErrInsufficientScope = errors.New("apikey: insufficient scope")
+)
+
+// Extractor is a function that takes a context as input and returns
+// a plain API key. An empty string should be returned if no key found.
+type Extractor func(*context.Context) string
+
+// FromHeader returns an Extractor which reads the key from the given request header,
+// e.g. FromHeader("X-API-Key").
+func FromHeader(key string) Extractor {
+	return func(ctx *context.Context) string {
+		return ctx.GetHeader(key)
+	}
+}
+
+// FromQuery returns an Extractor which reads the key from the given url query parameter.
+// Note that url queries are usually logged by proxies and servers.
+func FromQuery(param string) Extractor {
+	return func(ctx *context.Context) string {
+		return ctx.URLParam(param)
+	}
+}
+
+// FromBearer is an Extractor which reads the key from the
+// Authorization request header of form: Authorization: "Bearer {key}".
+func FromBearer(ctx *context.Context) string {
+	authHeader := ctx.GetHeader("Authorization")
+	if len(authHeader) > 7 && strings.EqualFold(authHeader[:7], "bearer ") {
+		return authHeader[7:]
+	}
+
+	return ""
+}
+
+// Options holds the necessary information that the API key middleware needs to perform.
+// The only required value is the Store field.
+type Options struct {
+	// Store is the only one required field, it's used
+	// to look up the keys by their identifier.
+	// See `NewMemStore` package-level function.
+	Store Store
+	// Prefix, if not empty, rejects the keys with a different prefix,
+	// e.g. "acme" for "acme_..." keys.
+	Prefix string
+	// Extractors are the functions to read the API key from the request,
+	// the first non-empty value is used.
+	//
+	// Defaults to FromHeader("X-API-Key") and FromBearer.
+	Extractors []Extractor
+	// Scopes are the required scopes of the keys for all routes,
+	// use the `RequireScopes` handler for per-route scopes.
+	Scopes []string
+	// ErrorHandler handles the authentication failures,
+	// the "err" is one of the ErrMissing, ErrInvalid, ErrExpired,
+	// ErrInsufficientScope or a Store's error.
+	//
+	// Defaults to the DefaultErrorHandler.
+	ErrorHandler func(ctx *context.Context, err error)
+}
+
+// DefaultErrorHandler is the default error handler.
+// It sends 403 on ErrInsufficientScope, 500 on Store errors and 401 otherwise.
+// The error messages are not sent to the client.
+func DefaultErrorHandler(ctx *context.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInsufficientScope):
+		ctx.StopWithError(http.StatusForbidden, context.PrivateError(err))
+	case errors.Is(err, ErrMissing), errors.Is(err, ErrInvalid), errors.Is(err, ErrExpired):
+		ctx.StopWithError(http.StatusUnauthorized, context.PrivateError(err))
+	default:
+		ctx.StopWithError(http.StatusInternalServerError, context.PrivateError(err))
+	}
+}
+
+// APIKey holds the options of the API key authentication middleware.
+// Initialize with the `New` package-level function.
+type APIKey struct {
+	opts Options
+}
+
+// New returns a new API key authentication middleware.
+// On success, the Key is available through the `Get` package-level function
+// and the context User is filled with the key's identifier, name (username), scopes (roles)
+// and fields.
+//
+// Example Code:
+//
+//	plain, key, _ := apikey.Generate("acme")
+//	key.Name = "billing-service"
+//	key.Scopes = []string{"orders:read"}
+//	store := apikey.NewMemStore(key)
+//
+//	api := app.Party("/api", apikey.New(apikey.Options{Store: store, Prefix: "acme"}))
+//	api.Get("/orders", apikey.RequireScopes("orders:read"), listOrders)
+func New(opts Options) context.Handler {
+	if opts.Store == nil {
+		panic("apikey: Store field is required")
+	}
+
+	if len(opts.Extractors) == 0 {
+		opts.Extractors = []Extractor{FromHeader("X-API-Key"), FromBearer}
+	}
+
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+
+	a := &APIKey{opts: opts}
+	return a.serveHTTP
+}
+
+func (a *APIKey) extract(ctx *context.Context) string {
+	for _, extract := range a.opts.Extractors {
+		if plain := extract(ctx); plain != "" {
+			return plain
+		}
+	}
+
+	return ""
+}
+
+func (a *APIKey) serveHTTP(ctx *context.Context) {
+	plain := a.extract(ctx)
+	if plain == "" {
+		a.opts.ErrorHandler(ctx, ErrMissing)
+		return
+	}
+
+	prefix, id, ok := parse(plain)
+	if !ok || (a.opts.Prefix != "" && prefix != a.opts.Prefix) {
+		a.opts.ErrorHandler(ctx, ErrInvalid)
+		return
+	}
+
+	key, err := a.opts.Store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			err = ErrInvalid
+		}
+
+		a.opts.ErrorHandler(ctx, err)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(Hash(plain)), []byte(key.Hash)) != 1 {
+		a.opts.ErrorHandler(ctx, ErrInvalid)
+		return
+	}
+
+	now := time.Now()
+	if key.Expired(now) {
+		a.opts.ErrorHandler(ctx, ErrExpired)
+		return
+	}
+
+	if !key.HasScopes(a.opts.Scopes...) {
+		a.opts.ErrorHandler(ctx, ErrInsufficientScope)
+		return
+	}
+
+	ctx.Values().Set(keyContextKey, key)
+	ctx.SetUser(&context.SimpleUser{
+		Authorization: authorizationType,
+		AuthorizedAt:  now,
+		ID:            key.ID,
+		Username:      key.Name,
+		Roles:         key.Scopes,
+		Fields:        key.Fields,
+	})
+
+	ctx.Next()
+}
+
+// Get returns the Key of the current request.
+// It's only available after the API key middleware is executed.
+func Get(ctx *context.Context) *Key {
+	if v := ctx.Values().Get(keyContextKey); v != nil {
+		if key, ok := v.(*Key); ok {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// RequireScopes returns a handler which allows only the keys
+// which have all of the given scopes. It should be registered after
+// the API key middleware, otherwise it sends 401.
+func RequireScopes(scopes ...string) context.Handler {
+	return func(ctx *context.Context) {
+		key := Get(ctx)
+		if key == nil {
+			DefaultErrorHandler(ctx, ErrMissing)
+			return
+		}
+
+		if !key.HasScopes(scopes...) {
+			DefaultErrorHandler(ctx, ErrInsufficientScope)
+			return
+		}
+
+		ctx.Next()
+	}
+}
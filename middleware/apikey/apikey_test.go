@@ -0,0 +1,65 @@
+package apikey_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/apikey"
+)
+
+func TestAPIKey(t *testing.T) {
+	plain, key, err := apikey.Generate("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Name = "billing-service"
+	key.Scopes = []string{"orders:read"}
+
+	expiredPlain, expiredKey, err := apikey.Generate("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredKey.ExpiresAt = time.Now().Add(-time.Minute)
+
+	otherPlain, otherKey, err := apikey.Generate("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := apikey.NewMemStore(key, expiredKey, otherKey)
+
+	app := iris.New()
+	api := app.Party("/api", apikey.New(apikey.Options{Store: store, Prefix: "acme"}))
+	handler := func(ctx iris.Context) {
+		username, _ := ctx.User().GetUsername()
+		ctx.WriteString(username)
+	}
+	api.Get("/orders", apikey.RequireScopes("orders:read"), handler)
+	api.Post("/orders", apikey.RequireScopes("orders:write"), handler)
+
+	e := httptest.New(t, app)
+
+	e.GET("/api/orders").Expect().Status(httptest.StatusUnauthorized)
+	e.GET("/api/orders").WithHeader("X-API-Key", plain).Expect().
+		Status(httptest.StatusOK).Body().IsEqual("billing-service")
+	e.GET("/api/orders").WithHeader("Authorization", "Bearer "+plain).Expect().
+		Status(httptest.StatusOK).Body().IsEqual("billing-service")
+	e.POST("/api/orders").WithHeader("X-API-Key", plain).Expect().Status(httptest.StatusForbidden)
+
+	// Invalid checksum.
+	last := "x"
+	if plain[len(plain)-1] == 'x' {
+		last = "y"
+	}
+	e.GET("/api/orders").WithHeader("X-API-Key", plain[:len(plain)-1]+last).Expect().
+		Status(httptest.StatusUnauthorized)
+	// Different prefix.
+	e.GET("/api/orders").WithHeader("X-API-Key", otherPlain).Expect().Status(httptest.StatusUnauthorized)
+	e.GET("/api/orders").WithHeader("X-API-Key", expiredPlain).Expect().Status(httptest.StatusUnauthorized)
+
+	// Revoked.
+	store.Delete(key.ID)
+	e.GET("/api/orders").WithHeader("X-API-Key", plain).Expect().Status(httptest.StatusUnauthorized)
+}
@@ -0,0 +1,171 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// DefaultPrefix is the default prefix of the generated keys.
+const DefaultPrefix = "iris"
+
+const (
+	idLength       = 12
+	secretLength   = 30
+	checksumLength = 6
+
+	base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// Key holds the stored information of an API key.
+// The plain key value is never stored, only its hash.
+type Key struct {
+	// ID is the public identifier of the key, it's part of the plain key value
+	// and it's used to look up the key in the Store.
+	ID string `json:"id" yaml:"ID"`
+	// Hash is the hex encoded SHA-256 hash of the plain key value, see `Hash` function.
+	Hash string `json:"hash" yaml:"Hash"`
+	// Name is the identity of the key's owner, e.g. "billing-service".
+	// It's the username of the context User.
+	Name string `json:"name" yaml:"Name"`
+	// Scopes is the list of the permissions of the key, e.g. "orders:read".
+	// The "*" scope allows everything.
+	// They are the roles of the context User.
+	Scopes []string `json:"scopes,omitempty" yaml:"Scopes"`
+	// ExpiresAt is the expiration time of the key, zero for no expiration.
+	ExpiresAt time.Time `json:"expires_at,omitempty" yaml:"ExpiresAt"`
+	// CreatedAt is the time that the key was generated.
+	CreatedAt time.Time `json:"created_at,omitempty" yaml:"CreatedAt"`
+	// Fields holds any custom information of the key,
+	// they are the fields of the context User.
+	Fields context.Map `json:"fields,omitempty" yaml:"Fields"`
+}
+
+// Expired reports whether the key has been expired at the given time.
+func (k *Key) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)
+}
+
+// HasScopes reports whether the key has all of the given scopes.
+func (k *Key) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !k.hasScope(scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (k *Key) hasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Generate returns a new random plain API key value and its Key information.
+// The plain key has the form of {prefix}_{id}_{secret}, where the
+// last characters of the secret are a checksum, so typos and random strings
+// are rejected without a Store look up. The prefix makes the keys easy to
+// identify by secret scanners, e.g. "acme" for "acme_..." keys.
+//
+// The plain key should be shown to the client once,
+// the returned Key (filled with the Name, Scopes and ExpiresAt)
+// should be saved to the Store.
+func Generate(prefix string) (string, *Key, error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	if strings.Contains(prefix, "_") {
+		return "", nil, errors.New("apikey: prefix should not contain underscores")
+	}
+
+	id, err := randomString(idLength)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret, err := randomString(secretLength)
+	if err != nil {
+		return "", nil, err
+	}
+
+	body := prefix + "_" + id + "_" + secret
+	plain := body + checksum(body)
+
+	key := &Key{
+		ID:        id,
+		Hash:      Hash(plain),
+		CreatedAt: time.Now(),
+	}
+
+	return plain, key, nil
+}
+
+// Hash returns the hex encoded SHA-256 hash of a plain key value.
+// The generated keys have enough entropy, a slow password hash function is not required.
+func Hash(plain string) string {
+	h := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(h[:])
+}
+
+// parse validates the format and the checksum of a plain key value
+// and returns its prefix and id.
+func parse(plain string) (prefix, id string, ok bool) {
+	parts := strings.SplitN(plain, "_", 3)
+	if len(parts) != 3 {
+		return
+	}
+
+	prefix, id = parts[0], parts[1]
+	if prefix == "" || len(id) != idLength || len(parts[2]) != secretLength+checksumLength {
+		return
+	}
+
+	n := len(plain) - checksumLength
+	if checksum(plain[:n]) != plain[n:] {
+		return
+	}
+
+	return prefix, id, true
+}
+
+func checksum(s string) string {
+	// Keep it unsigned, an int is 32-bit on 32-bit platforms.
+	sum := crc32.ChecksumIEEE([]byte(s))
+
+	b := make([]byte, checksumLength)
+	for i := checksumLength - 1; i >= 0; i-- {
+		b[i] = base62[sum%uint32(len(base62))]
+		sum /= uint32(len(base62))
+	}
+
+	return string(b)
+}
+
+func randomString(n int) (string, error) {
+	max := big.NewInt(int64(len(base62)))
+
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = base62[idx.Int64()]
+	}
+
+	return string(b), nil
+}
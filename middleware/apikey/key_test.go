@@ -0,0 +1,15 @@
+package apikey
+
+import "testing"
+
+func TestChecksum(t *testing.T) {
+	// The CRC32 of "key2" does not fit in a 32-bit int.
+	for plain, expected := range map[string]string{
+		"key2":                  "3Jt0v4",
+		"ak_0123456789abcdef_x": "3Fo8t8",
+	} {
+		if got := checksum(plain); got != expected {
+			t.Fatalf("[%s] expected checksum: %s but got: %s", plain, expected, got)
+		}
+	}
+}
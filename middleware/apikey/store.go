@@ -0,0 +1,67 @@
+package apikey
+
+import (
+	stdContext "context"
+	"errors"
+	"sync"
+)
+
+// ErrNotFound should be returned by a Store when a key does not exist.
+var ErrNotFound = errors.New("apikey: not found")
+
+// Store is the interface which API keys storage backends should implement.
+// See `NewMemStore` package-level function.
+type Store interface {
+	// Get should return the Key of the given "id"
+	// or an error of ErrNotFound if it does not exist.
+	// Any other error means that the store could not be reached.
+	Get(ctx stdContext.Context, id string) (*Key, error)
+}
+
+// MemStore is an in-memory Store. It's safe for concurrent use.
+type MemStore struct {
+	keys map[string]*Key
+	mu   sync.RWMutex
+}
+
+var _ Store = (*MemStore)(nil)
+
+// NewMemStore returns a new in-memory Store filled with the given keys.
+func NewMemStore(keys ...*Key) *MemStore {
+	s := &MemStore{
+		keys: make(map[string]*Key, len(keys)),
+	}
+
+	for _, key := range keys {
+		s.keys[key.ID] = key
+	}
+
+	return s
+}
+
+// Get implements the Store interface.
+func (s *MemStore) Get(_ stdContext.Context, id string) (*Key, error) {
+	s.mu.RLock()
+	key, ok := s.keys[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return key, nil
+}
+
+// Set adds or replaces a key.
+func (s *MemStore) Set(key *Key) {
+	s.mu.Lock()
+	s.keys[key.ID] = key
+	s.mu.Unlock()
+}
+
+// Delete removes (revokes) a key.
+func (s *MemStore) Delete(id string) {
+	s.mu.Lock()
+	delete(s.keys, id)
+	s.mu.Unlock()
+}
@@ -0,0 +1,332 @@
+// Package authz implements a declarative role and attribute based
+// authorization layer on top of the context.User.
+// Routes and Parties declare their required permissions and a Policy
+// maps the user's roles to permissions, optionally under attribute conditions.
+package authz
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/kataras/iris/v12/x/errors"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/authz.*", "iris.authz")
+}
+
+// permissionsPropertyKey is the Party property key of the required permissions.
+const permissionsPropertyKey = "iris.authz.permissions"
+
+type (
+	// Condition is an attribute condition of a permission grant.
+	// It reports whether the "user" can perform the action on the current request,
+	// e.g. whether the user is the owner of the requested resource.
+	// See `OwnerParam` and `FieldParam` package-level functions.
+	Condition func(ctx *context.Context, user context.User) bool
+
+	// Policy maps roles to permissions.
+	// A permission is a string of form "resource:action", e.g. "orders:write".
+	// A granted permission may end with the "*" wildcard,
+	// e.g. "orders:*" grants all the order actions and "*" grants everything.
+	//
+	// Initialize with the `New` package-level function.
+	Policy struct {
+		grants map[string][]grant // key = role.
+		routes map[string][]string
+		mu     sync.RWMutex
+	}
+
+	grant struct {
+		permission string
+		conditions []Condition
+	}
+
+	// RouteRequirement holds the required permissions of a route,
+	// it's the response body of the Policy's IntrospectionHandler.
+	RouteRequirement struct {
+		Method      string   `json:"method"`
+		Path        string   `json:"path"`
+		Name        string   `json:"name"`
+		Permissions []string `json:"permissions"`
+	}
+)
+
+// New returns a new empty Policy.
+//
+// Usage:
+//
+//	policy := authz.New().
+//		Grant("admin", "*").
+//		Grant("staff", "orders:read", "orders:write").
+//		GrantIf("customer", "orders:read", authz.FieldParam("customer_id", "customerID"))
+//
+//	orders := app.Party("/customers/{customerID}/orders")
+//	policy.Party(orders, "orders:read")
+//	orders.Get("/", listOrders)
+//	policy.Route(orders.Post("/", createOrder), "orders:write")
+//
+//	app.Get("/authz/routes", policy.IntrospectionHandler)
+func New() *Policy {
+	return &Policy{
+		grants: make(map[string][]grant),
+		routes: make(map[string][]string),
+	}
+}
+
+// Grant grants the given permissions to a role.
+func (p *Policy) Grant(role string, permissions ...string) *Policy {
+	p.mu.Lock()
+	for _, permission := range permissions {
+		p.grants[role] = append(p.grants[role], grant{permission: permission})
+	}
+	p.mu.Unlock()
+
+	return p
+}
+
+// GrantIf grants a permission to a role only when all of the
+// given attribute conditions are met.
+func (p *Policy) GrantIf(role, permission string, conditions ...Condition) *Policy {
+	p.mu.Lock()
+	p.grants[role] = append(p.grants[role], grant{permission: permission, conditions: conditions})
+	p.mu.Unlock()
+
+	return p
+}
+
+// Allowed reports whether the current request's user has all of the given permissions.
+// It returns false if there is no user.
+func (p *Policy) Allowed(ctx *context.Context, permissions ...string) bool {
+	_, ok := p.missing(ctx, permissions)
+	return ok
+}
+
+// missing returns the first permission that the user does not have.
+func (p *Policy) missing(ctx *context.Context, permissions []string) (string, bool) {
+	user := ctx.User()
+	if user == nil {
+		return "", false
+	}
+
+	roles, err := user.GetRoles()
+	if err != nil {
+		return "", false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, permission := range permissions {
+		if !p.allowed(ctx, user, roles, permission) {
+			return permission, false
+		}
+	}
+
+	return "", true
+}
+
+func (p *Policy) allowed(ctx *context.Context, user context.User, roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, g := range p.grants[role] {
+			if !match(g.permission, permission) {
+				continue
+			}
+
+			if g.check(ctx, user) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (g grant) check(ctx *context.Context, user context.User) bool {
+	for _, condition := range g.conditions {
+		if !condition(ctx, user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match reports whether a granted permission pattern matches the required permission.
+func match(pattern, permission string) bool {
+	if pattern == permission || pattern == "*" {
+		return true
+	}
+
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(permission, pattern[:len(pattern)-1])
+	}
+
+	return false
+}
+
+// Require returns a handler which allows only the users who have all of the given permissions.
+// It sends errors.Unauthenticated when there is no user (an authentication middleware should run first)
+// and errors.PermissionDenied when a permission is missing.
+//
+// Use the Party and Route methods instead to list the permissions
+// through the IntrospectionHandler too.
+func (p *Policy) Require(permissions ...string) context.Handler {
+	return func(ctx *context.Context) {
+		if ctx.User() == nil {
+			errors.Unauthenticated.Message(ctx, "authentication required")
+			return
+		}
+
+		if permission, ok := p.missing(ctx, permissions); !ok {
+			errors.PermissionDenied.Message(ctx, fmt.Sprintf("missing permission: %s", permission))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Party registers a Require middleware to the given Party.
+// The permissions are inherited by the routes
+// and the child Parties which are registered after this call.
+func (p *Policy) Party(party router.Party, permissions ...string) router.Party {
+	party.Use(p.Require(permissions...))
+
+	properties := party.Properties()
+	inherited, _ := properties[permissionsPropertyKey].([]string)
+	properties[permissionsPropertyKey] = appendUnique(append([]string(nil), inherited...), permissions...)
+
+	return party
+}
+
+// Route registers a Require middleware to the given route, right before its main handler,
+// so the Party's authentication middlewares run first.
+// It should be called before the application is built.
+func (p *Policy) Route(route *router.Route, permissions ...string) *router.Route {
+	insertBeforeMainHandler(route, p.Require(permissions...))
+
+	p.mu.Lock()
+	p.routes[route.Name] = appendUnique(p.routes[route.Name], permissions...)
+	p.mu.Unlock()
+
+	return route
+}
+
+// Requirements returns the routes of the application which require permissions.
+func (p *Policy) Requirements(app context.Application) []RouteRequirement {
+	var requirements []RouteRequirement
+
+	p.mu.RLock()
+	for _, r := range app.GetRoutesReadOnly() {
+		var permissions []string
+		if v, ok := r.Property(permissionsPropertyKey); ok {
+			permissions, _ = v.([]string)
+		}
+		permissions = appendUnique(append([]string(nil), permissions...), p.routes[r.Name()]...)
+
+		if len(permissions) == 0 {
+			continue
+		}
+
+		requirements = append(requirements, RouteRequirement{
+			Method:      r.Method(),
+			Path:        r.Path(),
+			Name:        r.Name(),
+			Permissions: permissions,
+		})
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(requirements, func(i, j int) bool {
+		if requirements[i].Path == requirements[j].Path {
+			return requirements[i].Method < requirements[j].Method
+		}
+
+		return requirements[i].Path < requirements[j].Path
+	})
+
+	return requirements
+}
+
+// IntrospectionHandler sends the routes which require permissions
+// as JSON body of a `RouteRequirement` list.
+// It should be protected too, e.g. policy.Route(app.Get("/authz/routes", policy.IntrospectionHandler), "authz:read").
+func (p *Policy) IntrospectionHandler(ctx *context.Context) {
+	requirements := p.Requirements(ctx.Application())
+	if requirements == nil {
+		requirements = []RouteRequirement{}
+	}
+
+	ctx.JSON(requirements)
+}
+
+// OwnerParam returns a Condition which reports whether
+// the user's ID is equal to the value of the given path parameter,
+// e.g. OwnerParam("userID") for "/users/{userID}".
+func OwnerParam(param string) Condition {
+	return func(ctx *context.Context, user context.User) bool {
+		id, err := user.GetID()
+		return err == nil && id != "" && id == ctx.Params().Get(param)
+	}
+}
+
+// FieldParam returns a Condition which reports whether
+// the user's field value is equal to the value of the given path parameter,
+// e.g. FieldParam("tenant_id", "tenantID") for "/tenants/{tenantID}".
+func FieldParam(field, param string) Condition {
+	return func(ctx *context.Context, user context.User) bool {
+		value, err := user.GetField(field)
+		if err != nil || value == nil {
+			return false
+		}
+
+		return fmt.Sprintf("%v", value) == ctx.Params().Get(param)
+	}
+}
+
+func insertBeforeMainHandler(route *router.Route, handler context.Handler) {
+	// The MainHandlerIndex does not count the path parameters evaluator handler,
+	// find the main handler by its name.
+	index := route.MainHandlerIndex
+	for i := index; i < len(route.Handlers); i++ {
+		if context.HandlerName(route.Handlers[i]) == route.MainHandlerName {
+			index = i
+			break
+		}
+	}
+
+	if index > len(route.Handlers) {
+		index = len(route.Handlers)
+	}
+
+	handlers := make(context.Handlers, 0, len(route.Handlers)+1)
+	handlers = append(handlers, route.Handlers[:index]...)
+	handlers = append(handlers, handler)
+	handlers = append(handlers, route.Handlers[index:]...)
+
+	route.Handlers = handlers
+	route.MainHandlerIndex++
+}
+
+func appendUnique(dest []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, d := range dest {
+			if d == v {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			dest = append(dest, v)
+		}
+	}
+
+	return dest
+}
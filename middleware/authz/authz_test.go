@@ -0,0 +1,61 @@
+package authz_test
+
+import (
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/authz"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := authz.New().
+		Grant("admin", "*").
+		Grant("staff", "orders:read").
+		GrantIf("customer", "orders:*", authz.OwnerParam("customerID"))
+
+	app := iris.New()
+	authenticate := func(ctx iris.Context) {
+		if role := ctx.GetHeader("X-Role"); role != "" {
+			ctx.SetUser(&iris.SimpleUser{ID: ctx.GetHeader("X-User"), Roles: []string{role}})
+		}
+		ctx.Next()
+	}
+
+	handler := func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	}
+
+	orders := app.Party("/customers/{customerID}/orders", authenticate)
+	policy.Party(orders, "orders:read")
+	orders.Get("/", handler)
+	policy.Route(orders.Post("/", handler), "orders:write")
+
+	app.Get("/authz/routes", policy.IntrospectionHandler)
+
+	e := httptest.New(t, app)
+
+	request := func(method, role, userID string) *httptest.Request {
+		return e.Request(method, "/customers/42/orders").WithHeader("X-Role", role).WithHeader("X-User", userID)
+	}
+
+	e.GET("/customers/42/orders").Expect().Status(httptest.StatusUnauthorized)
+
+	request("GET", "admin", "1").Expect().Status(httptest.StatusOK).Body().IsEqual("OK")
+	request("POST", "admin", "1").Expect().Status(httptest.StatusOK)
+
+	request("GET", "staff", "2").Expect().Status(httptest.StatusOK)
+	request("POST", "staff", "2").Expect().Status(httptest.StatusForbidden).
+		Body().Contains("PERMISSION_DENIED")
+
+	request("GET", "customer", "42").Expect().Status(httptest.StatusOK)
+	request("POST", "customer", "42").Expect().Status(httptest.StatusOK)
+	request("GET", "customer", "43").Expect().Status(httptest.StatusForbidden)
+
+	routes := e.GET("/authz/routes").Expect().Status(httptest.StatusOK).JSON().Array()
+	routes.Length().IsEqual(2)
+	routes.Value(0).Object().Value("method").IsEqual("GET")
+	routes.Value(0).Object().Value("permissions").Array().ConsistsOf("orders:read")
+	routes.Value(1).Object().Value("method").IsEqual("POST")
+	routes.Value(1).Object().Value("permissions").Array().ConsistsOf("orders:read", "orders:write")
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/kataras/iris/v12/context"
 	"github.com/kataras/iris/v12/sessions"
+
+	"golang.org/x/time/rate"
 )
 
 func init() {
@@ -26,6 +28,8 @@ const (
 	// DefaultCookieMaxAge is the default cookie max age on MaxTries,
 	// when the Options.MaxAge is zero.
 	DefaultCookieMaxAge = time.Hour
+	// DefaultFailureWindow is the default Options.FailureWindow.
+	DefaultFailureWindow = time.Minute
 )
 
 const (
@@ -110,6 +114,20 @@ type Options struct {
 	// was registered then the application will log an error.
 	// Note that this field has a priority over the MaxTriesCookie.
 	MaxTriesSession string
+	// FailureLimit, if greater than zero, limits the sign in failures of each client,
+	// identified by its remote IP address, to FailureLimit per FailureWindow.
+	// A client which exceeded the limit receives a 429 (Too Many Requests) status code,
+	// without its credentials being checked, until a failure slot is restored.
+	// Unlike the MaxTries, it's kept on the server-side and it cannot be bypassed by the client.
+	// The clients which restored all of their failure slots are removed on a next failure,
+	// at most once per FailureWindow, no separate go routine is started.
+	//
+	// Usage:
+	//  FailureLimit: 5 // 5 failures per minute.
+	FailureLimit int
+	// FailureWindow is the duration which FailureLimit failures are allowed.
+	// Defaults to 1 minute.
+	FailureWindow time.Duration
 	// ErrorHandler handles the given request credentials failure.
 	// E.g  when the client tried to access a protected resource
 	// with empty or invalid or expired credentials or
@@ -166,6 +184,13 @@ type BasicAuth struct {
 	credentials map[string]*time.Time // TODO: think of just a uint64 here (unix seconds).
 	// protects the credentials concurrent access.
 	mu sync.RWMutex
+
+	// failures stores the sign in failures rate limiter of each client,
+	// key = remote IP address. Used when FailureLimit > 0.
+	failures   map[string]*rate.Limiter
+	failuresMu sync.Mutex
+	// failuresGCAt is the last time the restored failure limiters were removed.
+	failuresGCAt time.Time
 }
 
 // New returns a new basic authentication middleware.
@@ -218,6 +243,10 @@ func New(opts Options) context.Handler {
 		opts.ErrorHandler = DefaultErrorHandler
 	}
 
+	if opts.FailureLimit > 0 && opts.FailureWindow <= 0 {
+		opts.FailureWindow = DefaultFailureWindow
+	}
+
 	b := &BasicAuth{
 		opts:                    opts,
 		askCode:                 askCode,
@@ -231,6 +260,11 @@ func New(opts Options) context.Handler {
 		go b.runGC(opts.GC.Context, opts.GC.Every)
 	}
 
+	if opts.FailureLimit > 0 {
+		b.failures = make(map[string]*rate.Limiter)
+		b.failuresGCAt = time.Now()
+	}
+
 	return b.serveHTTP
 }
 
@@ -364,6 +398,16 @@ func (b *BasicAuth) serveHTTP(ctx *context.Context) {
 		return
 	}
 
+	if b.failures != nil {
+		if retryAfter, limited := b.failureLimited(ctx.RemoteAddr()); limited {
+			b.handleError(ctx, ErrCredentialsRateLimited{
+				Username:   username,
+				RetryAfter: retryAfter,
+			})
+			return
+		}
+	}
+
 	var (
 		maxTries = b.opts.MaxTries
 		tries    int
@@ -375,6 +419,10 @@ func (b *BasicAuth) serveHTTP(ctx *context.Context) {
 
 	user, ok := b.opts.Allow(ctx, username, password)
 	if !ok { // This username:password combination was not allowed.
+		if b.failures != nil {
+			b.addFailure(ctx.RemoteAddr())
+		}
+
 		if maxTries > 0 {
 			tries++
 			b.setCurrentTries(ctx, tries)
@@ -553,3 +601,56 @@ func (b *BasicAuth) gc() int {
 
 	return n
 }
+
+// failureLimited reports whether the client has exceeded the FailureLimit
+// and the duration until a failure slot is restored.
+func (b *BasicAuth) failureLimited(remoteAddr string) (time.Duration, bool) {
+	b.failuresMu.Lock()
+	limiter, ok := b.failures[remoteAddr]
+	b.failuresMu.Unlock()
+
+	if !ok {
+		return 0, false
+	}
+
+	tokens := limiter.Tokens()
+	if tokens >= 1 {
+		return 0, false
+	}
+
+	interval := b.opts.FailureWindow / time.Duration(b.opts.FailureLimit)
+	return time.Duration((1 - tokens) * float64(interval)), true
+}
+
+// addFailure consumes a failure slot of the client.
+func (b *BasicAuth) addFailure(remoteAddr string) {
+	b.failuresMu.Lock()
+	// Instead of a separate go routine, the restored limiters
+	// are removed at most once per failure window, on a new failure.
+	if now := time.Now(); now.Sub(b.failuresGCAt) >= b.opts.FailureWindow {
+		b.failuresGC(now)
+	}
+
+	limiter, ok := b.failures[remoteAddr]
+	if !ok {
+		every := rate.Every(b.opts.FailureWindow / time.Duration(b.opts.FailureLimit))
+		limiter = rate.NewLimiter(every, b.opts.FailureLimit)
+		b.failures[remoteAddr] = limiter
+	}
+	b.failuresMu.Unlock()
+
+	limiter.Allow()
+}
+
+// failuresGC removes the failure limiters of the clients
+// which have restored all of their failure slots.
+// The caller should hold the failuresMu lock.
+func (b *BasicAuth) failuresGC(now time.Time) {
+	for remoteAddr, limiter := range b.failures {
+		if limiter.TokensAt(now) >= float64(b.opts.FailureLimit) {
+			delete(b.failures, remoteAddr)
+		}
+	}
+
+	b.failuresGCAt = now
+}
@@ -5,6 +5,7 @@ package basicauth_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/httptest"
@@ -155,3 +156,27 @@ func TestBasicAuthUseRouter(t *testing.T) {
 		sub.GET("/notfound").Expect().Status(httptest.StatusNotFound).Body().IsEqual("Not Found")
 	}
 }
+
+func TestBasicAuthFailureLimit(t *testing.T) {
+	app := iris.New()
+	auth := basicauth.New(basicauth.Options{
+		Allow:         basicauth.AllowUsers(map[string]string{"usr": "pss"}),
+		FailureLimit:  2,
+		FailureWindow: time.Hour,
+	})
+	app.Use(auth)
+	app.Get("/", func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	})
+
+	e := httptest.New(t, app)
+
+	e.GET("/").WithBasicAuth("usr", "pss").Expect().Status(httptest.StatusOK)
+	for i := 0; i < 2; i++ {
+		e.GET("/").WithBasicAuth("usr", "invalid").Expect().Status(httptest.StatusUnauthorized)
+	}
+
+	// The limit has been exceeded, even valid credentials are not checked.
+	e.GET("/").WithBasicAuth("usr", "pss").Expect().
+		Status(httptest.StatusTooManyRequests).Header("Retry-After").IsEqual("1800")
+}
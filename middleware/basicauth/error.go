@@ -2,7 +2,9 @@ package basicauth
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/kataras/iris/v12/context"
@@ -22,6 +24,13 @@ type (
 		Age      time.Duration
 	}
 
+	// ErrCredentialsRateLimited is fired when Options.FailureLimit
+	// has been exceeded by the client. The client may retry after "RetryAfter".
+	ErrCredentialsRateLimited struct {
+		Username   string
+		RetryAfter time.Duration
+	}
+
 	// ErrCredentialsMissing is fired when the authorization header is empty or malformed.
 	ErrCredentialsMissing struct {
 		Header string
@@ -62,6 +71,10 @@ func (e ErrCredentialsForbidden) Error() string {
 	return fmt.Sprintf("credentials: forbidden <%s:%s> for <%s> after <%d> attempts", e.Username, e.Password, e.Age, e.Tries)
 }
 
+func (e ErrCredentialsRateLimited) Error() string {
+	return fmt.Sprintf("credentials: too many failures <%s> retry after <%s>", e.Username, e.RetryAfter)
+}
+
 func (e ErrCredentialsMissing) Error() string {
 	if e.Header != "" {
 		return fmt.Sprintf("credentials: malformed <%s>", e.Header)
@@ -88,6 +101,9 @@ func DefaultErrorHandler(ctx *context.Context, err error) {
 		// the server should respond with the 403 Forbidden status code.
 		// Unlike 401 Unauthorized or 407 Proxy Authentication Required, authentication is impossible for this user.
 		ctx.StopWithStatus(http.StatusForbidden)
+	case ErrCredentialsRateLimited:
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+		ctx.StopWithStatus(http.StatusTooManyRequests)
 	case ErrCredentialsMissing:
 		unauthorize(ctx, e.AuthenticateHeader, e.AuthenticateHeaderValue, e.Code)
 	case ErrCredentialsInvalid:
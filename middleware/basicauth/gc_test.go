@@ -0,0 +1,31 @@
+package basicauth
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestFailuresGC(t *testing.T) {
+	b := &BasicAuth{
+		opts:         Options{FailureLimit: 2, FailureWindow: 20 * time.Millisecond},
+		failures:     make(map[string]*rate.Limiter),
+		failuresGCAt: time.Now(),
+	}
+
+	b.addFailure("a")
+	b.addFailure("b")
+	time.Sleep(3 * b.opts.FailureWindow)
+	// "a" and "b" have restored their failure slots.
+	b.addFailure("c")
+
+	b.failuresMu.Lock()
+	n := len(b.failures)
+	_, ok := b.failures["c"]
+	b.failuresMu.Unlock()
+
+	if n != 1 || !ok {
+		t.Fatalf("expected only the failure limiter of: c but got: %d limiters", n)
+	}
+}
@@ -0,0 +1,209 @@
+package basicauth
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Argon2idParams holds the argon2id parameters of the HashArgon2id function.
+type Argon2idParams struct {
+	Memory     uint32 // in KiB.
+	Iterations uint32
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultArgon2idParams are the default parameters of the HashArgon2id function,
+// based on the OWASP password storage recommendations.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:     64 * 1024,
+	Iterations: 3,
+	Threads:    2,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+// HashArgon2id returns the argon2id hash of the "password", in the
+// "$argon2id$v=19$m=65536,t=3,p=2$salt$key" form, using the DefaultArgon2idParams.
+// The result can be stored on a users file.
+func HashArgon2id(password string) (string, error) {
+	p := DefaultArgon2idParams
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Threads, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CompareHashAndPassword compares a stored password with the user input in constant time.
+// The type of the stored password is detected by its prefix:
+//
+//	"$2a$", "$2b$", "$2y$": bcrypt
+//	"$argon2id$": argon2id (see HashArgon2id)
+//	"$apr1$": Apache MD5 (htpasswd)
+//	"{SHA}": SHA-1 (htpasswd)
+//	anything else: plain text
+//
+// Reports true on success and false on failure.
+// It's the default compare function of the AllowUsers, AllowUsersFile and AllowHtpasswd functions.
+func CompareHashAndPassword(stored, userPassword string) bool {
+	switch {
+	case strings.HasPrefix(stored, "$2a$"), strings.HasPrefix(stored, "$2b$"), strings.HasPrefix(stored, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(userPassword)) == nil
+	case strings.HasPrefix(stored, "$argon2id$"):
+		return compareArgon2id(stored, userPassword)
+	case strings.HasPrefix(stored, apr1Prefix):
+		salt := strings.SplitN(strings.TrimPrefix(stored, apr1Prefix), "$", 2)[0]
+		return constantTimeEqual(stored, apr1(userPassword, salt))
+	case strings.HasPrefix(stored, shaPrefix):
+		sum := sha1.Sum([]byte(userPassword))
+		return constantTimeEqual(stored, shaPrefix+base64.StdEncoding.EncodeToString(sum[:]))
+	default:
+		return constantTimeEqual(stored, userPassword)
+	}
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// The upper limits of the stored argon2id parameters.
+const (
+	maxArgon2idMemory     = 1024 * 1024 // 1 GiB.
+	maxArgon2idIterations = 64
+)
+
+func compareArgon2id(stored, userPassword string) bool {
+	// $argon2id$v=19$m=65536,t=3,p=2$salt$key
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+
+	var (
+		memory, iterations uint32
+		threads            uint8
+	)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false
+	}
+
+	// Reject parameters which would panic or exhaust the server's resources.
+	if iterations < 1 || iterations > maxArgon2idIterations ||
+		threads < 1 || memory < 8*uint32(threads) || memory > maxArgon2idMemory {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	userKey := argon2.IDKey([]byte(userPassword), salt, iterations, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, userKey) == 1
+}
+
+const (
+	apr1Prefix = "$apr1$"
+	shaPrefix  = "{SHA}"
+)
+
+// apr1 implements the Apache variant of the MD5-based crypt algorithm,
+// the default algorithm of the htpasswd tool.
+func apr1(password, salt string) string {
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	h := md5.New()
+	h.Write([]byte(password + apr1Prefix + salt))
+
+	alt := md5.Sum([]byte(password + salt + password))
+	for i := len(password); i > 0; i -= 16 {
+		if i > 16 {
+			h.Write(alt[:])
+		} else {
+			h.Write(alt[:i])
+		}
+	}
+
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			h.Write([]byte{0})
+		} else {
+			h.Write([]byte{password[0]})
+		}
+	}
+
+	sum := h.Sum(nil)
+	for i := 0; i < 1000; i++ {
+		h.Reset()
+
+		if i&1 == 1 {
+			h.Write([]byte(password))
+		} else {
+			h.Write(sum)
+		}
+
+		if i%3 != 0 {
+			h.Write([]byte(salt))
+		}
+
+		if i%7 != 0 {
+			h.Write([]byte(password))
+		}
+
+		if i&1 == 1 {
+			h.Write(sum)
+		} else {
+			h.Write([]byte(password))
+		}
+
+		sum = h.Sum(nil)
+	}
+
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	encode := func(v uint32, n int, b *strings.Builder) {
+		for ; n > 0; n-- {
+			b.WriteByte(itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(apr1Prefix + salt + "$")
+	encode(uint32(sum[0])<<16|uint32(sum[6])<<8|uint32(sum[12]), 4, &b)
+	encode(uint32(sum[1])<<16|uint32(sum[7])<<8|uint32(sum[13]), 4, &b)
+	encode(uint32(sum[2])<<16|uint32(sum[8])<<8|uint32(sum[14]), 4, &b)
+	encode(uint32(sum[3])<<16|uint32(sum[9])<<8|uint32(sum[15]), 4, &b)
+	encode(uint32(sum[4])<<16|uint32(sum[10])<<8|uint32(sum[5]), 4, &b)
+	encode(uint32(sum[11]), 2, &b)
+
+	return b.String()
+}
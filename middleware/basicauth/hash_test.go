@@ -0,0 +1,52 @@
+package basicauth
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCompareHashAndPassword(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	argon2idHash, err := HashArgon2id("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		stored   string
+		password string
+		ok       bool
+	}{
+		{string(bcryptHash), "password", true},
+		{string(bcryptHash), "invalid", false},
+		{argon2idHash, "password", true},
+		{argon2idHash, "invalid", false},
+		{"$argon2id$v=19$m=65536,t=3,p=2$invalid", "password", false},
+		{"$argon2id$v=19$m=65536,t=0,p=2$c2FsdHNhbHQ$a2V5", "password", false},
+		{"$argon2id$v=19$m=65536,t=3,p=0$c2FsdHNhbHQ$a2V5", "password", false},
+		{"$argon2id$v=19$m=8,t=3,p=2$c2FsdHNhbHQ$a2V5", "password", false},
+		{"$argon2id$v=19$m=4294967295,t=3,p=2$c2FsdHNhbHQ$a2V5", "password", false},
+		{"$argon2id$v=19$m=65536,t=4294967295,p=2$c2FsdHNhbHQ$a2V5", "password", false},
+		// generated through: openssl passwd -apr1 -salt ...
+		{"$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/", "password", true},
+		{"$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/", "invalid", false},
+		{"$apr1$ab$8KjmIlgiRPiknqtnroEj61", "a much longer password than sixteen chars", true},
+		{"$apr1$12345678$zbBEMgfXu4mAHPrplrtNt.", "x", true},
+		// generated through: htpasswd -nbs ...
+		{"{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", "password", true},
+		{"{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", "invalid", false},
+		{"password", "password", true},
+		{"password", "passwordd", false},
+	}
+
+	for i, tt := range tests {
+		if got := CompareHashAndPassword(tt.stored, tt.password); got != tt.ok {
+			t.Fatalf("[%d] expected: %v but got: %v", i, tt.ok, got)
+		}
+	}
+}
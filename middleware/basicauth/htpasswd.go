@@ -0,0 +1,118 @@
+package basicauth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/middleware/internal/filewatch"
+)
+
+// AllowHtpasswd is an AuthFunc which authenticates user input based on
+// an Apache htpasswd file loaded on initialization.
+// The supported password formats are bcrypt ("htpasswd -B"), Apache MD5 (the htpasswd default),
+// SHA-1 ("htpasswd -s") and argon2id, see CompareHashAndPassword.
+// The crypt(3) format is not supported.
+// Pass the Reload option to reload the file on change.
+//
+// Example Code:
+//
+//	New(Options{Allow: AllowHtpasswd(".htpasswd", Reload(5*time.Second))})
+//
+// The .htpasswd file looks like the following:
+//
+//	# comments and empty lines are ignored.
+//	kataras:$2y$05$...
+//	makis:$apr1$...
+func AllowHtpasswd(filename string, opts ...UserAuthOption) AuthFunc {
+	return allowFile(filename, loadHtpasswd, opts)
+}
+
+// LoadHtpasswd same as Load but it loads the users from an Apache htpasswd file.
+// See AllowHtpasswd for details.
+//
+// Usage:
+//
+//	auth := LoadHtpasswd(".htpasswd")
+func LoadHtpasswd(filename string, userOpts ...UserAuthOption) context.Handler {
+	opts := Options{
+		Realm: DefaultRealm,
+		Allow: AllowHtpasswd(filename, userOpts...),
+	}
+	return New(opts)
+}
+
+func loadHtpasswd(filename string, opts ...UserAuthOption) (AuthFunc, error) {
+	data, err := ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := parseHtpasswd(data)
+	if err != nil {
+		return nil, fmt.Errorf("htpasswd: %s: %w", filename, err)
+	}
+
+	return userMap(users, opts...), nil
+}
+
+func parseHtpasswd(data []byte) (map[string]string, error) {
+	users := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		username, password, ok := strings.Cut(text, ":")
+		if !ok || username == "" || password == "" {
+			return nil, fmt.Errorf("malformed line: %d", line)
+		}
+
+		users[username] = password
+	}
+
+	return users, scanner.Err()
+}
+
+// fileReloader reloads an AuthFunc when its users file is modified.
+type fileReloader struct {
+	watcher *filewatch.Watcher
+	load    func() (AuthFunc, error)
+	current atomic.Value // AuthFunc.
+}
+
+func newFileReloader(filename string, every time.Duration, allow AuthFunc, load func() (AuthFunc, error)) *fileReloader {
+	r := &fileReloader{
+		watcher: filewatch.New(every, filename),
+		load:    load,
+	}
+	r.current.Store(allow)
+
+	return r
+}
+
+func (r *fileReloader) allow(ctx *context.Context, username, password string) (any, bool) {
+	if err := r.watcher.Check(r.reload); err != nil && ctx != nil {
+		// keep serving the previous users.
+		ctx.Application().Logger().Errorf("basicauth: reload: %v", err)
+	}
+
+	return r.current.Load().(AuthFunc)(ctx, username, password)
+}
+
+func (r *fileReloader) reload() error {
+	allow, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	r.current.Store(allow)
+	return nil
+}
@@ -0,0 +1,78 @@
+package basicauth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAllowHtpasswd(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".htpasswd")
+
+	write := func(contents string) {
+		t.Helper()
+
+		if err := os.WriteFile(filename, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`# comment
+kataras:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/
+
+makis:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=
+`)
+
+	allow := AllowHtpasswd(filename, Reload(10*time.Millisecond))
+
+	tests := []struct {
+		username string
+		password string
+		ok       bool
+	}{
+		{"kataras", "password", true},
+		{"makis", "password", true},
+		{"makis", "invalid", false},
+		{"unknown", "password", false},
+	}
+
+	for i, tt := range tests {
+		if _, ok := allow(nil, tt.username, tt.password); ok != tt.ok {
+			t.Fatalf("[%d] expected: %v but got: %v", i, tt.ok, ok)
+		}
+	}
+
+	write("gerasimos:password\n")
+	// make sure the modification time differs on low resolution file systems.
+	future := time.Now().Add(time.Second)
+	if err := os.Chtimes(filename, future, future); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := allow(nil, "gerasimos", "password"); !ok {
+		t.Fatalf("expected the file to be reloaded")
+	}
+	if _, ok := allow(nil, "kataras", "password"); ok {
+		t.Fatalf("expected the removed user to be rejected after reload")
+	}
+
+	// Malformed files keep the previous users.
+	write("malformed line\n")
+	later := time.Now().Add(2 * time.Second)
+	if err := os.Chtimes(filename, later, later); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := allow(nil, "gerasimos", "password"); !ok {
+		t.Fatalf("expected the previous users to be kept on a malformed file")
+	}
+}
+
+func TestParseHtpasswdMalformed(t *testing.T) {
+	if _, err := parseHtpasswd([]byte("kataras:pass\nmakis\n")); err == nil {
+		t.Fatalf("expected an error on a malformed line")
+	}
+}
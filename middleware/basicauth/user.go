@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/kataras/iris/v12/context"
 
@@ -29,17 +30,38 @@ type User interface {
 // UserAuthOptions holds optional user authentication options
 // that can be given to the builtin Default and Load (and AllowUsers, AllowUsersFile) functions.
 type UserAuthOptions struct {
-	// Defaults to plain check, can be modified for encrypted passwords,
-	// see the BCRYPT optional function.
+	// Defaults to the CompareHashAndPassword function which detects
+	// bcrypt, argon2id and htpasswd hashes by their prefix and compares plain passwords in constant time.
+	// See the BCRYPT optional function too.
 	ComparePassword func(stored, userPassword string) bool
+	// ReloadEvery, if greater than zero, checks the users file of the
+	// AllowUsersFile and AllowHtpasswd functions for changes at most once per "ReloadEvery"
+	// duration and reloads it on change. See the Reload optional function.
+	ReloadEvery time.Duration
 }
 
 // UserAuthOption is the option function type
 // for the Default and Load (and AllowUsers, AllowUsersFile) functions.
 //
-// See BCRYPT for an implementation.
+// See BCRYPT and Reload for implementations.
 type UserAuthOption func(*UserAuthOptions)
 
+// Reload returns a UserAuthOption which reloads the users file
+// when it's modified. The file modification time is checked on requests,
+// at most once per "every" duration, e.g. Reload(5*time.Second).
+// Note that it requires a physical file, see ReadFile.
+//
+// Usage:
+//
+//	Load("users.yml", Reload(5*time.Second)) OR
+//	LoadHtpasswd(".htpasswd", Reload(5*time.Second)) OR
+//	Options.Allow = AllowUsersFile("users.yml", Reload(5*time.Second))
+func Reload(every time.Duration) UserAuthOption {
+	return func(opts *UserAuthOptions) {
+		opts.ReloadEvery = every
+	}
+}
+
 // BCRYPT it is a UserAuthOption, it compares a bcrypt hashed password with its user input.
 // Reports true on success and false on failure.
 //
@@ -66,9 +88,7 @@ func toUserAuthOptions(opts []UserAuthOption) (options UserAuthOptions) {
 	}
 
 	if options.ComparePassword == nil {
-		options.ComparePassword = func(stored, userPassword string) bool {
-			return stored == userPassword
-		}
+		options.ComparePassword = CompareHashAndPassword
 	}
 
 	return options
@@ -92,6 +112,9 @@ func AllowUsers(users any, opts ...UserAuthOption) AuthFunc {
 		ref      any
 	}
 	cp := make(map[string]*user)
+	// the password of a random user, it is compared on unknown usernames
+	// so the response time does not reveal whether a username exists.
+	var dummy string
 
 	v := reflect.Indirect(reflect.ValueOf(users))
 	switch v.Kind() {
@@ -132,12 +155,20 @@ func AllowUsers(users any, opts ...UserAuthOption) AuthFunc {
 	}
 
 	options := toUserAuthOptions(opts)
+	for _, u := range cp {
+		dummy = u.password
+		break
+	}
 
 	return func(_ *context.Context, username, password string) (any, bool) {
-		if u, ok := cp[username]; ok { // fast map access,
-			if options.ComparePassword(u.password, password) {
-				return u.ref, true
-			}
+		u, ok := cp[username] // fast map access,
+		if !ok {
+			options.ComparePassword(dummy, password)
+			return nil, false
+		}
+
+		if options.ComparePassword(u.password, password) {
+			return u.ref, true
 		}
 
 		return nil, false
@@ -147,18 +178,32 @@ func AllowUsers(users any, opts ...UserAuthOption) AuthFunc {
 func userMap(usernamePassword map[string]string, opts ...UserAuthOption) AuthFunc {
 	options := toUserAuthOptions(opts)
 
+	var dummy string
+	for _, pass := range usernamePassword {
+		dummy = pass
+		break
+	}
+
 	return func(_ *context.Context, username, password string) (any, bool) {
 		pass, ok := usernamePassword[username]
-		return nil, ok && options.ComparePassword(pass, password)
+		if !ok {
+			// compare anyway, see AllowUsers.
+			options.ComparePassword(dummy, password)
+			return nil, false
+		}
+
+		return nil, options.ComparePassword(pass, password)
 	}
 }
 
 // AllowUsersFile is an AuthFunc which authenticates user input based on a (static) user list
 // loaded from a file on initialization.
+// The passwords can be stored as bcrypt or argon2id hashes, see CompareHashAndPassword.
+// Pass the Reload option to reload the file on change.
 //
 // Example Code:
 //
-//	New(Options{Allow: AllowUsersFile("users.yml", BCRYPT)})
+//	New(Options{Allow: AllowUsersFile("users.yml")})
 //
 // The users.yml file looks like the following:
 //   - username: kataras
@@ -169,6 +214,27 @@ func userMap(usernamePassword map[string]string, opts ...UserAuthOption) AuthFun
 //     password: makis_password
 //     ...
 func AllowUsersFile(jsonOrYamlFilename string, opts ...UserAuthOption) AuthFunc {
+	return allowFile(jsonOrYamlFilename, loadUsersFile, opts)
+}
+
+// allowFile loads the users from a file and, if the Reload option was passed,
+// reloads them on file changes.
+func allowFile(filename string, load func(filename string, opts ...UserAuthOption) (AuthFunc, error), opts []UserAuthOption) AuthFunc {
+	allow, err := load(filename, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	if every := toUserAuthOptions(opts).ReloadEvery; every > 0 {
+		return newFileReloader(filename, every, allow, func() (AuthFunc, error) {
+			return load(filename, opts...)
+		}).allow
+	}
+
+	return allow
+}
+
+func loadUsersFile(jsonOrYamlFilename string, opts ...UserAuthOption) (AuthFunc, error) {
 	var (
 		usernamePassword map[string]string
 		// no need to support too much forms, this would be for:
@@ -177,14 +243,14 @@ func AllowUsersFile(jsonOrYamlFilename string, opts ...UserAuthOption) AuthFunc
 	)
 
 	if err := decodeFile(jsonOrYamlFilename, &usernamePassword, &userList); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if len(usernamePassword) > 0 {
 		// JSON Form: { "$username":"$pass", "$username": "$pass" }
 		// YAML Form: $username: $pass
 		// 			  $username: $pass
-		return userMap(usernamePassword, opts...)
+		return userMap(usernamePassword, opts...), nil
 	}
 
 	if len(userList) > 0 {
@@ -193,10 +259,10 @@ func AllowUsersFile(jsonOrYamlFilename string, opts ...UserAuthOption) AuthFunc
 		// - username: $username
 		//   password: $password
 		//   other_field: ...
-		return AllowUsers(userList, opts...)
+		return AllowUsers(userList, opts...), nil
 	}
 
-	panic("malformed document file: " + jsonOrYamlFilename)
+	return nil, fmt.Errorf("malformed document file: %s", jsonOrYamlFilename)
 }
 
 func decodeFile(src string, dest ...any) error {
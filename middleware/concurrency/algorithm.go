@@ -0,0 +1,119 @@
+package concurrency
+
+import (
+	"math"
+	"time"
+)
+
+type (
+	// Sample holds the measurements of a completed request,
+	// it's passed to the Algorithm's Update method.
+	Sample struct {
+		// RTT is the time the request spent in the handlers chain
+		// (queue time is not included).
+		RTT time.Duration
+		// InFlight is the number of in-flight requests when the request started.
+		InFlight int
+		// Dropped reports whether the request failed because of the load,
+		// e.g. it took longer than the Options.Timeout or responded with 503 or 504.
+		Dropped bool
+	}
+
+	// Algorithm is the interface which limit algorithms should implement.
+	// An Algorithm calculates the new limit of in-flight requests
+	// based on the measurements of each completed request.
+	// See `AIMD` and `Gradient` package-level functions.
+	//
+	// Update is called under the Limiter's lock,
+	// implementations do not have to be safe for concurrent use.
+	Algorithm interface {
+		Update(limit float64, sample Sample) float64
+	}
+)
+
+// AIMD returns a new Additive Increase Multiplicative Decrease limit Algorithm.
+// The limit is increased by one when the in-flight requests reach the half of it
+// and it's multiplied by the "backoffRatio" (e.g. 0.9) when a request is dropped.
+func AIMD(backoffRatio float64) Algorithm {
+	if backoffRatio <= 0 || backoffRatio >= 1 {
+		backoffRatio = 0.9
+	}
+
+	return &aimd{backoffRatio: backoffRatio}
+}
+
+type aimd struct {
+	backoffRatio float64
+}
+
+func (a *aimd) Update(limit float64, sample Sample) float64 {
+	if sample.Dropped {
+		return limit * a.backoffRatio
+	}
+
+	if float64(sample.InFlight)*2 >= limit {
+		return limit + 1
+	}
+
+	return limit
+}
+
+// Gradient returns a new latency-based limit Algorithm,
+// based on the Netflix's concurrency-limits Gradient2 algorithm.
+// It keeps an exponential moving average of the long-term latency and
+// compares it with the latency of each request. When the latency increases
+// the limit decreases, otherwise the limit grows by its square root (the queue size).
+//
+// The "tolerance" (e.g. 1.5) is the ratio of the latency increase
+// which is tolerated before the limit is reduced.
+func Gradient(tolerance float64) Algorithm {
+	if tolerance < 1 {
+		tolerance = 1.5
+	}
+
+	return &gradient{
+		tolerance: tolerance,
+		smoothing: 0.2,
+		window:    600,
+	}
+}
+
+type gradient struct {
+	tolerance float64
+	smoothing float64
+	window    float64 // the number of samples of the long-term average.
+
+	longRTT float64 // the exponential moving average, in nanoseconds.
+	samples float64
+}
+
+func (g *gradient) Update(limit float64, sample Sample) float64 {
+	shortRTT := float64(sample.RTT)
+	if shortRTT <= 0 {
+		return limit
+	}
+
+	if g.samples < g.window {
+		g.samples++
+	}
+	// Warm up with a simple average, then use an exponential moving average.
+	g.longRTT += (shortRTT - g.longRTT) / g.samples
+
+	// Recover faster when the long-term latency drifted too high.
+	if ratio := g.longRTT / shortRTT; ratio > 2 {
+		g.longRTT *= 0.95
+	}
+
+	// Do not grow the limit when the application is not limited by it.
+	if !sample.Dropped && float64(sample.InFlight) < limit/2 {
+		return limit
+	}
+
+	grad := math.Max(0.5, math.Min(1, g.tolerance*g.longRTT/shortRTT))
+	if sample.Dropped {
+		grad = 0.5
+	}
+
+	newLimit := limit*grad + math.Sqrt(limit)
+	return limit*(1-g.smoothing) + newLimit*g.smoothing
+}
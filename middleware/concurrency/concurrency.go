@@ -0,0 +1,318 @@
+// Package concurrency implements an adaptive concurrency limiter
+// which caps the in-flight requests of a Party or a route,
+// queues briefly the excess requests by priority and
+// sheds the rest with 503 Service Unavailable.
+package concurrency
+
+import (
+	"container/heap"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/concurrency.*", "iris.concurrency")
+}
+
+// Options holds the optional fields for the Limiter structure.
+type Options struct {
+	// Algorithm adapts the limit of in-flight requests based on their latency.
+	// For a fixed limit set the same MinLimit and MaxLimit, see `NewFixed`.
+	//
+	// Defaults to Gradient(1.5).
+	Algorithm Algorithm
+	// InitialLimit is the limit of in-flight requests on start.
+	// Defaults to 20.
+	InitialLimit int
+	// MinLimit is the minimum limit of in-flight requests.
+	// Defaults to 1.
+	MinLimit int
+	// MaxLimit is the maximum limit of in-flight requests.
+	// Defaults to 1000.
+	MaxLimit int
+	// Timeout is the maximum latency of a request. Requests which
+	// take longer are considered dropped and they reduce the limit.
+	// Defaults to zero, only 503 and 504 responses are considered dropped.
+	Timeout time.Duration
+
+	// MaxQueue is the maximum number of requests that can wait
+	// for a slot when the limit is reached. The rest are rejected immediately.
+	// Defaults to zero (no queue).
+	MaxQueue int
+	// QueueTimeout is the maximum time that a request may wait in the queue.
+	// Defaults to 100 milliseconds.
+	QueueTimeout time.Duration
+	// Priority returns the priority of a request,
+	// queued requests with higher priority are served first.
+	// E.g. return 2 for health checks, 1 for authenticated users and 0 for the rest.
+	//
+	// Defaults to nil, all requests have the same priority (first in, first out).
+	Priority func(ctx *context.Context) int
+
+	// RetryAfter is the value of the Retry-After response header
+	// of rejected requests. Defaults to 1 second.
+	RetryAfter time.Duration
+	// RejectHandler is fired when a request is rejected.
+	// Defaults to a handler which sends a Retry-After header
+	// and a 503 Service Unavailable status code.
+	RejectHandler context.Handler
+}
+
+// Limiter caps the in-flight requests of the handlers which are registered after it.
+// It adapts its limit based on the latency of the requests, see `Algorithm`.
+//
+// Initialize with the `New` package-level function
+// and register its `Handler` method as a middleware on a Party or a route.
+type Limiter struct {
+	opts Options
+
+	limit    float64
+	inFlight int
+	queue    waitQueue
+	seq      uint64 // keeps the order of same priority waiters.
+
+	mu sync.Mutex
+}
+
+// New returns a new concurrency Limiter.
+//
+// Usage:
+//
+//	limiter := concurrency.New(concurrency.Options{
+//		MaxQueue: 50,
+//		Priority: func(ctx iris.Context) int {
+//			if ctx.User() != nil {
+//				return 1
+//			}
+//			return 0
+//		},
+//	})
+//	api := app.Party("/api")
+//	api.Use(limiter.Handler)
+func New(opts Options) *Limiter {
+	if opts.Algorithm == nil {
+		opts.Algorithm = Gradient(1.5)
+	}
+
+	if opts.InitialLimit <= 0 {
+		opts.InitialLimit = 20
+	}
+
+	if opts.MinLimit <= 0 {
+		opts.MinLimit = 1
+	}
+
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 1000
+	}
+
+	if opts.MaxLimit < opts.MinLimit {
+		opts.MaxLimit = opts.MinLimit
+	}
+
+	if opts.QueueTimeout <= 0 {
+		opts.QueueTimeout = 100 * time.Millisecond
+	}
+
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+
+	if opts.RejectHandler == nil {
+		retryAfter := strconv.Itoa(int(math.Ceil(opts.RetryAfter.Seconds())))
+		opts.RejectHandler = func(ctx *context.Context) {
+			ctx.Header("Retry-After", retryAfter)
+			ctx.StopWithStatus(http.StatusServiceUnavailable)
+		}
+	}
+
+	l := &Limiter{
+		opts:  opts,
+		limit: float64(opts.InitialLimit),
+	}
+	l.limit = l.clamp(l.limit)
+
+	return l
+}
+
+// NewFixed returns a new Limiter with a fixed limit of in-flight requests
+// and no queue.
+func NewFixed(limit int) *Limiter {
+	return New(Options{
+		InitialLimit: limit,
+		MinLimit:     limit,
+		MaxLimit:     limit,
+	})
+}
+
+// Limit returns the current limit of in-flight requests.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	limit := int(l.limit)
+	l.mu.Unlock()
+	return limit
+}
+
+// InFlight returns the current number of in-flight requests.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	n := l.inFlight
+	l.mu.Unlock()
+	return n
+}
+
+// Queued returns the current number of requests waiting in the queue.
+func (l *Limiter) Queued() int {
+	l.mu.Lock()
+	n := l.queue.Len()
+	l.mu.Unlock()
+	return n
+}
+
+// Handler is the middleware which limits the in-flight requests.
+func (l *Limiter) Handler(ctx *context.Context) {
+	inFlight, ok := l.acquire(ctx)
+	if !ok {
+		l.opts.RejectHandler(ctx)
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		rtt := time.Since(start)
+		statusCode := ctx.GetStatusCode()
+		dropped := statusCode == http.StatusServiceUnavailable || statusCode == http.StatusGatewayTimeout ||
+			(l.opts.Timeout > 0 && rtt > l.opts.Timeout)
+
+		l.release(Sample{
+			RTT:      rtt,
+			InFlight: inFlight,
+			Dropped:  dropped,
+		})
+	}()
+
+	ctx.Next()
+}
+
+// acquire reserves a slot for the request, it waits in the queue if necessary.
+// It returns the in-flight requests when the slot was reserved
+// and false if the request should be rejected.
+func (l *Limiter) acquire(ctx *context.Context) (int, bool) {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) {
+		l.inFlight++
+		n := l.inFlight
+		l.mu.Unlock()
+		return n, true
+	}
+
+	if l.queue.Len() >= l.opts.MaxQueue {
+		l.mu.Unlock()
+		return 0, false
+	}
+
+	w := &waiter{ready: make(chan int, 1), seq: l.seq}
+	if l.opts.Priority != nil {
+		w.priority = l.opts.Priority(ctx)
+	}
+	l.seq++
+	heap.Push(&l.queue, w)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.opts.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case n := <-w.ready:
+		return n, true
+	case <-timer.C:
+	case <-ctx.Request().Context().Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if w.index >= 0 { // still in the queue.
+		heap.Remove(&l.queue, w.index)
+		return 0, false
+	}
+
+	// The slot was given right before the timeout.
+	return <-w.ready, true
+}
+
+// release frees the slot of a completed request,
+// updates the limit and wakes up the queued requests.
+func (l *Limiter) release(sample Sample) {
+	l.mu.Lock()
+	l.inFlight--
+
+	l.limit = l.clamp(l.opts.Algorithm.Update(l.limit, sample))
+
+	for l.inFlight < int(l.limit) && l.queue.Len() > 0 {
+		w := heap.Pop(&l.queue).(*waiter)
+		l.inFlight++
+		w.ready <- l.inFlight
+	}
+	l.mu.Unlock()
+}
+
+func (l *Limiter) clamp(limit float64) float64 {
+	if limit < float64(l.opts.MinLimit) {
+		return float64(l.opts.MinLimit)
+	}
+
+	if limit > float64(l.opts.MaxLimit) {
+		return float64(l.opts.MaxLimit)
+	}
+
+	return limit
+}
+
+// waiter is a queued request.
+type waiter struct {
+	ready    chan int // receives the in-flight requests when a slot is reserved.
+	priority int
+	seq      uint64
+	index    int // the index in the heap, -1 when it's removed.
+}
+
+// waitQueue is a priority queue of waiters, see container/heap.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority == q[j].priority {
+		return q[i].seq < q[j].seq
+	}
+
+	return q[i].priority > q[j].priority
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,86 @@
+package concurrency_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/concurrency"
+)
+
+func TestLimiter(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		unblock = make(chan struct{})
+	)
+
+	limiter := concurrency.New(concurrency.Options{
+		InitialLimit: 1,
+		MinLimit:     1,
+		MaxLimit:     1,
+		MaxQueue:     1,
+		QueueTimeout: 50 * time.Millisecond,
+		RetryAfter:   2 * time.Second,
+	})
+
+	app := iris.New()
+	app.Get("/slow", limiter.Handler, func(ctx iris.Context) {
+		close(started)
+		<-unblock
+		ctx.WriteString("slow")
+	})
+	app.Get("/", limiter.Handler, func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	})
+
+	e := httptest.New(t, app)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		e.GET("/slow").Expect().Status(httptest.StatusOK).Body().IsEqual("slow")
+	}()
+	<-started
+
+	// The limit is reached, waits in the queue and then it's rejected.
+	e.GET("/").Expect().Status(httptest.StatusServiceUnavailable).Header("Retry-After").IsEqual("2")
+
+	// Waits in the queue until the slow request completes.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		e.GET("/").Expect().Status(httptest.StatusOK).Body().IsEqual("OK")
+	}()
+
+	for limiter.Queued() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(unblock)
+	wg.Wait()
+
+	if inFlight := limiter.InFlight(); inFlight != 0 {
+		t.Fatalf("expected zero in-flight requests but got %d", inFlight)
+	}
+}
+
+func TestAIMD(t *testing.T) {
+	algorithm := concurrency.AIMD(0.5)
+
+	limit := algorithm.Update(10, concurrency.Sample{RTT: time.Millisecond, InFlight: 5})
+	if expected := 11.0; limit != expected {
+		t.Fatalf("expected limit to increase to %v but got %v", expected, limit)
+	}
+
+	limit = algorithm.Update(limit, concurrency.Sample{RTT: time.Millisecond, InFlight: 1})
+	if expected := 11.0; limit != expected {
+		t.Fatalf("expected limit to stay at %v but got %v", expected, limit)
+	}
+
+	limit = algorithm.Update(limit, concurrency.Sample{RTT: time.Second, InFlight: 11, Dropped: true})
+	if expected := 5.5; limit != expected {
+		t.Fatalf("expected limit to decrease to %v but got %v", expected, limit)
+	}
+}
@@ -0,0 +1,506 @@
+// Package csrf implements a Cross-Site Request Forgery protection middleware.
+// It supports synchronizer tokens stored in the sessions.Session
+// and the stateless signed double-submit cookie pattern.
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/sessions"
+	"github.com/kataras/iris/v12/view"
+
+	"github.com/Shopify/goreferrer"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/csrf.*", "iris.csrf")
+	view.RegisterFunc("csrf_field", TemplateField)
+	view.RegisterFunc("csrf_token", TemplateToken)
+}
+
+const (
+	tokenContextKey = "iris.csrf.token"
+	tokenLength     = 32
+
+	// ViewDataTokenKey is the view data key of the masked token,
+	// it can be used as {{ .csrf_token }} on templates.
+	ViewDataTokenKey = "csrf_token"
+	// ViewDataFieldKey is the view data key of the hidden form input field
+	// which holds the masked token, it can be used as {{ .csrf_field }} on templates.
+	ViewDataFieldKey = "csrf_field"
+)
+
+var (
+	// ErrMissingToken is fired when the request does not contain a token.
+	ErrMissingToken = errors.New("csrf: missing token")
+	// ErrInvalidToken is fired when the submitted token does not match the stored one.
+	ErrInvalidToken = errors.New("csrf: invalid token")
+	// ErrBadOrigin is fired when the Origin header does not match the request's host
+	// or any of the trusted origins.
+	ErrBadOrigin = errors.New("csrf: origin does not match")
+	// ErrBadReferer is fired when the Referer header does not match the request's host
+	// or any of the trusted origins.
+	ErrBadReferer = errors.New("csrf: referer does not match")
+	// ErrNoReferer is fired when a secure request has neither an Origin nor a Referer header.
+	ErrNoReferer = errors.New("csrf: missing referer")
+	// ErrNoSession is fired when the session storage is used
+	// but the sessions middleware was not registered before the CSRF one.
+	ErrNoSession = errors.New("csrf: no session")
+)
+
+// Options holds the configuration for the CSRF middleware.
+// All fields are optional.
+type Options struct {
+	// Stateless enables the signed double-submit cookie pattern:
+	// the token is stored, signed by the Secret, on a cookie and it's compared with the submitted one.
+	// When false, the token is stored on the sessions.Session, which
+	// requires the sessions middleware to be registered before the CSRF one.
+	Stateless bool
+	// Secret is the HMAC-SHA256 key which signs the token cookie when Stateless is true,
+	// so a cookie which was set by a subdomain or injected by a man-in-the-middle
+	// with a token of their choice is rejected.
+	// All the instances of the application should share the same Secret.
+	// Defaults to a random key, the tokens are invalidated when the application restarts.
+	Secret []byte
+	// Identity, if not nil, returns a value which identifies the client when Stateless is true,
+	// e.g. the value of the authentication cookie or the user ID, which is signed along with the token.
+	// It binds the token to that client, so a valid cookie and token pair, which an attacker
+	// received from the server, cannot be injected to another client's browser.
+	// It's strongly recommended, without it only the session-backed mode is safe against cookie injection.
+	// A new token is generated when the identity changes, e.g. on sign in.
+	Identity func(ctx *context.Context) string
+	// SessionKey is the session key to store the token.
+	// Defaults to "iris.csrf.token".
+	SessionKey string
+	// CookieName is the name of the cookie to store the token when Stateless is true.
+	// Defaults to "_csrf".
+	CookieName string
+	// CookiePath is the path of the token cookie.
+	// Defaults to "/".
+	CookiePath string
+	// CookieDomain is the domain of the token cookie.
+	CookieDomain string
+	// CookieMaxAge is the max age of the token cookie.
+	// Defaults to zero, the cookie is removed when the browser is closed.
+	CookieMaxAge time.Duration
+	// CookieSecure sets the Secure attribute to the token cookie.
+	CookieSecure bool
+	// CookieSameSite sets the SameSite attribute to the token cookie.
+	// Defaults to http.SameSiteLaxMode.
+	CookieSameSite http.SameSite
+	// FieldName is the form (and multipart form) field name of the submitted token.
+	// Defaults to "csrf_token".
+	FieldName string
+	// HeaderName is the request header name of the submitted token,
+	// useful for AJAX requests. The header has a priority over the form field.
+	// Defaults to "X-CSRF-Token".
+	HeaderName string
+	// TrustedOrigins is a list of additional origins, in the form of "host[:port]"
+	// or "scheme://host[:port]", which are allowed to send unsafe requests.
+	// The request's host is always trusted.
+	TrustedOrigins []string
+	// Skip, if not nil, reports whether the protection should be skipped
+	// for a specific request, e.g. for an API which is authenticated through headers.
+	Skip func(ctx *context.Context) bool
+	// ErrorHandler handles the validation failures,
+	// the "err" is one of the package-level errors.
+	//
+	// Defaults to the DefaultErrorHandler.
+	ErrorHandler func(ctx *context.Context, err error)
+}
+
+// DefaultErrorHandler is the default error handler.
+// It sends 500 on ErrNoSession and 403 otherwise.
+// The error messages are not sent to the client.
+func DefaultErrorHandler(ctx *context.Context, err error) {
+	if errors.Is(err, ErrNoSession) {
+		ctx.StopWithError(http.StatusInternalServerError, context.PrivateError(err))
+		return
+	}
+
+	ctx.StopWithError(http.StatusForbidden, context.PrivateError(err))
+}
+
+// CSRF holds the options of the CSRF middleware.
+// Initialize with the `New` package-level function.
+type CSRF struct {
+	opts Options
+}
+
+// New returns a new CSRF protection middleware.
+// It generates a token per client, the masked token is available through the
+// `Token` and `Field` package-level functions and on templates through
+// the "csrf_field" and "csrf_token" functions, which are registered automatically
+// to every view engine, or the view data:
+//
+//	{{ csrf_field . }} or {{ .csrf_field }}
+//	{{ csrf_token . }} or {{ .csrf_token }}
+//
+// When a struct view model is passed to the Context.View method the view data are not available,
+// use the `Field` or the `Token` to fill a field of the view model instead.
+//
+// The unsafe requests (all except GET, HEAD, OPTIONS and TRACE) are rejected unless
+// their Origin (or Referer) header matches the request's host or a trusted origin and
+// they contain a valid token on the "X-CSRF-Token" header or the "csrf_token" form field.
+//
+// Example Code:
+//
+//	sess := sessions.New(sessions.Config{Cookie: "session_id"})
+//	app.Use(sess.Handler(), csrf.New(csrf.Options{}))
+//
+// Or, without sessions:
+//
+//	app.Use(csrf.New(csrf.Options{
+//		Stateless:    true,
+//		Secret:       []byte(os.Getenv("CSRF_SECRET")),
+//		Identity:     func(ctx iris.Context) string { return ctx.GetCookie("auth") },
+//		CookieSecure: true,
+//	}))
+func New(opts Options) context.Handler {
+	if opts.SessionKey == "" {
+		opts.SessionKey = tokenContextKey
+	}
+
+	if opts.CookieName == "" {
+		opts.CookieName = "_csrf"
+	}
+
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+
+	if opts.CookieSameSite == 0 {
+		opts.CookieSameSite = http.SameSiteLaxMode
+	}
+
+	if opts.FieldName == "" {
+		opts.FieldName = "csrf_token"
+	}
+
+	if opts.HeaderName == "" {
+		opts.HeaderName = "X-CSRF-Token"
+	}
+
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+
+	if opts.Stateless && len(opts.Secret) == 0 {
+		opts.Secret = make([]byte, 32)
+		if _, err := rand.Read(opts.Secret); err != nil {
+			panic(fmt.Sprintf("csrf: secret: %v", err))
+		}
+	}
+
+	c := &CSRF{opts: opts}
+	return c.serveHTTP
+}
+
+func (c *CSRF) serveHTTP(ctx *context.Context) {
+	if c.opts.Skip != nil && c.opts.Skip(ctx) {
+		ctx.Next()
+		return
+	}
+
+	token, err := c.getToken(ctx)
+	if err != nil {
+		c.opts.ErrorHandler(ctx, err)
+		return
+	}
+
+	if token == nil {
+		if token, err = c.newToken(ctx); err != nil {
+			c.opts.ErrorHandler(ctx, err)
+			return
+		}
+	}
+
+	masked := mask(token)
+	ctx.Values().Set(tokenContextKey, masked)
+	ctx.ViewData(ViewDataTokenKey, masked)
+	ctx.ViewData(ViewDataFieldKey, field(c.opts.FieldName, masked))
+
+	if !isSafeMethod(ctx.Method()) {
+		if err = c.checkOrigin(ctx); err != nil {
+			c.opts.ErrorHandler(ctx, err)
+			return
+		}
+
+		if err = c.checkToken(ctx, token); err != nil {
+			c.opts.ErrorHandler(ctx, err)
+			return
+		}
+	}
+
+	ctx.Next()
+}
+
+// getToken returns the stored token or nil if it's missing, malformed
+// or, on Stateless mode, its signature does not match.
+func (c *CSRF) getToken(ctx *context.Context) ([]byte, error) {
+	if c.opts.Stateless {
+		encoded, encodedSignature, _ := strings.Cut(ctx.GetCookie(c.opts.CookieName), ".")
+		token := decodeToken(encoded)
+		if token == nil {
+			return nil, nil
+		}
+
+		signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+		if err != nil || !hmac.Equal(signature, c.sign(ctx, token)) {
+			return nil, nil
+		}
+
+		return token, nil
+	}
+
+	sess := sessions.Get(ctx)
+	if sess == nil {
+		return nil, ErrNoSession
+	}
+
+	return decodeToken(sess.GetString(c.opts.SessionKey)), nil
+}
+
+func decodeToken(encoded string) []byte {
+	token, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(token) != tokenLength {
+		return nil
+	}
+
+	return token
+}
+
+func (c *CSRF) newToken(ctx *context.Context) ([]byte, error) {
+	token := make([]byte, tokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(token)
+
+	if c.opts.Stateless {
+		cookie := &http.Cookie{
+			Name:     c.opts.CookieName,
+			Value:    encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(ctx, token)),
+			Path:     c.opts.CookiePath,
+			Domain:   c.opts.CookieDomain,
+			Secure:   c.opts.CookieSecure,
+			SameSite: c.opts.CookieSameSite,
+			HttpOnly: true,
+		}
+
+		if c.opts.CookieMaxAge > 0 {
+			cookie.MaxAge = int(c.opts.CookieMaxAge.Seconds())
+			cookie.Expires = time.Now().Add(c.opts.CookieMaxAge)
+		}
+
+		ctx.UpsertCookie(cookie)
+	} else {
+		sessions.Get(ctx).Set(c.opts.SessionKey, encoded)
+	}
+
+	return token, nil
+}
+
+// sign returns the signature of the token cookie,
+// it covers the token and the client's Identity, if any.
+func (c *CSRF) sign(ctx *context.Context, token []byte) []byte {
+	h := hmac.New(sha256.New, c.opts.Secret)
+	h.Write(token) // fixed length.
+	if c.opts.Identity != nil {
+		h.Write([]byte(c.opts.Identity(ctx)))
+	}
+
+	return h.Sum(nil)
+}
+
+func (c *CSRF) checkToken(ctx *context.Context, token []byte) error {
+	submitted := ctx.GetHeader(c.opts.HeaderName)
+	if submitted == "" {
+		submitted = ctx.PostValue(c.opts.FieldName)
+		if submitted == "" {
+			return ErrMissingToken
+		}
+	}
+
+	if subtle.ConstantTimeCompare(unmask(submitted), token) != 1 {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// checkOrigin verifies that the request was sent from the same or a trusted origin.
+func (c *CSRF) checkOrigin(ctx *context.Context) error {
+	if origin := ctx.GetHeader("Origin"); origin != "" {
+		if !c.isTrusted(ctx, origin) {
+			return ErrBadOrigin
+		}
+
+		return nil
+	}
+
+	referer := ctx.GetHeader("Referer")
+	if referer == "" {
+		// Browsers always send the Referer on secure same-origin requests,
+		// unless a Referrer-Policy prevents them to do so.
+		if ctx.IsSSL() {
+			return ErrNoReferer
+		}
+
+		return nil
+	}
+
+	if !c.isTrustedReferer(ctx, referer) {
+		return ErrBadReferer
+	}
+
+	return nil
+}
+
+func (c *CSRF) isTrusted(ctx *context.Context, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false // including the "null" origin.
+	}
+
+	if ctx.IsSSL() && u.Scheme != "https" {
+		return false
+	}
+
+	if strings.EqualFold(u.Host, ctx.Host()) {
+		return true
+	}
+
+	for _, trusted := range c.opts.TrustedOrigins {
+		if strings.EqualFold(trusted, u.Host) || strings.EqualFold(trusted, u.Scheme+"://"+u.Host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTrustedReferer reports whether the "referer" URL is a direct one,
+// of the request's host or of a trusted origin's host.
+func (c *CSRF) isTrustedReferer(ctx *context.Context, referer string) bool {
+	referer = strings.ToLower(referer)
+	if ctx.IsSSL() && !strings.HasPrefix(referer, "https://") {
+		return false
+	}
+
+	domains := make([]string, 0, len(c.opts.TrustedOrigins)+1)
+	domains = append(domains, strings.ToLower(ctx.Host()))
+	for _, trusted := range c.opts.TrustedOrigins {
+		trusted = strings.ToLower(trusted)
+		if i := strings.Index(trusted, "://"); i > 0 {
+			if !strings.HasPrefix(referer, trusted[:i+3]) {
+				continue // scheme does not match.
+			}
+			trusted = trusted[i+3:]
+		}
+
+		domains = append(domains, trusted)
+	}
+
+	return goreferrer.DefaultRules.ParseWith(referer, domains, "").Type == goreferrer.Direct
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// mask returns a one-time pad followed by the token xored with that pad,
+// so the token sent to the client is different on each response (BREACH mitigation).
+func mask(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	pad := masked[:len(token)]
+	if _, err := rand.Read(pad); err != nil {
+		panic(fmt.Sprintf("csrf: mask: %v", err))
+	}
+
+	for i := range token {
+		masked[len(token)+i] = pad[i] ^ token[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func unmask(s string) []byte {
+	masked, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(masked) != 2*tokenLength {
+		return nil
+	}
+
+	token := make([]byte, tokenLength)
+	for i := range token {
+		token[i] = masked[i] ^ masked[tokenLength+i]
+	}
+
+	return token
+}
+
+func field(name, token string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, html.EscapeString(name), token))
+}
+
+// Token returns the masked token of the current request.
+// It should be sent back on the request header or form field
+// of the next unsafe requests. Returns empty if the CSRF middleware was not executed.
+func Token(ctx *context.Context) string {
+	return ctx.Values().GetString(tokenContextKey)
+}
+
+// Field returns the hidden form input field which holds
+// the masked token of the current request.
+func Field(ctx *context.Context) template.HTML {
+	if v, ok := ctx.GetViewData()[ViewDataFieldKey].(template.HTML); ok {
+		return v
+	}
+
+	return ""
+}
+
+// TemplateToken is the "csrf_token" template function,
+// it accepts the template's binding data, e.g. {{ csrf_token . }}.
+// It's registered automatically to the application's view engine.
+func TemplateToken(data any) string {
+	if m, ok := data.(map[string]any); ok {
+		if v, ok := m[ViewDataTokenKey].(string); ok {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// TemplateField is the "csrf_field" template function,
+// it accepts the template's binding data, e.g. {{ csrf_field . }}.
+// It's registered automatically to the application's view engine.
+func TemplateField(data any) template.HTML {
+	if m, ok := data.(map[string]any); ok {
+		if v, ok := m[ViewDataFieldKey].(template.HTML); ok {
+			return v
+		}
+	}
+
+	return ""
+}
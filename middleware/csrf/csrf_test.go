@@ -0,0 +1,144 @@
+package csrf_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/csrf"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+func newApp(middleware ...iris.Handler) *iris.Application {
+	app := iris.New()
+	app.Use(middleware...)
+
+	app.Get("/", func(ctx iris.Context) {
+		ctx.WriteString(csrf.Token(ctx))
+	})
+	app.Post("/", func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	})
+
+	return app
+}
+
+func TestCSRFSession(t *testing.T) {
+	sess := sessions.New(sessions.Config{Cookie: "session_id"})
+	app := newApp(sess.Handler(), csrf.New(csrf.Options{}))
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+
+	token := e.GET("/").Expect().Status(httptest.StatusOK).Body().NotEmpty().Raw()
+	// The token is masked differently on each response.
+	token2 := e.GET("/").Expect().Status(httptest.StatusOK).Body().NotEqual(token).Raw()
+
+	e.POST("/").Expect().Status(httptest.StatusForbidden)
+	e.POST("/").WithHeader("X-CSRF-Token", "invalid").Expect().Status(httptest.StatusForbidden)
+	e.POST("/").WithHeader("X-CSRF-Token", token).Expect().Status(httptest.StatusOK).Body().IsEqual("OK")
+	e.POST("/").WithFormField("csrf_token", token2).Expect().Status(httptest.StatusOK)
+	e.POST("/").WithMultipart().WithFormField("csrf_token", token2).Expect().Status(httptest.StatusOK)
+
+	// Origin and Referer checks.
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "http://example.com").
+		Expect().Status(httptest.StatusOK)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "http://evil.com").
+		Expect().Status(httptest.StatusForbidden)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "null").
+		Expect().Status(httptest.StatusForbidden)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Referer", "http://evil.com/form").
+		Expect().Status(httptest.StatusForbidden)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Referer", "http://example.com/form").
+		Expect().Status(httptest.StatusOK)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Referer", "http://sub.example.com/form").
+		Expect().Status(httptest.StatusForbidden)
+
+	// A token of a different session is not valid.
+	e2 := httptest.New(t, app, httptest.URL("http://example.com"))
+	e2.POST("/").WithHeader("X-CSRF-Token", token).Expect().Status(httptest.StatusForbidden)
+}
+
+func TestCSRFStateless(t *testing.T) {
+	app := newApp(csrf.New(csrf.Options{
+		Stateless:      true,
+		TrustedOrigins: []string{"https://app.example.com"},
+	}))
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+
+	resp := e.GET("/").Expect().Status(httptest.StatusOK)
+	token := resp.Body().Raw()
+
+	e.POST("/").WithHeader("X-CSRF-Token", token).Expect().Status(httptest.StatusOK)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "https://app.example.com").
+		Expect().Status(httptest.StatusOK)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "http://app.example.com").
+		Expect().Status(httptest.StatusForbidden)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Referer", "https://app.example.com/form").
+		Expect().Status(httptest.StatusOK)
+	e.POST("/").WithHeader("X-CSRF-Token", token).WithHeader("Referer", "http://app.example.com/form").
+		Expect().Status(httptest.StatusForbidden)
+
+	// Without the cookie.
+	httptest.New(t, app, httptest.URL("http://example.com")).POST("/").
+		WithHeader("X-CSRF-Token", token).Expect().Status(httptest.StatusForbidden)
+
+	// An injected, unsigned, cookie with a token of the attacker's choice.
+	forged := bytes.Repeat([]byte{'a'}, 32)
+	maskedForged := base64.RawURLEncoding.EncodeToString(append(make([]byte, 32), forged...)) // zero pad.
+	for _, cookie := range []string{
+		base64.RawURLEncoding.EncodeToString(forged),
+		base64.RawURLEncoding.EncodeToString(forged) + "." + base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+	} {
+		httptest.New(t, app, httptest.URL("http://example.com")).POST("/").WithCookie("_csrf", cookie).
+			WithHeader("X-CSRF-Token", maskedForged).Expect().Status(httptest.StatusForbidden)
+	}
+}
+
+func TestCSRFStatelessIdentity(t *testing.T) {
+	app := newApp(csrf.New(csrf.Options{
+		Stateless: true,
+		Secret:    []byte("secret"),
+		Identity: func(ctx iris.Context) string {
+			return ctx.GetCookie("auth")
+		},
+	}))
+
+	attacker := httptest.New(t, app, httptest.URL("http://example.com"))
+	attackerResp := attacker.GET("/").WithCookie("auth", "attacker").Expect().Status(httptest.StatusOK)
+	attackerToken := attackerResp.Body().Raw()
+	attackerCookie := attackerResp.Cookie("_csrf").Value().Raw()
+
+	attacker.POST("/").WithCookie("auth", "attacker").WithHeader("X-CSRF-Token", attackerToken).
+		Expect().Status(httptest.StatusOK)
+
+	// The attacker's cookie and token pair is injected to the victim's browser.
+	httptest.New(t, app, httptest.URL("http://example.com")).POST("/").
+		WithCookie("auth", "victim").WithCookie("_csrf", attackerCookie).
+		WithHeader("X-CSRF-Token", attackerToken).Expect().Status(httptest.StatusForbidden)
+}
+
+func TestCSRFView(t *testing.T) {
+	app := iris.New()
+	app.RegisterView(iris.HTML(fstest.MapFS{
+		"form.html":   &fstest.MapFile{Data: []byte(`{{ csrf_field . }}|{{ csrf_token . }}|{{ .csrf_field }}|{{ .csrf_token }}`)},
+		"struct.html": &fstest.MapFile{Data: []byte(`{{ .CSRFField }}`)},
+	}, ".html"))
+	app.Use(csrf.New(csrf.Options{Stateless: true}))
+	app.Get("/", func(ctx iris.Context) {
+		ctx.View("form.html")
+	})
+	app.Get("/struct", func(ctx iris.Context) {
+		ctx.View("struct.html", struct{ CSRFField template.HTML }{csrf.Field(ctx)})
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(httptest.StatusOK).
+		Body().Match(`^<input type="hidden" name="csrf_token" value="([\w-]+)">\|([\w-]+)\|<input type="hidden" name="csrf_token" value="([\w-]+)">\|([\w-]+)$`)
+	e.GET("/struct").Expect().Status(httptest.StatusOK).
+		Body().Match(`^<input type="hidden" name="csrf_token" value="([\w-]+)">$`)
+}
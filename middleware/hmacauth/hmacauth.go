@@ -0,0 +1,294 @@
+// Package hmacauth implements a middleware which verifies HMAC-SHA256 signed requests,
+// e.g. inbound webhooks, with replay protection through a timestamp window and a nonce cache.
+// The outgoing requests can be signed the same way through the Sign function
+// or the Signer, an x/client request handler.
+package hmacauth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/hmacauth.*", "iris.hmacauth")
+}
+
+var (
+	// ErrMissing is fired when the request does not contain a signature.
+	ErrMissing = errors.New("hmacauth: missing signature")
+	// ErrInvalid is fired when the signature is malformed or it does not match.
+	ErrInvalid = errors.New("hmacauth: invalid signature")
+	// ErrExpired is fired when the timestamp is missing or outside of the Tolerance window.
+	ErrExpired = errors.New("hmacauth: expired timestamp")
+	// ErrReplayed is fired when a request with the same nonce (or signature) was already received.
+	ErrReplayed = errors.New("hmacauth: replayed request")
+	// ErrBodyTooLarge is fired when the request body is larger than the MaxBodySize option.
+	ErrBodyTooLarge = errors.New("hmacauth: request body too large")
+)
+
+// NonceStore keeps the received nonces for replay protection.
+// See `NewMemNonceStore` package-level function.
+type NonceStore interface {
+	// Seen reports whether the nonce was already stored and, if not, it stores it until "expiresAt".
+	// It must be safe for concurrent use.
+	Seen(nonce string, expiresAt time.Time) (bool, error)
+}
+
+// Options holds the configuration for the HMAC verification middleware.
+// The only required value is the Secrets (or GetSecrets) field.
+type Options struct {
+	// Scheme describes the signature and its headers.
+	// Defaults to the DefaultScheme.
+	Scheme Scheme
+	// Secrets are the shared secrets, the request is valid if it's signed by any of them.
+	// Use more than one during a secret rotation.
+	Secrets []string
+	// GetSecrets, if not nil, returns the secrets of the current request
+	// instead of the static Secrets, e.g. per partner secrets.
+	GetSecrets func(ctx *context.Context) ([]string, error)
+	// Tolerance is the maximum allowed difference between the request's
+	// timestamp and the server's time. It's also the nonce lifetime,
+	// consider a longer one for schemes without a timestamp, e.g. the GitHubScheme.
+	// Defaults to 5 minutes.
+	Tolerance time.Duration
+	// NonceStore stores the received nonces, or signatures when the scheme has no nonce header,
+	// for replay protection. Defaults to a memory store, set it to
+	// a shared store when the application runs on more than one instances.
+	NonceStore NonceStore
+	// DisableReplayProtection disables the nonce store.
+	DisableReplayProtection bool
+	// MaxBodySize, if greater than zero, limits the size of the request body.
+	MaxBodySize int64
+	// ErrorHandler handles the verification failures,
+	// the "err" is one of the package-level errors or a NonceStore's one.
+	//
+	// Defaults to the DefaultErrorHandler.
+	ErrorHandler func(ctx *context.Context, err error)
+}
+
+// DefaultErrorHandler is the default error handler.
+// It sends 413 when the body is larger than the MaxBodySize,
+// 500 on NonceStore and GetSecrets errors and 401 otherwise.
+// The error messages are not sent to the client.
+func DefaultErrorHandler(ctx *context.Context, err error) {
+	switch {
+	case errors.Is(err, ErrMissing), errors.Is(err, ErrInvalid), errors.Is(err, ErrExpired), errors.Is(err, ErrReplayed):
+		ctx.StopWithError(http.StatusUnauthorized, context.PrivateError(err))
+	case errors.Is(err, ErrBodyTooLarge):
+		ctx.StopWithError(http.StatusRequestEntityTooLarge, context.PrivateError(err))
+	default:
+		ctx.StopWithError(http.StatusInternalServerError, context.PrivateError(err))
+	}
+}
+
+// HMACAuth holds the options of the HMAC verification middleware.
+// Initialize with the `New` package-level function.
+type HMACAuth struct {
+	opts Options
+	// nonceSigned reports whether the scheme's content covers the nonce.
+	nonceSigned bool
+}
+
+// New returns a new HMAC signature verification middleware.
+// The request body is recorded, so it's still readable by the next handlers.
+//
+// Example Code:
+//
+//	app.Post("/webhooks/github", hmacauth.New(hmacauth.Options{
+//		Scheme:  hmacauth.GitHubScheme,
+//		Secrets: []string{os.Getenv("GITHUB_WEBHOOK_SECRET")},
+//	}), handleGitHubEvent)
+func New(opts Options) context.Handler {
+	if len(opts.Secrets) == 0 && opts.GetSecrets == nil {
+		panic("hmacauth: Secrets field is required")
+	}
+
+	if opts.Scheme.SignatureHeader == "" {
+		opts.Scheme = DefaultScheme
+	}
+
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+
+	if opts.NonceStore == nil && !opts.DisableReplayProtection {
+		opts.NonceStore = NewMemNonceStore()
+	}
+
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+
+	a := &HMACAuth{
+		opts:        opts,
+		nonceSigned: isNonceSigned(opts.Scheme),
+	}
+	return a.serveHTTP
+}
+
+func (a *HMACAuth) serveHTTP(ctx *context.Context) {
+	if err := a.verify(ctx); err != nil {
+		a.opts.ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.Next()
+}
+
+func (a *HMACAuth) verify(ctx *context.Context) error {
+	scheme := a.opts.Scheme
+
+	headerValue := ctx.GetHeader(scheme.SignatureHeader)
+	if headerValue == "" {
+		return ErrMissing
+	}
+
+	signatures, timestamp, err := scheme.Parse(headerValue)
+	if err != nil {
+		return ErrInvalid
+	}
+
+	if scheme.TimestampHeader != "" {
+		if timestamp, err = strconv.ParseInt(ctx.GetHeader(scheme.TimestampHeader), 10, 64); err != nil {
+			return ErrExpired
+		}
+	}
+
+	now := time.Now()
+	// The schemes without a timestamp (e.g. GitHub) are protected by the nonce only.
+	if timestamp != 0 || scheme.TimestampHeader != "" {
+		if diff := now.Sub(time.Unix(timestamp, 0)); diff > a.opts.Tolerance || diff < -a.opts.Tolerance {
+			return ErrExpired
+		}
+	}
+
+	if a.opts.MaxBodySize > 0 {
+		ctx.SetMaxRequestBodySize(a.opts.MaxBodySize)
+	}
+
+	// Keep the body readable for the next handlers.
+	ctx.RecordRequestBody(true)
+	body, err := ctx.GetBody()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return ErrBodyTooLarge
+		}
+
+		return err
+	}
+
+	secrets := a.opts.Secrets
+	if a.opts.GetSecrets != nil {
+		if secrets, err = a.opts.GetSecrets(ctx); err != nil {
+			return err
+		}
+	}
+
+	p := Payload{
+		Method:    ctx.Method(),
+		Path:      ctx.Request().URL.RequestURI(),
+		Timestamp: timestamp,
+		Body:      body,
+	}
+	if scheme.NonceHeader != "" {
+		p.Nonce = ctx.GetHeader(scheme.NonceHeader)
+	}
+
+	if !match(scheme, secrets, p, signatures) {
+		return ErrInvalid
+	}
+
+	if a.opts.NonceStore != nil {
+		// An unsigned nonce can be changed by an attacker, use the signature instead.
+		nonce := p.Nonce
+		if nonce == "" || !a.nonceSigned {
+			nonce = hex.EncodeToString(signatures[0])
+		}
+
+		seen, err := a.opts.NonceStore.Seen(nonce, now.Add(a.opts.Tolerance))
+		if err != nil {
+			return err
+		}
+
+		if seen {
+			return ErrReplayed
+		}
+	}
+
+	return nil
+}
+
+// isNonceSigned reports whether the scheme's content depends on the nonce.
+func isNonceSigned(scheme Scheme) bool {
+	if scheme.NonceHeader == "" {
+		return false
+	}
+
+	return !bytes.Equal(scheme.Content(Payload{Nonce: "a"}), scheme.Content(Payload{Nonce: "b"}))
+}
+
+func match(scheme Scheme, secrets []string, p Payload, signatures [][]byte) bool {
+	for _, secret := range secrets {
+		expected := scheme.Compute(secret, p)
+		for _, signature := range signatures {
+			if hmac.Equal(expected, signature) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MemNonceStore is a NonceStore which keeps the nonces in memory.
+// The expired nonces are removed on insertion, at most once per minute.
+type MemNonceStore struct {
+	mu       sync.Mutex
+	nonces   map[string]time.Time
+	lastGC   time.Time
+	gcPeriod time.Duration
+}
+
+var _ NonceStore = (*MemNonceStore)(nil)
+
+// NewMemNonceStore returns a new memory NonceStore.
+func NewMemNonceStore() *MemNonceStore {
+	return &MemNonceStore{
+		nonces:   make(map[string]time.Time),
+		lastGC:   time.Now(),
+		gcPeriod: time.Minute,
+	}
+}
+
+// Seen completes the NonceStore interface.
+func (s *MemNonceStore) Seen(nonce string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastGC) >= s.gcPeriod {
+		for n, exp := range s.nonces {
+			if now.After(exp) {
+				delete(s.nonces, n)
+			}
+		}
+		s.lastGC = now
+	}
+
+	if exp, ok := s.nonces[nonce]; ok && now.Before(exp) {
+		return true, nil
+	}
+
+	s.nonces[nonce] = expiresAt
+	return false, nil
+}
@@ -0,0 +1,181 @@
+package hmacauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	stdhttptest "net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/hmacauth"
+	"github.com/kataras/iris/v12/x/client"
+)
+
+const secret = "It's a Secret to Everybody"
+
+func newApp(opts hmacauth.Options) *iris.Application {
+	app := iris.New()
+	app.Post("/webhook", hmacauth.New(opts), func(ctx iris.Context) {
+		body, _ := ctx.GetBody()
+		ctx.Write(body)
+	})
+
+	return app
+}
+
+// signedHeaders returns the headers of a signed request.
+func signedHeaders(t *testing.T, scheme hmacauth.Scheme, body string) map[string]string {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, "/webhook", nil)
+	if err := hmacauth.Sign(req, []byte(body), secret, scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	headers := make(map[string]string)
+	for k := range req.Header {
+		headers[k] = req.Header.Get(k)
+	}
+
+	return headers
+}
+
+func TestHMACAuth(t *testing.T) {
+	app := newApp(hmacauth.Options{Secrets: []string{"old secret", secret}})
+	e := httptest.New(t, app)
+
+	headers := signedHeaders(t, hmacauth.DefaultScheme, "payload")
+	e.POST("/webhook").WithHeaders(headers).WithText("payload").Expect().
+		Status(httptest.StatusOK).Body().IsEqual("payload")
+	// Replay.
+	e.POST("/webhook").WithHeaders(headers).WithText("payload").Expect().
+		Status(httptest.StatusUnauthorized)
+
+	// Tampered body.
+	e.POST("/webhook").WithHeaders(signedHeaders(t, hmacauth.DefaultScheme, "payload")).
+		WithText("tampered").Expect().Status(httptest.StatusUnauthorized)
+
+	// Missing signature.
+	e.POST("/webhook").WithText("payload").Expect().Status(httptest.StatusUnauthorized)
+
+	// Expired timestamp, the signature is valid.
+	headers = signedHeaders(t, hmacauth.DefaultScheme, "payload")
+	p := hmacauth.Payload{
+		Method:    http.MethodPost,
+		Path:      "/webhook",
+		Timestamp: time.Now().Add(-10 * time.Minute).Unix(),
+		Nonce:     headers["X-Signature-Nonce"],
+		Body:      []byte("payload"),
+	}
+	headers["X-Signature-Timestamp"] = strconv.FormatInt(p.Timestamp, 10)
+	headers["X-Signature"] = hmacauth.DefaultScheme.Format(hmacauth.DefaultScheme.Compute(secret, p), p.Timestamp)
+	e.POST("/webhook").WithHeaders(headers).WithText("payload").Expect().
+		Status(httptest.StatusUnauthorized)
+}
+
+func TestHMACAuthGitHub(t *testing.T) {
+	app := newApp(hmacauth.Options{Scheme: hmacauth.GitHubScheme, Secrets: []string{secret}})
+	e := httptest.New(t, app)
+
+	// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries#testing-the-webhook-payload-validation
+	e.POST("/webhook").
+		WithHeader("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17").
+		WithHeader("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958").
+		WithText("Hello, World!").Expect().Status(httptest.StatusOK).Body().IsEqual("Hello, World!")
+	e.POST("/webhook").
+		WithHeader("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17").
+		WithHeader("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958").
+		WithText("Hello, World!").Expect().Status(httptest.StatusUnauthorized)
+}
+
+func TestHMACAuthGitHubReplayNewDelivery(t *testing.T) {
+	var errs []error
+	app := newApp(hmacauth.Options{
+		Scheme:  hmacauth.GitHubScheme,
+		Secrets: []string{secret},
+		ErrorHandler: func(ctx iris.Context, err error) {
+			errs = append(errs, err)
+			hmacauth.DefaultErrorHandler(ctx, err)
+		},
+	})
+	e := httptest.New(t, app)
+
+	e.POST("/webhook").
+		WithHeader("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17").
+		WithHeader("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958").
+		WithText("Hello, World!").Expect().Status(httptest.StatusOK)
+	// The delivery header is not signed, a replay with a new one must be rejected.
+	e.POST("/webhook").
+		WithHeader("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17").
+		WithHeader("X-GitHub-Delivery", "00000000-0000-0000-0000-000000000000").
+		WithText("Hello, World!").Expect().Status(httptest.StatusUnauthorized)
+
+	if len(errs) != 1 || !errors.Is(errs[0], hmacauth.ErrReplayed) {
+		t.Fatalf("expected error: %v but got: %v", hmacauth.ErrReplayed, errs)
+	}
+}
+
+func TestHMACAuthStripe(t *testing.T) {
+	app := newApp(hmacauth.Options{Scheme: hmacauth.StripeScheme, Secrets: []string{secret}})
+	e := httptest.New(t, app)
+
+	headers := signedHeaders(t, hmacauth.StripeScheme, `{"id":"evt_1"}`)
+	// Stripe sends more than one signatures during a secret rotation.
+	headers["Stripe-Signature"] += ",v1=00ff"
+	e.POST("/webhook").WithHeaders(headers).WithText(`{"id":"evt_1"}`).Expect().Status(httptest.StatusOK)
+
+	e.POST("/webhook").WithHeader("Stripe-Signature", "v1=00ff").
+		WithText(`{"id":"evt_1"}`).Expect().Status(httptest.StatusUnauthorized)
+}
+
+func TestHMACAuthMaxBodySize(t *testing.T) {
+	var errs []error
+	app := newApp(hmacauth.Options{
+		Secrets:     []string{secret},
+		MaxBodySize: 4,
+		ErrorHandler: func(ctx iris.Context, err error) {
+			errs = append(errs, err)
+			hmacauth.DefaultErrorHandler(ctx, err)
+		},
+	})
+	e := httptest.New(t, app)
+
+	e.POST("/webhook").WithHeaders(signedHeaders(t, hmacauth.DefaultScheme, "body")).
+		WithText("body").Expect().Status(httptest.StatusOK).Body().IsEqual("body")
+	e.POST("/webhook").WithHeaders(signedHeaders(t, hmacauth.DefaultScheme, "payload")).
+		WithText("payload").Expect().Status(httptest.StatusRequestEntityTooLarge)
+
+	if len(errs) != 1 || !errors.Is(errs[0], hmacauth.ErrBodyTooLarge) {
+		t.Fatalf("expected error: %v but got: %v", hmacauth.ErrBodyTooLarge, errs)
+	}
+}
+
+func TestSigner(t *testing.T) {
+	app := newApp(hmacauth.Options{Secrets: []string{secret}})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := stdhttptest.NewServer(app)
+	defer srv.Close()
+
+	c := client.New(client.BaseURL(srv.URL))
+	c.RegisterRequestHandler(hmacauth.NewSigner(secret, hmacauth.DefaultScheme))
+
+	for i := 0; i < 2; i++ { // a new nonce per request.
+		var body string
+		if err := c.ReadPlain(context.Background(), &body, http.MethodPost, "/webhook", []byte("payload")); err != nil {
+			t.Fatal(err)
+		}
+
+		if body != "payload" {
+			t.Fatalf("expected body: payload but got: %s", body)
+		}
+	}
+}
+
+var _ client.RequestHandler = (*hmacauth.Signer)(nil)
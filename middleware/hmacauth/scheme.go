@@ -0,0 +1,230 @@
+package hmacauth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payload holds the request's information which is signed.
+type Payload struct {
+	Method    string
+	Path      string // including the raw query, if any.
+	Timestamp int64  // unix seconds.
+	Nonce     string
+	Body      []byte
+}
+
+// Scheme describes how a request is signed: the signed content and
+// the headers which carry the signature, the timestamp and the nonce.
+// See the DefaultScheme, GitHubScheme and StripeScheme package-level variables.
+type Scheme struct {
+	// SignatureHeader is the request header which holds the signature.
+	SignatureHeader string
+	// TimestampHeader is the request header which holds the unix timestamp (seconds).
+	// Empty when the scheme does not use a timestamp or
+	// the timestamp is part of the signature header.
+	TimestampHeader string
+	// NonceHeader is the request header which holds a unique request identifier,
+	// it's used for replay protection. When empty, or when the Content does not cover it,
+	// the signature itself is used instead, as an unsigned nonce can be changed by an attacker.
+	NonceHeader string
+	// Content returns the content to be signed.
+	Content func(p Payload) []byte
+	// Format returns the signature header value.
+	Format func(signature []byte, timestamp int64) string
+	// Parse parses the signature header value and returns
+	// the signatures and the timestamp (zero if it's not part of the header).
+	Parse func(value string) (signatures [][]byte, timestamp int64, err error)
+}
+
+var errMalformedHeader = errors.New("malformed signature header")
+
+// DefaultScheme signs the method, the path, the timestamp, the nonce and the body of the request,
+// separated by new lines. The hex-encoded signature is sent through the "X-Signature" header,
+// the timestamp through the "X-Signature-Timestamp" and the nonce through the "X-Signature-Nonce" one.
+var DefaultScheme = Scheme{
+	SignatureHeader: "X-Signature",
+	TimestampHeader: "X-Signature-Timestamp",
+	NonceHeader:     "X-Signature-Nonce",
+	Content: func(p Payload) []byte {
+		var b bytes.Buffer
+		b.WriteString(p.Method)
+		b.WriteByte('\n')
+		b.WriteString(p.Path)
+		b.WriteByte('\n')
+		b.WriteString(strconv.FormatInt(p.Timestamp, 10))
+		b.WriteByte('\n')
+		b.WriteString(p.Nonce)
+		b.WriteByte('\n')
+		b.Write(p.Body)
+		return b.Bytes()
+	},
+	Format: func(signature []byte, _ int64) string {
+		return hex.EncodeToString(signature)
+	},
+	Parse: func(value string) ([][]byte, int64, error) {
+		signature, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, 0, errMalformedHeader
+		}
+
+		return [][]byte{signature}, 0, nil
+	},
+}
+
+// GitHubScheme is the scheme of the GitHub webhooks.
+// The body is signed and sent through the "X-Hub-Signature-256" header
+// in the form of "sha256={hex}". The "X-GitHub-Delivery" header is sent as the nonce
+// but it's not signed, so the replay protection is based on the signature.
+// Note that GitHub does not send a timestamp.
+var GitHubScheme = Scheme{
+	SignatureHeader: "X-Hub-Signature-256",
+	NonceHeader:     "X-GitHub-Delivery",
+	Content: func(p Payload) []byte {
+		return p.Body
+	},
+	Format: func(signature []byte, _ int64) string {
+		return "sha256=" + hex.EncodeToString(signature)
+	},
+	Parse: func(value string) ([][]byte, int64, error) {
+		hexSignature, ok := strings.CutPrefix(value, "sha256=")
+		if !ok {
+			return nil, 0, errMalformedHeader
+		}
+
+		signature, err := hex.DecodeString(hexSignature)
+		if err != nil {
+			return nil, 0, errMalformedHeader
+		}
+
+		return [][]byte{signature}, 0, nil
+	},
+}
+
+// StripeScheme is the scheme of the Stripe webhooks.
+// The "{timestamp}.{body}" is signed and sent through the "Stripe-Signature" header
+// in the form of "t={timestamp},v1={hex}". The header may contain more than one v1 signatures
+// during a secret rotation.
+var StripeScheme = Scheme{
+	SignatureHeader: "Stripe-Signature",
+	Content: func(p Payload) []byte {
+		return append([]byte(strconv.FormatInt(p.Timestamp, 10)+"."), p.Body...)
+	},
+	Format: func(signature []byte, timestamp int64) string {
+		return "t=" + strconv.FormatInt(timestamp, 10) + ",v1=" + hex.EncodeToString(signature)
+	},
+	Parse: func(value string) ([][]byte, int64, error) {
+		var (
+			signatures [][]byte
+			timestamp  int64
+		)
+
+		for _, part := range strings.Split(value, ",") {
+			key, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				return nil, 0, errMalformedHeader
+			}
+
+			switch key {
+			case "t":
+				t, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return nil, 0, errMalformedHeader
+				}
+				timestamp = t
+			case "v1":
+				signature, err := hex.DecodeString(v)
+				if err != nil {
+					return nil, 0, errMalformedHeader
+				}
+				signatures = append(signatures, signature)
+			}
+		}
+
+		if timestamp == 0 || len(signatures) == 0 {
+			return nil, 0, errMalformedHeader
+		}
+
+		return signatures, timestamp, nil
+	},
+}
+
+// Compute returns the HMAC-SHA256 of the scheme's content of "p".
+func (s Scheme) Compute(secret string, p Payload) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(s.Content(p))
+	return h.Sum(nil)
+}
+
+// Sign signs the request based on the scheme and sets the necessary headers.
+// The "body" should be the exact request body, e.g. the result of http.Request.GetBody.
+func Sign(r *http.Request, body []byte, secret string, scheme Scheme) error {
+	p := Payload{
+		Method:    r.Method,
+		Path:      r.URL.RequestURI(),
+		Timestamp: time.Now().Unix(),
+		Body:      body,
+	}
+
+	if scheme.NonceHeader != "" {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+
+		p.Nonce = hex.EncodeToString(nonce)
+		r.Header.Set(scheme.NonceHeader, p.Nonce)
+	}
+
+	if scheme.TimestampHeader != "" {
+		r.Header.Set(scheme.TimestampHeader, strconv.FormatInt(p.Timestamp, 10))
+	}
+
+	r.Header.Set(scheme.SignatureHeader, scheme.Format(scheme.Compute(secret, p), p.Timestamp))
+	return nil
+}
+
+// SignRequest same as Sign but it reads the body from the request
+// and makes it readable again.
+func SignRequest(r *http.Request, secret string, scheme Scheme) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	return Sign(r, body, secret, scheme)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if r.GetBody != nil {
+		rc, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		return io.ReadAll(rc)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,34 @@
+package hmacauth
+
+import (
+	"context"
+	"net/http"
+)
+
+// Signer signs the outgoing requests with HMAC-SHA256,
+// the same way the middleware verifies them.
+// It completes the x/client.RequestHandler interface.
+//
+// Usage:
+//
+//	c := client.New(client.BaseURL("https://partner.com"))
+//	c.RegisterRequestHandler(hmacauth.NewSigner(secret, hmacauth.DefaultScheme))
+type Signer struct {
+	Secret string
+	Scheme Scheme
+}
+
+// NewSigner returns a new request Signer.
+func NewSigner(secret string, scheme Scheme) *Signer {
+	return &Signer{Secret: secret, Scheme: scheme}
+}
+
+// BeginRequest signs the request. The request body is kept readable.
+func (s *Signer) BeginRequest(_ context.Context, req *http.Request) error {
+	return SignRequest(req, s.Secret, s.Scheme)
+}
+
+// EndRequest does nothing, it returns the "err" as it is.
+func (s *Signer) EndRequest(_ context.Context, _ *http.Response, err error) error {
+	return err
+}
@@ -0,0 +1,102 @@
+// Package filewatch provides a polling watcher for the middlewares
+// which reload their files, e.g. basicauth, ipfilter and mtls.
+// The files are checked on the request path, no goroutine is used.
+package filewatch
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watcher reports the modifications of a set of files, by their
+// modification time and size, at most once per "every" duration.
+// Initialize with the `New` package-level function.
+type Watcher struct {
+	filenames []string
+	every     time.Duration
+
+	checkedAt atomic.Int64 // unix nanoseconds.
+
+	mu    sync.Mutex // protects the stats.
+	stats []fileStat
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// New returns a new Watcher of the "filenames", the empty ones are ignored.
+// The current state of the files is the unmodified one.
+// If "every" is zero or negative then the files are never checked.
+func New(every time.Duration, filenames ...string) *Watcher {
+	w := &Watcher{every: every}
+	for _, filename := range filenames {
+		if filename != "" {
+			w.filenames = append(w.filenames, filename)
+		}
+	}
+
+	w.checkedAt.Store(time.Now().UnixNano())
+	w.stats = w.stat()
+	return w
+}
+
+// Check calls the "reload" function when any of the files was modified
+// since the last successful reload and returns its error.
+// Only one caller checks the files per "every" duration, the rest return immediately.
+// On "reload" failure the files are reported as modified on the next check too.
+func (w *Watcher) Check(reload func() error) error {
+	if w.every <= 0 {
+		return nil
+	}
+
+	now := time.Now().UnixNano()
+	checkedAt := w.checkedAt.Load()
+	if now-checkedAt < int64(w.every) || !w.checkedAt.CompareAndSwap(checkedAt, now) {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := w.stat()
+	if equalStats(stats, w.stats) {
+		return nil
+	}
+
+	if err := reload(); err != nil {
+		return err
+	}
+
+	w.stats = stats
+	return nil
+}
+
+// stat returns the state of the files, a missing file has a zero one.
+func (w *Watcher) stat() []fileStat {
+	stats := make([]fileStat, len(w.filenames))
+	for i, filename := range w.filenames {
+		if info, err := os.Stat(filename); err == nil {
+			stats[i] = fileStat{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	return stats
+}
+
+func equalStats(a, b []fileStat) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package filewatch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(filename, []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(time.Millisecond, filename, "")
+	var reloads int
+	reload := func() error {
+		reloads++
+		return nil
+	}
+
+	check := func(reload func() error, expectedReloads int) {
+		t.Helper()
+
+		time.Sleep(2 * time.Millisecond)
+		w.Check(reload)
+		if reloads != expectedReloads {
+			t.Fatalf("expected %d reloads but got: %d", expectedReloads, reloads)
+		}
+	}
+
+	check(reload, 0)
+
+	// Same modification time, different size.
+	info, _ := os.Stat(filename)
+	os.WriteFile(filename, []byte("ab"), 0600)
+	os.Chtimes(filename, info.ModTime(), info.ModTime())
+	check(reload, 1)
+	check(reload, 1)
+
+	// A failed reload is retried.
+	os.WriteFile(filename, []byte("abc"), 0600)
+	failErr := errors.New("fail")
+	time.Sleep(2 * time.Millisecond)
+	if err := w.Check(func() error { return failErr }); err != failErr {
+		t.Fatalf("expected error: %v but got: %v", failErr, err)
+	}
+	check(reload, 2)
+
+	// Removed file.
+	os.Remove(filename)
+	check(reload, 3)
+
+	// Not checked more than once per "every" duration.
+	w = New(time.Hour, filename)
+	os.WriteFile(filename, []byte("a"), 0600)
+	check(reload, 3)
+
+	// Never checked.
+	w = New(0, filename)
+	os.Remove(filename)
+	check(reload, 3)
+}
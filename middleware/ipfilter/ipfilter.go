@@ -0,0 +1,295 @@
+// Package ipfilter implements an IP allow/deny list middleware
+// based on IPv4 and IPv6 CIDR ranges, which can be reloaded at runtime.
+package ipfilter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/netutil"
+	"github.com/kataras/iris/v12/middleware/accesslog"
+	"github.com/kataras/iris/v12/middleware/internal/filewatch"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/ipfilter.*", "iris.ipfilter")
+}
+
+// The accesslog fields which are set on each request.
+const (
+	// DecisionField is the accesslog field of the decision, "allow" or "deny".
+	DecisionField = "ip.filter"
+	// RuleField is the accesslog field of the matched rule,
+	// or "default" when the client's address did not match any rule.
+	RuleField = "ip.rule"
+)
+
+// Mode is the order of the rules evaluation.
+type Mode uint8
+
+const (
+	// DenyFirst evaluates the Deny rules first and then the Allow ones.
+	// A client is allowed if it matches an Allow rule or it does not match any Deny rule,
+	// the default decision is to allow.
+	DenyFirst Mode = iota
+	// AllowFirst evaluates the Allow rules first and then the Deny ones.
+	// A client is allowed only if it matches an Allow rule and it does not match any Deny rule,
+	// the default decision is to deny. Use it to restrict a Party to specific networks.
+	AllowFirst
+)
+
+// Options holds the configuration for the IP filter middleware.
+type Options struct {
+	// Mode is the order of the rules evaluation.
+	// Defaults to DenyFirst.
+	Mode Mode
+	// Allow is a list of IP addresses, CIDRs (e.g. "10.8.0.0/16", "2001:db8::/32")
+	// or ranges (e.g. "10.0.0.1-10.0.0.9") which are allowed.
+	Allow []string
+	// Deny is a list of IP addresses, CIDRs or ranges which are denied.
+	Deny []string
+	// AllowFile is a file which contains one allow rule per line,
+	// empty lines and lines starting with # are ignored.
+	// Its rules are appended to the Allow ones.
+	AllowFile string
+	// DenyFile is a file which contains one deny rule per line.
+	// Its rules are appended to the Deny ones.
+	DenyFile string
+	// ReloadEvery, if greater than zero, checks the AllowFile and DenyFile
+	// for changes at most once per "ReloadEvery" duration and reloads them on change.
+	ReloadEvery time.Duration
+	// DenyHandler is fired when a client is denied.
+	// Defaults to a handler which sends 403 Forbidden.
+	DenyHandler context.Handler
+}
+
+type rule struct {
+	raw        string
+	start, end net.IP // 16-byte form.
+}
+
+func (r rule) contains(ip net.IP) bool {
+	return bytes.Compare(ip, r.start) >= 0 && bytes.Compare(ip, r.end) <= 0
+}
+
+type rules struct {
+	allow, deny []rule
+}
+
+// Filter is the IP filter middleware.
+// Initialize with the `New` package-level function.
+type Filter struct {
+	opts Options
+
+	rules atomic.Pointer[rules]
+
+	mu      sync.Mutex // protects the file loading.
+	watcher *filewatch.Watcher
+}
+
+// New returns a new IP filter. It panics on invalid rules or files.
+// The client's address is extracted through the Context.RemoteAddr method,
+// which respects the RemoteAddrHeaders and RemoteAddrPrivateSubnets configuration fields.
+//
+// Example Code:
+//
+//	vpn := ipfilter.New(ipfilter.Options{
+//		Mode:  ipfilter.AllowFirst,
+//		Allow: []string{"10.8.0.0/16", "fd00:8::/32"},
+//	})
+//	admin := app.Party("/admin", vpn.Handler)
+func New(opts Options) *Filter {
+	if opts.DenyHandler == nil {
+		opts.DenyHandler = func(ctx *context.Context) {
+			ctx.StopWithStatus(http.StatusForbidden)
+		}
+	}
+
+	f := &Filter{
+		opts:    opts,
+		watcher: filewatch.New(opts.ReloadEvery, opts.AllowFile, opts.DenyFile),
+	}
+	if err := f.Reload(); err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// Handler is the IP filter middleware.
+// It fires the DenyHandler when the client is not allowed,
+// otherwise it fires the next handler.
+func (f *Filter) Handler(ctx *context.Context) {
+	if f.opts.ReloadEvery > 0 {
+		f.checkFiles(ctx)
+	}
+
+	allowed, matched := f.Allowed(ctx.RemoteAddr())
+
+	fields := accesslog.GetFields(ctx)
+	if allowed {
+		fields.Set(DecisionField, "allow")
+	} else {
+		fields.Set(DecisionField, "deny")
+	}
+	fields.Set(RuleField, matched)
+
+	if !allowed {
+		f.opts.DenyHandler(ctx)
+		return
+	}
+
+	ctx.Next()
+}
+
+// Allowed reports whether the "ipAddress" is allowed
+// and the rule which made the decision, "default" if none matched.
+// Invalid addresses are always denied.
+func (f *Filter) Allowed(ipAddress string) (bool, string) {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false, "invalid"
+	}
+
+	r := f.rules.Load()
+
+	switch f.opts.Mode {
+	case AllowFirst:
+		allowRule, ok := match(r.allow, ip)
+		if !ok {
+			return false, "default"
+		}
+
+		if denyRule, ok := match(r.deny, ip); ok {
+			return false, denyRule
+		}
+
+		return true, allowRule
+	default:
+		if allowRule, ok := match(r.allow, ip); ok {
+			return true, allowRule
+		}
+
+		if denyRule, ok := match(r.deny, ip); ok {
+			return false, denyRule
+		}
+
+		return true, "default"
+	}
+}
+
+func match(list []rule, ip net.IP) (string, bool) {
+	for _, r := range list {
+		if r.contains(ip) {
+			return r.raw, true
+		}
+	}
+
+	return "", false
+}
+
+// Update replaces the Allow and Deny rules at runtime.
+// The rules of the AllowFile and DenyFile are kept.
+// On error the previous rules are kept.
+func (f *Filter) Update(allow, deny []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prevAllow, prevDeny := f.opts.Allow, f.opts.Deny
+	f.opts.Allow, f.opts.Deny = allow, deny
+	if err := f.load(); err != nil {
+		f.opts.Allow, f.opts.Deny = prevAllow, prevDeny
+		return err
+	}
+
+	return nil
+}
+
+// Reload parses the rules and reloads the AllowFile and DenyFile.
+// On error the previous rules are kept.
+func (f *Filter) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.load()
+}
+
+func (f *Filter) load() error {
+	allow, err := parseRules(f.opts.Allow, f.opts.AllowFile)
+	if err != nil {
+		return err
+	}
+
+	deny, err := parseRules(f.opts.Deny, f.opts.DenyFile)
+	if err != nil {
+		return err
+	}
+
+	f.rules.Store(&rules{allow: allow, deny: deny})
+	return nil
+}
+
+// checkFiles reloads the files if they were modified.
+func (f *Filter) checkFiles(ctx *context.Context) {
+	if err := f.watcher.Check(f.Reload); err != nil {
+		// keep the previous rules.
+		ctx.Application().Logger().Errorf("ipfilter: reload: %v", err)
+	}
+}
+
+func parseRules(list []string, filename string) ([]rule, error) {
+	if filename != "" {
+		fileRules, err := readRulesFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(append([]string(nil), list...), fileRules...)
+	}
+
+	result := make([]rule, 0, len(list))
+	for _, s := range list {
+		r, err := netutil.ParseIPRange(s)
+		if err != nil {
+			return nil, fmt.Errorf("ipfilter: %w", err)
+		}
+
+		result = append(result, rule{
+			raw:   strings.TrimSpace(s),
+			start: net.ParseIP(r.Start).To16(),
+			end:   net.ParseIP(r.End).To16(),
+		})
+	}
+
+	return result, nil
+}
+
+func readRulesFile(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		list = append(list, text)
+	}
+
+	return list, scanner.Err()
+}
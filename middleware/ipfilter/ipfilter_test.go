@@ -0,0 +1,105 @@
+package ipfilter_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/accesslog"
+	"github.com/kataras/iris/v12/middleware/ipfilter"
+)
+
+func TestFilterAllowed(t *testing.T) {
+	denyFirst := ipfilter.New(ipfilter.Options{
+		Allow: []string{"10.0.0.5"},
+		Deny:  []string{"10.0.0.0/8", "2001:db8::/32"},
+	})
+	allowFirst := ipfilter.New(ipfilter.Options{
+		Mode:  ipfilter.AllowFirst,
+		Allow: []string{"10.8.0.0/16", "fd00:8::/32"},
+		Deny:  []string{"10.8.0.1-10.8.0.9"},
+	})
+
+	tests := []struct {
+		filter  *ipfilter.Filter
+		ip      string
+		allowed bool
+		rule    string
+	}{
+		{denyFirst, "10.0.0.5", true, "10.0.0.5"},
+		{denyFirst, "10.1.2.3", false, "10.0.0.0/8"},
+		{denyFirst, "2001:db8::1", false, "2001:db8::/32"},
+		{denyFirst, "192.168.1.1", true, "default"},
+		{denyFirst, "invalid", false, "invalid"},
+		{allowFirst, "10.8.1.1", true, "10.8.0.0/16"},
+		{allowFirst, "fd00:8::abcd", true, "fd00:8::/32"},
+		{allowFirst, "10.8.0.5", false, "10.8.0.1-10.8.0.9"},
+		{allowFirst, "10.9.0.1", false, "default"},
+		{allowFirst, "::1", false, "default"},
+	}
+
+	for i, tt := range tests {
+		allowed, rule := tt.filter.Allowed(tt.ip)
+		if allowed != tt.allowed || rule != tt.rule {
+			t.Fatalf("[%d] %s: expected: %v (%s) but got: %v (%s)", i, tt.ip, tt.allowed, tt.rule, allowed, rule)
+		}
+	}
+}
+
+func TestFilterHandler(t *testing.T) {
+	dir := t.TempDir()
+	allowFile := filepath.Join(dir, "allow.txt")
+	if err := os.WriteFile(allowFile, []byte("# office VPN\n81.2.0.0/16\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	vpn := ipfilter.New(ipfilter.Options{
+		Mode:        ipfilter.AllowFirst,
+		AllowFile:   allowFile,
+		ReloadEvery: time.Millisecond,
+	})
+
+	app := iris.New()
+	app.Configure(iris.WithRemoteAddrHeader("X-Real-Ip"))
+	admin := app.Party("/admin", vpn.Handler)
+	admin.Get("/", func(ctx iris.Context) {
+		fields := accesslog.GetFields(ctx)
+		ctx.Writef("%s %s", fields.GetString(ipfilter.DecisionField), fields.GetString(ipfilter.RuleField))
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/admin").WithHeader("X-Real-Ip", "81.2.3.4").Expect().
+		Status(httptest.StatusOK).Body().IsEqual("allow 81.2.0.0/16")
+	e.GET("/admin").WithHeader("X-Real-Ip", "8.8.8.8").Expect().
+		Status(httptest.StatusForbidden)
+
+	// Live reload of the file.
+	if err := os.WriteFile(allowFile, []byte("8.8.8.8\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Second)
+	if err := os.Chtimes(allowFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	e.GET("/admin").WithHeader("X-Real-Ip", "8.8.8.8").Expect().
+		Status(httptest.StatusOK).Body().IsEqual("allow 8.8.8.8")
+	e.GET("/admin").WithHeader("X-Real-Ip", "81.2.3.4").Expect().
+		Status(httptest.StatusForbidden)
+
+	// Runtime update.
+	if err := vpn.Update([]string{"1.1.1.1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/admin").WithHeader("X-Real-Ip", "1.1.1.1").Expect().Status(httptest.StatusOK)
+
+	if err := vpn.Update([]string{"invalid"}, nil); err == nil {
+		t.Fatalf("expected an error on invalid rules")
+	}
+	// The previous rules are kept.
+	e.GET("/admin").WithHeader("X-Real-Ip", "1.1.1.1").Expect().Status(httptest.StatusOK)
+}
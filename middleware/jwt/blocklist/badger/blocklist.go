@@ -0,0 +1,224 @@
+package badger
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/host"
+	"github.com/kataras/iris/v12/middleware/jwt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DefaultFileMode used as the default database's "fileMode"
+// for creating the blocklist directory path.
+var DefaultFileMode = 0755
+
+// DefaultGCEvery is the default interval of the value log garbage collection
+// which reclaims the disk space of the expired tokens.
+var DefaultGCEvery = 30 * time.Minute
+
+// Blocklist is a jwt.Blocklist backed by a badger(key-value file-based) database.
+// Each invalidated token is stored with a TTL equal to its time left to expire,
+// so expired tokens are removed automatically.
+type Blocklist struct {
+	// GetKey is a function which can be used how to extract
+	// the unique identifier for a token.
+	// Required. By default the token key is extracted through the claims.ID ("jti")
+	// or the token itself if it's empty.
+	GetKey func(token []byte, claims jwt.Claims) string
+	// Prefix the token key into the badger database.
+	// Defaults to "jwt_blocklist_".
+	Prefix string
+	// Service is the underline badger database connection,
+	// it's initialized at `NewBlocklist` or `NewBlocklistFromDB`.
+	Service *badger.DB
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+var _ jwt.Blocklist = (*Blocklist)(nil)
+
+// NewBlocklist returns a new badger-based Blocklist
+// which stores the tokens under the "directoryPath", e.g. ./blocklist.
+// The database is closed on interrupt signals, call its Close method
+// to close it manually.
+//
+// Usage:
+//
+//	blocklist, err := NewBlocklist("./blocklist")
+//
+// And register it:
+//
+//	verifier := jwt.NewVerifier(...)
+//	verifier.Blocklist = blocklist
+func NewBlocklist(directoryPath string) (*Blocklist, error) {
+	if directoryPath == "" {
+		return nil, errors.New("directoryPath is empty")
+	}
+
+	if err := os.MkdirAll(directoryPath, os.FileMode(DefaultFileMode)); err != nil {
+		return nil, err
+	}
+
+	opts := badger.DefaultOptions(directoryPath)
+	opts.Logger = context.DefaultLogger("jwt.blocklist.badger").DisableNewLine()
+
+	service, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBlocklistFromDB(service), nil
+}
+
+// NewBlocklistFromDB same as `NewBlocklist` but accepts an already-created
+// custom badger connection instead.
+func NewBlocklistFromDB(service *badger.DB) *Blocklist {
+	b := &Blocklist{
+		GetKey:  defaultGetKey,
+		Prefix:  "jwt_blocklist_",
+		Service: service,
+		done:    make(chan struct{}),
+	}
+
+	if DefaultGCEvery > 0 {
+		go b.runGC(DefaultGCEvery)
+	}
+
+	host.RegisterOnInterrupt(func() {
+		b.Close()
+	})
+
+	return b
+}
+
+func defaultGetKey(token []byte, claims jwt.Claims) string {
+	if claims.ID != "" {
+		return claims.ID
+	}
+
+	return string(token)
+}
+
+func (b *Blocklist) makeKey(key string) []byte {
+	return []byte(b.Prefix + key)
+}
+
+// ValidateToken checks if the token exists and returns a jwt.ErrBlocked error if so.
+func (b *Blocklist) ValidateToken(token []byte, c jwt.Claims, err error) error {
+	if err != nil {
+		return err // respect the previous error.
+	}
+
+	has, err := b.Has(b.GetKey(token, c))
+	if err != nil {
+		return err
+	} else if has {
+		return jwt.ErrBlocked
+	}
+
+	return nil
+}
+
+// InvalidateToken invalidates a verified JWT token.
+// The token is stored until its expiration time, or forever if it does not expire.
+func (b *Blocklist) InvalidateToken(token []byte, c jwt.Claims) error {
+	if len(token) == 0 {
+		return jwt.ErrMissing
+	}
+
+	entry := badger.NewEntry(b.makeKey(b.GetKey(token, c)), nil)
+	if c.Expiry > 0 {
+		ttl := c.Timeleft()
+		if ttl <= 0 {
+			return nil // already expired, it cannot be used anyway.
+		}
+
+		entry = entry.WithTTL(ttl)
+	}
+
+	return b.Service.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(entry)
+	})
+}
+
+// Del removes a token from the storage.
+func (b *Blocklist) Del(key string) error {
+	return b.Service.Update(func(txn *badger.Txn) error {
+		return txn.Delete(b.makeKey(key))
+	})
+}
+
+// Has reports whether a specific token exists in the storage.
+func (b *Blocklist) Has(key string) (bool, error) {
+	err := b.Service.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(b.makeKey(key))
+		return err
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Count returns the total amount of tokens stored.
+func (b *Blocklist) Count() (int64, error) {
+	var n int64
+
+	err := b.Service.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(b.Prefix)
+
+		iter := txn.NewIterator(opts)
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			n++
+		}
+
+		return nil
+	})
+
+	return n, err
+}
+
+// Close stops the garbage collection and closes the database.
+func (b *Blocklist) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		err = b.Service.Close()
+	})
+
+	return err
+}
+
+// runGC reclaims the disk space of the expired and deleted tokens,
+// badger removes them from the key space automatically.
+func (b *Blocklist) runGC(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			for b.Service.RunValueLogGC(0.5) == nil {
+				// rewrite as many value log files as possible.
+			}
+		}
+	}
+}
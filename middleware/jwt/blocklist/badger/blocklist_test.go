@@ -0,0 +1,96 @@
+package badger_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/middleware/jwt"
+	"github.com/kataras/iris/v12/middleware/jwt/blocklist/badger"
+)
+
+func TestBlocklist(t *testing.T) {
+	dir := t.TempDir()
+
+	b, err := badger.NewBlocklist(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectHas := func(b *badger.Blocklist, key string, expected bool) {
+		t.Helper()
+
+		has, err := b.Has(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != expected {
+			t.Fatalf("[%s] expected has: %v but got: %v", key, expected, has)
+		}
+	}
+
+	expectCount := func(b *badger.Blocklist, expected int64) {
+		t.Helper()
+
+		n, err := b.Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != expected {
+			t.Fatalf("expected %d stored tokens but got: %d", expected, n)
+		}
+	}
+
+	if err = b.InvalidateToken(nil, jwt.Claims{ID: "missing"}); err != jwt.ErrMissing {
+		t.Fatalf("expected error: %v but got: %v", jwt.ErrMissing, err)
+	}
+
+	now := time.Now()
+	tokens := map[string]jwt.Claims{
+		"forever": {ID: "forever"},
+		"expires": {ID: "expires", Expiry: now.Add(2 * time.Second).Unix()},
+		"deleted": {ID: "deleted", Expiry: now.Add(time.Hour).Unix()},
+	}
+	for key, claims := range tokens {
+		if err = b.InvalidateToken([]byte("token"), claims); err != nil {
+			t.Fatal(err)
+		}
+		expectHas(b, key, true)
+	}
+	expectCount(b, 3)
+
+	if err = b.ValidateToken([]byte("token"), tokens["forever"], nil); err != jwt.ErrBlocked {
+		t.Fatalf("expected error: %v but got: %v", jwt.ErrBlocked, err)
+	}
+
+	// Already expired tokens are not stored.
+	if err = b.InvalidateToken([]byte("token"), jwt.Claims{ID: "expired", Expiry: now.Add(-time.Second).Unix()}); err != nil {
+		t.Fatal(err)
+	}
+	expectHas(b, "expired", false)
+
+	if err = b.Del("deleted"); err != nil {
+		t.Fatal(err)
+	}
+	expectHas(b, "deleted", false)
+
+	// TTL expiration, the expired tokens are removed from the key space.
+	time.Sleep(3 * time.Second)
+	expectHas(b, "expires", false)
+	expectHas(b, "forever", true)
+	expectCount(b, 1)
+
+	// Persistence.
+	if err = b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = badger.NewBlocklist(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	expectHas(b, "forever", true)
+	expectHas(b, "deleted", false)
+	expectHas(b, "expires", false)
+}
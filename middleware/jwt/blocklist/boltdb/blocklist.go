@@ -0,0 +1,258 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/core/host"
+	"github.com/kataras/iris/v12/middleware/jwt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultFileMode used as the default database's "fileMode"
+// for creating the blocklist directory path and the database file.
+var DefaultFileMode = 0755
+
+// DefaultGCEvery is the default interval of the removal of the expired tokens.
+var DefaultGCEvery = 30 * time.Minute
+
+// Blocklist is a jwt.Blocklist backed by a BoltDB(file-based) database.
+// Each invalidated token is stored along with its expiration time,
+// the expired tokens are ignored and removed periodically.
+type Blocklist struct {
+	// GetKey is a function which can be used how to extract
+	// the unique identifier for a token.
+	// Required. By default the token key is extracted through the claims.ID ("jti")
+	// or the token itself if it's empty.
+	GetKey func(token []byte, claims jwt.Claims) string
+	// Clock is used to check the tokens expiration, defaults to time.Now.
+	Clock func() time.Time
+	// Service is the underline BoltDB database connection,
+	// it's initialized at `NewBlocklist` or `NewBlocklistFromDB`.
+	Service *bolt.DB
+
+	bucket    []byte
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+var _ jwt.Blocklist = (*Blocklist)(nil)
+
+// NewBlocklist returns a new BoltDB-based Blocklist
+// which stores the tokens on the "path" file, e.g. ./blocklist/jwt.db.
+// The database is closed on interrupt signals, call its Close method
+// to close it manually.
+//
+// Usage:
+//
+//	blocklist, err := NewBlocklist("./blocklist/jwt.db")
+//
+// And register it:
+//
+//	verifier := jwt.NewVerifier(...)
+//	verifier.Blocklist = blocklist
+func NewBlocklist(path string) (*Blocklist, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	fileMode := os.FileMode(DefaultFileMode)
+	if err := os.MkdirAll(filepath.Dir(path), fileMode); err != nil {
+		return nil, err
+	}
+
+	service, err := bolt.Open(path, fileMode, &bolt.Options{Timeout: 20 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := NewBlocklistFromDB(service, "jwt_blocklist")
+	if err != nil {
+		service.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// NewBlocklistFromDB same as `NewBlocklist` but accepts an already-created
+// custom BoltDB connection and the bucket name to store the tokens instead.
+// The expired tokens are removed on initialization.
+func NewBlocklistFromDB(service *bolt.DB, bucketName string) (*Blocklist, error) {
+	bucket := []byte(bucketName)
+
+	err := service.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Blocklist{
+		GetKey:  defaultGetKey,
+		Clock:   time.Now,
+		Service: service,
+		bucket:  bucket,
+		done:    make(chan struct{}),
+	}
+
+	if _, err = b.GC(); err != nil {
+		return nil, err
+	}
+
+	if DefaultGCEvery > 0 {
+		go b.runGC(DefaultGCEvery)
+	}
+
+	host.RegisterOnInterrupt(func() {
+		b.Close()
+	})
+
+	return b, nil
+}
+
+func defaultGetKey(token []byte, claims jwt.Claims) string {
+	if claims.ID != "" {
+		return claims.ID
+	}
+
+	return string(token)
+}
+
+// ValidateToken checks if the token exists and returns a jwt.ErrBlocked error if so.
+func (b *Blocklist) ValidateToken(token []byte, c jwt.Claims, err error) error {
+	if err != nil {
+		if err == jwt.ErrExpired {
+			b.Del(b.GetKey(token, c))
+		}
+
+		return err // respect the previous error.
+	}
+
+	has, err := b.Has(b.GetKey(token, c))
+	if err != nil {
+		return err
+	} else if has {
+		return jwt.ErrBlocked
+	}
+
+	return nil
+}
+
+// InvalidateToken invalidates a verified JWT token.
+// The token is stored until its expiration time, or forever if it does not expire.
+func (b *Blocklist) InvalidateToken(token []byte, c jwt.Claims) error {
+	if len(token) == 0 {
+		return jwt.ErrMissing
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(c.Expiry))
+
+	return b.Service.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Put([]byte(b.GetKey(token, c)), value)
+	})
+}
+
+// Del removes a token from the storage.
+func (b *Blocklist) Del(key string) error {
+	return b.Service.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Delete([]byte(key))
+	})
+}
+
+// Has reports whether a specific, non-expired, token exists in the storage.
+func (b *Blocklist) Has(key string) (bool, error) {
+	var has bool
+
+	err := b.Service.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(b.bucket).Get([]byte(key)); value != nil {
+			has = !b.expired(value, b.Clock().Unix())
+		}
+
+		return nil
+	})
+
+	return has, err
+}
+
+// Count returns the total amount of tokens stored.
+func (b *Blocklist) Count() (int64, error) {
+	var n int64
+
+	err := b.Service.View(func(tx *bolt.Tx) error {
+		n = int64(tx.Bucket(b.bucket).Stats().KeyN)
+		return nil
+	})
+
+	return n, err
+}
+
+// GC removes the expired tokens and returns the number of the removed ones.
+// It's called automatically every DefaultGCEvery.
+func (b *Blocklist) GC() (int, error) {
+	var n int
+	now := b.Clock().Unix()
+
+	err := b.Service.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.bucket).Cursor()
+		for k, v := c.First(); k != nil; {
+			if !b.expired(v, now) {
+				k, v = c.Next()
+				continue
+			}
+
+			key := append([]byte(nil), k...)
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			n++
+			// Seek to the next key, Next skips keys after a delete.
+			k, v = c.Seek(key)
+		}
+
+		return nil
+	})
+
+	return n, err
+}
+
+// Close stops the garbage collection and closes the database.
+func (b *Blocklist) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		err = b.Service.Close()
+	})
+
+	return err
+}
+
+func (b *Blocklist) expired(value []byte, now int64) bool {
+	if len(value) != 8 {
+		return true // invalid entry.
+	}
+
+	expiry := int64(binary.BigEndian.Uint64(value))
+	return expiry > 0 && now > expiry
+}
+
+func (b *Blocklist) runGC(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.GC()
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package boltdb_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/middleware/jwt"
+	"github.com/kataras/iris/v12/middleware/jwt/blocklist/boltdb"
+)
+
+func TestBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist", "jwt.db")
+
+	b, err := boltdb.NewBlocklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	b.Clock = func() time.Time { return now }
+
+	expectHas := func(b *boltdb.Blocklist, key string, expected bool) {
+		t.Helper()
+
+		has, err := b.Has(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != expected {
+			t.Fatalf("[%s] expected has: %v but got: %v", key, expected, has)
+		}
+	}
+
+	if err = b.InvalidateToken(nil, jwt.Claims{ID: "missing"}); err != jwt.ErrMissing {
+		t.Fatalf("expected error: %v but got: %v", jwt.ErrMissing, err)
+	}
+
+	tokens := map[string]jwt.Claims{
+		"forever": {ID: "forever"},
+		"expires": {ID: "expires", Expiry: now.Add(time.Minute).Unix()},
+		"deleted": {ID: "deleted", Expiry: now.Add(time.Hour).Unix()},
+	}
+	for key, claims := range tokens {
+		if err = b.InvalidateToken([]byte("token"), claims); err != nil {
+			t.Fatal(err)
+		}
+		expectHas(b, key, true)
+	}
+
+	if err = b.ValidateToken([]byte("token"), tokens["expires"], nil); err != jwt.ErrBlocked {
+		t.Fatalf("expected error: %v but got: %v", jwt.ErrBlocked, err)
+	}
+
+	if err = b.Del("deleted"); err != nil {
+		t.Fatal(err)
+	}
+	expectHas(b, "deleted", false)
+
+	// TTL expiration.
+	now = now.Add(2 * time.Minute)
+	expectHas(b, "expires", false)
+	expectHas(b, "forever", true)
+
+	if n, err := b.GC(); err != nil || n != 1 {
+		t.Fatalf("expected 1 removed token but got: %d (%v)", n, err)
+	}
+	if n, err := b.Count(); err != nil || n != 1 {
+		t.Fatalf("expected 1 stored token but got: %d (%v)", n, err)
+	}
+
+	// Persistence.
+	if err = b.InvalidateToken([]byte("token"), jwt.Claims{ID: "persisted", Expiry: time.Now().Add(time.Hour).Unix()}); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = boltdb.NewBlocklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	expectHas(b, "forever", true)
+	expectHas(b, "persisted", true)
+	expectHas(b, "deleted", false)
+}
@@ -0,0 +1,299 @@
+package jwt
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+
+	"github.com/kataras/jwt"
+)
+
+// JWKSPath is the well-known path of the JSON Web Key Set,
+// see the KeySet.JWKSHandler and the RemoteKeySet.
+const JWKSPath = "/.well-known/jwks.json"
+
+var (
+	// ErrNoSigningKey is returned by the KeySet's Sign method
+	// when there is no active key to sign a token.
+	ErrNoSigningKey = errors.New("jwt: no signing key")
+	// ErrUnknownKid is returned when the token's "kid" header
+	// does not match any active key.
+	ErrUnknownKid = jwt.ErrUnknownKid
+	// ErrEmptyKid is returned when the token's "kid" header is missing.
+	ErrEmptyKid = jwt.ErrEmptyKid
+)
+
+type (
+	// PrivateKey is the type alias for the private key of a signature algorithm.
+	PrivateKey = jwt.PrivateKey
+	// PublicKey is the type alias for the public key of a signature algorithm.
+	PublicKey = jwt.PublicKey
+	// JWKS is the type alias of a JSON Web Key Set.
+	JWKS = jwt.JWKS
+
+	// HeaderValidator is the type alias of the function which resolves
+	// the signature algorithm and the public key of a token based on its header.
+	// See the KeySet.ValidateHeader and RemoteKeySet.ValidateHeader methods
+	// and the NewKeySetVerifier package-level function.
+	HeaderValidator = jwt.HeaderValidator
+)
+
+// Key is a single key of a KeySet.
+type Key struct {
+	// ID is the unique key identifier, the "kid" header of the signed tokens.
+	ID  string
+	Alg Alg
+	// Public is used to verify tokens signed by this key.
+	Public PublicKey
+	// Private is used to sign tokens, it can be nil for verify-only keys.
+	Private PrivateKey
+	// CreatedAt is the time the key was added to the set,
+	// the newest key with a private key is used to sign tokens.
+	CreatedAt time.Time
+	// ExpiresAt, if not zero, is the time after which the key
+	// is no longer used, neither to sign nor to verify tokens.
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the key is expired at the given time.
+func (k *Key) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// KeySet holds multiple keys identified by their "kid".
+// Tokens are signed with the newest key and verified with any non-expired key,
+// so keys can be rotated without invalidating the tokens signed by the previous ones.
+// It's safe for concurrent use.
+//
+// Usage:
+//
+//	keys := jwt.NewKeySet()
+//	keys.Add("2024-01", jwt.EdDSA, publicKey, privateKey)
+//
+//	signer := jwt.NewKeySetSigner(keys, 15*time.Minute)
+//	verifier := jwt.NewKeySetVerifier(keys.ValidateHeader)
+//	app.Get(jwt.JWKSPath, keys.JWKSHandler)
+//
+// Rotate later:
+//
+//	keys.Rotate("2024-02", jwt.EdDSA, newPublicKey, newPrivateKey, 15*time.Minute)
+type KeySet struct {
+	// Clock is used to check the keys expiration, defaults to time.Now.
+	Clock func() time.Time
+
+	keys map[string]*Key
+	mu   sync.RWMutex
+}
+
+// NewKeySet returns a new empty KeySet.
+func NewKeySet() *KeySet {
+	return &KeySet{
+		Clock: time.Now,
+		keys:  make(map[string]*Key),
+	}
+}
+
+// Add adds a key which never expires (until it's rotated) to the set.
+// The "privateKey" can be nil for verify-only keys.
+// It replaces any existing key with the same "kid".
+func (ks *KeySet) Add(kid string, alg Alg, publicKey PublicKey, privateKey PrivateKey) *KeySet {
+	if alg == HS256 || alg == HS384 || alg == HS512 {
+		// A tiny helper if the end-developer uses string instead of []byte for hmac keys.
+		if k, ok := publicKey.(string); ok {
+			publicKey = []byte(k)
+		}
+		if k, ok := privateKey.(string); ok {
+			privateKey = []byte(k)
+		}
+	}
+
+	ks.AddKey(&Key{
+		ID:        kid,
+		Alg:       alg,
+		Public:    publicKey,
+		Private:   privateKey,
+		CreatedAt: ks.Clock(),
+	})
+	return ks
+}
+
+// AddKey adds a key to the set.
+// It replaces any existing key with the same ID.
+// The key must not be modified after it's added,
+// the KeySet replaces its stored keys instead of modifying them.
+func (ks *KeySet) AddKey(key *Key) {
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = ks.Clock()
+	}
+
+	ks.mu.Lock()
+	ks.keys[key.ID] = key
+	ks.mu.Unlock()
+}
+
+// Rotate adds a new signing key and retires the rest of the keys
+// after the "gracePeriod". The retired keys are not used to sign tokens
+// but they can still verify tokens until the grace period ends.
+// The "gracePeriod" should be at least the max age of the signed tokens.
+func (ks *KeySet) Rotate(kid string, alg Alg, publicKey PublicKey, privateKey PrivateKey, gracePeriod time.Duration) *KeySet {
+	now := ks.Clock()
+	expiresAt := now.Add(gracePeriod)
+
+	ks.mu.Lock()
+	for kid, key := range ks.keys {
+		if key.ExpiresAt.IsZero() || key.ExpiresAt.After(expiresAt) {
+			// Replace with a copy, the key may be in use by Get and Keys callers.
+			retired := *key
+			retired.ExpiresAt = expiresAt
+			ks.keys[kid] = &retired
+		}
+	}
+	ks.mu.Unlock()
+
+	return ks.Add(kid, alg, publicKey, privateKey)
+}
+
+// Remove removes a key from the set immediately,
+// all tokens signed by this key are no longer valid.
+func (ks *KeySet) Remove(kid string) {
+	ks.mu.Lock()
+	delete(ks.keys, kid)
+	ks.mu.Unlock()
+}
+
+// Get returns a non-expired key by its "kid".
+func (ks *KeySet) Get(kid string) (*Key, bool) {
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+
+	if !ok || key.Expired(ks.Clock()) {
+		return nil, false
+	}
+
+	return key, true
+}
+
+// Keys returns the non-expired keys, newest first.
+// The returned keys must not be modified.
+// The expired keys are removed from the set.
+func (ks *KeySet) Keys() []*Key {
+	now := ks.Clock()
+
+	ks.mu.Lock()
+	keys := make([]*Key, 0, len(ks.keys))
+	for kid, key := range ks.keys {
+		if key.Expired(now) {
+			delete(ks.keys, kid)
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+	ks.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys
+}
+
+// SigningKey returns the newest non-expired key which has a private key.
+func (ks *KeySet) SigningKey() (*Key, bool) {
+	for _, key := range ks.Keys() {
+		if key.Private != nil {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// Sign generates a new token based on the given "claims", signed by the SigningKey.
+// The token's header contains the key's "kid".
+func (ks *KeySet) Sign(claims any, opts ...SignOption) ([]byte, error) {
+	return ks.SignEncrypted(nil, claims, opts...)
+}
+
+// SignEncrypted same as Sign but it encrypts the payload with the "encrypt" function, if not nil.
+func (ks *KeySet) SignEncrypted(encrypt func([]byte) ([]byte, error), claims any, opts ...SignOption) ([]byte, error) {
+	key, ok := ks.SigningKey()
+	if !ok {
+		return nil, ErrNoSigningKey
+	}
+
+	header := jwt.HeaderWithKid{
+		Kid: key.ID,
+		Alg: key.Alg.Name(),
+	}
+
+	return jwt.SignEncryptedWithHeader(key.Alg, key.Private, encrypt, claims, header, opts...)
+}
+
+// ValidateHeader completes the HeaderValidator,
+// it resolves the verification key of a token by its "kid" header.
+// See the NewKeySetVerifier package-level function.
+func (ks *KeySet) ValidateHeader(alg string, headerDecoded []byte) (Alg, PublicKey, jwt.InjectFunc, error) {
+	return validateHeader(alg, headerDecoded, ks.Get)
+}
+
+// JWKS returns the JSON Web Key Set of the non-expired public keys.
+// The symmetric (HMAC) keys are never published.
+func (ks *KeySet) JWKS() (*JWKS, error) {
+	keys := ks.Keys()
+
+	sets := make([]*jwt.JWK, 0, len(keys))
+	for _, key := range keys {
+		if _, symmetric := key.Public.([]byte); symmetric || key.Public == nil {
+			continue
+		}
+
+		jwk, err := jwt.GenerateJWK(key.ID, key.Alg.Name(), key.Public)
+		if err != nil {
+			return nil, err
+		}
+
+		sets = append(sets, jwk)
+	}
+
+	return &JWKS{Keys: sets}, nil
+}
+
+// JWKSHandler sends the JSON Web Key Set of the public keys.
+// Register it on the JWKSPath, e.g. app.Get(jwt.JWKSPath, keys.JWKSHandler).
+func (ks *KeySet) JWKSHandler(ctx *context.Context) {
+	jwks, err := ks.JWKS()
+	if err != nil {
+		ctx.StopWithError(500, context.PrivateError(err))
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(jwks)
+}
+
+func validateHeader(alg string, headerDecoded []byte, get func(kid string) (*Key, bool)) (Alg, PublicKey, jwt.InjectFunc, error) {
+	var h jwt.HeaderWithKid
+	if err := jwt.Unmarshal(headerDecoded, &h); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if h.Kid == "" {
+		return nil, nil, nil, ErrEmptyKid
+	}
+
+	key, ok := get(h.Kid)
+	if !ok {
+		return nil, nil, nil, ErrUnknownKid
+	}
+
+	if h.Alg != key.Alg.Name() || (alg != "" && alg != h.Alg) {
+		return nil, nil, nil, ErrTokenAlg
+	}
+
+	return key.Alg, key.Public, nil, nil
+}
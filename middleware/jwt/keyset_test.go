@@ -0,0 +1,136 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	stdhttptest "net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/jwt"
+)
+
+func TestKeySet(t *testing.T) {
+	newKey := func() (ed25519.PublicKey, ed25519.PrivateKey) {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return publicKey, privateKey
+	}
+
+	publicKey, privateKey := newKey()
+	keys := jwt.NewKeySet().Add("key-1", jwt.EdDSA, publicKey, privateKey)
+	signer := jwt.NewKeySetSigner(keys, time.Minute)
+
+	// The issuer publishes its public keys.
+	var fetches uint32
+	issuer := iris.New()
+	issuer.Get(jwt.JWKSPath, func(ctx iris.Context) {
+		atomic.AddUint32(&fetches, 1)
+		ctx.Next()
+	}, keys.JWKSHandler)
+	if err := issuer.Build(); err != nil {
+		t.Fatal(err)
+	}
+	issuerServer := stdhttptest.NewServer(issuer)
+	defer issuerServer.Close()
+
+	remoteKeys := jwt.NewRemoteKeySet(issuerServer.URL + jwt.JWKSPath)
+	remoteKeys.MinRefreshInterval = 0
+
+	app := iris.New()
+	claimsType := func() any { return new(fooClaims) }
+	handler := func(ctx iris.Context) {
+		ctx.WriteString(jwt.Get(ctx).(*fooClaims).Foo)
+	}
+	app.Get("/local", jwt.NewKeySetVerifier(keys.ValidateHeader).Verify(claimsType), handler)
+	app.Get("/remote", jwt.NewKeySetVerifier(remoteKeys.ValidateHeader).Verify(claimsType), handler)
+
+	e := httptest.New(t, app)
+
+	expect := func(path string, token []byte, expectedStatus int) {
+		e.GET(path).WithHeader("Authorization", "Bearer "+string(token)).Expect().Status(expectedStatus)
+	}
+
+	oldToken, err := signer.Sign(fooClaims{Foo: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect("/local", oldToken, iris.StatusOK)
+	expect("/remote", oldToken, iris.StatusOK)
+	expect("/remote", oldToken, iris.StatusOK)
+	if n := atomic.LoadUint32(&fetches); n != 1 {
+		t.Fatalf("expected the remote keys to be fetched once but fetched %d times", n)
+	}
+
+	// Rotate, the previous key can still verify tokens during the grace period.
+	publicKey, privateKey = newKey()
+	keys.Rotate("key-2", jwt.EdDSA, publicKey, privateKey, time.Minute)
+
+	newToken, err := signer.Sign(fooClaims{Foo: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect("/local", oldToken, iris.StatusOK)
+	expect("/local", newToken, iris.StatusOK)
+	expect("/remote", oldToken, iris.StatusOK)
+	// Unknown kid refreshes the remote keys.
+	expect("/remote", newToken, iris.StatusOK)
+	if n := atomic.LoadUint32(&fetches); n != 2 {
+		t.Fatalf("expected the remote keys to be fetched twice but fetched %d times", n)
+	}
+
+	jwks := httptest.New(t, issuer).GET(jwt.JWKSPath).Expect().Status(iris.StatusOK).JSON().Object()
+	jwks.Value("keys").Array().Length().IsEqual(2)
+
+	// Expire the previous key.
+	keys.Clock = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	expect("/local", oldToken, iris.StatusUnauthorized)
+	jwks = httptest.New(t, issuer).GET(jwt.JWKSPath).Expect().Status(iris.StatusOK).JSON().Object()
+	jwks.Value("keys").Array().Length().IsEqual(1)
+	jwks.Value("keys").Array().Value(0).Object().Value("kid").IsEqual("key-2")
+
+	// Tokens without a known kid are rejected.
+	staticToken, err := jwt.Sign(jwt.EdDSA, privateKey, fooClaims{Foo: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect("/local", staticToken, iris.StatusUnauthorized)
+	expect("/remote", staticToken, iris.StatusUnauthorized)
+}
+
+func TestKeySetConcurrentRotate(t *testing.T) {
+	keys := jwt.NewKeySet().Add("key-0", jwt.HS256, "secret-0", "secret-0")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 100; i++ {
+			keys.Rotate("key-"+strconv.Itoa(i), jwt.HS256, "secret", "secret", time.Hour)
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		if key, ok := keys.Get("key-0"); !ok || key.Expired(time.Now()) {
+			t.Fatalf("expected key-0 to be valid during the grace period")
+		}
+
+		for _, key := range keys.Keys() {
+			if key.Expired(time.Now()) {
+				t.Fatalf("expected %s to be valid during the grace period", key.ID)
+			}
+		}
+	}
+	<-done
+
+	key, ok := keys.Get("key-0")
+	if !ok || key.ExpiresAt.IsZero() {
+		t.Fatalf("expected key-0 to be retired")
+	}
+}
@@ -0,0 +1,114 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kataras/jwt"
+)
+
+// RemoteKeySet is a verify-only key set which fetches the public keys
+// from a remote JSON Web Key Set endpoint, e.g. "https://issuer/.well-known/jwks.json".
+// The keys are cached for CacheDuration and they are fetched again,
+// at most once per MinRefreshInterval, when a token contains an unknown "kid",
+// so the remote issuer can rotate its keys at any time.
+// It's safe for concurrent use.
+//
+// Usage:
+//
+//	keys := jwt.NewRemoteKeySet("https://issuer/.well-known/jwks.json")
+//	verifier := jwt.NewKeySetVerifier(keys.ValidateHeader)
+type RemoteKeySet struct {
+	// URL is the remote JSON Web Key Set endpoint.
+	URL string
+	// HTTPClient is used to fetch the keys.
+	// Defaults to the http.DefaultClient.
+	HTTPClient jwt.HTTPClient
+	// CacheDuration is the duration the fetched keys are cached.
+	// Defaults to 1 hour.
+	CacheDuration time.Duration
+	// MinRefreshInterval is the minimum duration between two fetches
+	// caused by unknown key identifiers, it protects the remote endpoint
+	// from tokens with random "kid" values.
+	// Defaults to 1 minute.
+	MinRefreshInterval time.Duration
+	// Clock defaults to time.Now.
+	Clock func() time.Time
+
+	keys      jwt.Keys
+	fetchedAt time.Time
+	mu        sync.RWMutex
+
+	attemptedAt time.Time
+	fetchMu     sync.Mutex
+}
+
+// NewRemoteKeySet returns a new RemoteKeySet which fetches the keys from the given "url".
+// The keys are fetched lazily, on the first token verification,
+// call its Fetch method to fetch them on startup instead.
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{
+		URL:                url,
+		CacheDuration:      time.Hour,
+		MinRefreshInterval: time.Minute,
+		Clock:              time.Now,
+	}
+}
+
+// Fetch fetches the keys from the remote endpoint and replaces the cached ones.
+func (ks *RemoteKeySet) Fetch() error {
+	set, err := jwt.FetchJWKS(ks.HTTPClient, ks.URL)
+	if err != nil {
+		return err
+	}
+
+	keys := set.PublicKeys()
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.fetchedAt = ks.Clock()
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// Get returns a key by its "kid". It fetches the remote keys
+// when the cache is expired or the "kid" is unknown.
+func (ks *RemoteKeySet) Get(kid string) (*Key, bool) {
+	now := ks.Clock()
+
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	fetchedAt := ks.fetchedAt
+	ks.mu.RUnlock()
+
+	if ok && now.Sub(fetchedAt) < ks.CacheDuration {
+		return &Key{ID: key.ID, Alg: key.Alg, Public: key.Public}, true
+	}
+
+	// Only one fetch at a time and at most once per MinRefreshInterval,
+	// the cached keys are kept if the remote endpoint is not available.
+	ks.fetchMu.Lock()
+	if now.Sub(ks.attemptedAt) >= ks.MinRefreshInterval {
+		ks.attemptedAt = now
+		_ = ks.Fetch()
+	}
+	ks.fetchMu.Unlock()
+
+	ks.mu.RLock()
+	key, ok = ks.keys[kid]
+	ks.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	return &Key{ID: key.ID, Alg: key.Alg, Public: key.Public}, true
+}
+
+// ValidateHeader completes the HeaderValidator,
+// it resolves the verification key of a token by its "kid" header.
+// See the NewKeySetVerifier package-level function.
+func (ks *RemoteKeySet) ValidateHeader(alg string, headerDecoded []byte) (Alg, PublicKey, jwt.InjectFunc, error) {
+	return validateHeader(alg, headerDecoded, ks.Get)
+}
@@ -11,10 +11,13 @@ import (
 // Its Sign method can be used to generate a token which can be sent to the client.
 // Its NewTokenPair can be used to construct a token pair (access_token, refresh_token).
 //
-// It does not support JWE, JWK.
+// It does not support JWE. Use a KeySet to sign with rotating keys (see NewKeySetSigner).
 type Signer struct {
 	Alg Alg
 	Key any
+	// KeySet, if not nil, is used to sign the tokens with its newest key
+	// instead of the Alg and Key fields.
+	KeySet *KeySet
 
 	// MaxAge to set "exp" and "iat".
 	// Recommended value for access tokens: 15 minutes.
@@ -54,6 +57,27 @@ func NewSigner(signatureAlg Alg, signatureKey any, maxAge time.Duration) *Signer
 	return s
 }
 
+// NewKeySetSigner same as NewSigner but it signs the tokens
+// with the newest key of the given KeySet, the "kid" header is set to the key's ID.
+// See KeySet type and NewKeySetVerifier package-level function.
+//
+// Usage:
+//
+//	keys := NewKeySet().Add("2024-01", EdDSA, publicKey, privateKey)
+//	signer := NewKeySetSigner(keys, 15*time.Minute)
+func NewKeySetSigner(keys *KeySet, maxAge time.Duration) *Signer {
+	s := &Signer{
+		KeySet: keys,
+		MaxAge: maxAge,
+	}
+
+	if maxAge > 0 {
+		s.Options = []SignOption{MaxAge(maxAge)}
+	}
+
+	return s
+}
+
 // WithEncryption enables AES-GCM payload-only decryption.
 func (s *Signer) WithEncryption(key, additionalData []byte) *Signer {
 	encrypt, _, err := jwt.GCM(key, additionalData)
@@ -73,6 +97,10 @@ func (s *Signer) Sign(claims any, opts ...SignOption) ([]byte, error) {
 		opts = s.Options
 	}
 
+	if s.KeySet != nil {
+		return s.KeySet.SignEncrypted(s.Encrypt, claims, opts...)
+	}
+
 	return SignEncrypted(s.Alg, s.Key, s.Encrypt, claims, opts...)
 }
 
@@ -89,7 +117,12 @@ func (s *Signer) NewTokenPair(accessClaims any, refreshClaims any, refreshMaxAge
 		return TokenPair{}, err
 	}
 
-	refreshToken, err := Sign(s.Alg, s.Key, refreshClaims, MaxAge(refreshMaxAge))
+	var refreshToken []byte
+	if s.KeySet != nil {
+		refreshToken, err = s.KeySet.Sign(refreshClaims, MaxAge(refreshMaxAge))
+	} else {
+		refreshToken, err = Sign(s.Alg, s.Key, refreshClaims, MaxAge(refreshMaxAge))
+	}
 	if err != nil {
 		return TokenPair{}, err
 	}
@@ -39,10 +39,14 @@ func GetVerifiedToken(ctx *context.Context) *VerifiedToken {
 // Verifier holds common options to verify an incoming token.
 // Its Verify method can be used as a middleware to allow authorized clients to access an API.
 //
-// It does not support JWE, JWK.
+// It does not support JWE. Use a KeySet or a RemoteKeySet to verify
+// tokens signed with rotating keys (see NewKeySetVerifier).
 type Verifier struct {
 	Alg Alg
 	Key any
+	// HeaderValidator, if not nil, resolves the algorithm and the key of
+	// each token by its header (e.g. the "kid") instead of the Alg and Key fields.
+	HeaderValidator HeaderValidator
 
 	Decrypt func([]byte) ([]byte, error)
 
@@ -105,6 +109,24 @@ func NewVerifier(signatureAlg Alg, signatureKey any, validators ...TokenValidato
 	}
 }
 
+// NewKeySetVerifier same as NewVerifier but it verifies the tokens
+// with the key which matches their "kid" header.
+// The "validateHeader" is the ValidateHeader method of a KeySet or a RemoteKeySet.
+//
+// Usage:
+//
+//	verifier := NewKeySetVerifier(keys.ValidateHeader)
+//
+// OR
+//
+//	remoteKeys := NewRemoteKeySet("https://issuer/.well-known/jwks.json")
+//	verifier := NewKeySetVerifier(remoteKeys.ValidateHeader, Expected{Issuer: "https://issuer"})
+func NewKeySetVerifier(validateHeader HeaderValidator, validators ...TokenValidator) *Verifier {
+	v := NewVerifier(nil, nil, validators...)
+	v.HeaderValidator = validateHeader
+	return v
+}
+
 // WithDecryption enables AES-GCM payload-only encryption.
 func (v *Verifier) WithDecryption(key, additionalData []byte) *Verifier {
 	_, decrypt, err := jwt.GCM(key, additionalData)
@@ -163,6 +185,10 @@ type (
 // VerifyToken simply verifies the given "token" and validates its standard claims (such as expiration).
 // Returns a structure which holds the token's information. See the Verify method instead.
 func (v *Verifier) VerifyToken(token []byte, validators ...TokenValidator) (*VerifiedToken, error) {
+	if v.HeaderValidator != nil {
+		return jwt.VerifyEncryptedWithHeaderValidator(nil, nil, v.Decrypt, token, v.HeaderValidator, validators...)
+	}
+
 	return jwt.VerifyEncrypted(v.Alg, v.Key, v.Decrypt, token, validators...)
 }
 
@@ -0,0 +1,175 @@
+// Package metrics implements a middleware which records HTTP request metrics
+// and exposes them, along with any custom ones, in the Prometheus text exposition format,
+// without depending on the Prometheus client library.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/metrics.*", "iris.metrics")
+}
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// UnmatchedRoute is the value of the "route" label for requests which did not match any route.
+const UnmatchedRoute = "unmatched"
+
+var (
+	// DefaultBuckets are the default request duration buckets, in seconds.
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the default response size buckets, in bytes.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Options holds the configuration for the metrics middleware.
+type Options struct {
+	// Namespace is the prefix of the metric names.
+	// Defaults to "iris".
+	Namespace string
+	// Buckets are the request duration histogram buckets, in seconds.
+	// Defaults to the DefaultBuckets.
+	Buckets []float64
+	// SizeBuckets are the response size histogram buckets, in bytes.
+	// Defaults to the DefaultSizeBuckets.
+	SizeBuckets []float64
+	// Registry is the registry of the metrics.
+	// Defaults to a new one, use it to register custom metrics.
+	Registry *Registry
+	// Skip, if not nil, reports whether a request should not be recorded,
+	// e.g. the requests to the metrics route itself.
+	Skip func(ctx *context.Context) bool
+}
+
+// Metrics records the request metrics:
+//   - {namespace}_http_requests_total counter
+//   - {namespace}_http_request_duration_seconds histogram
+//   - {namespace}_http_response_size_bytes histogram
+//   - {namespace}_http_requests_in_flight gauge
+//
+// The first three are labelled by "method", "status" (the status code class, e.g. "2xx")
+// and "route", which is the route's registered path template, e.g. "/users/{id:uint64}",
+// so the labels cardinality is bounded.
+//
+// Initialize with the `New` package-level function.
+type Metrics struct {
+	opts Options
+
+	// Registry holds the metrics, register custom ones through its methods.
+	Registry *Registry
+
+	requests *Counter
+	duration *Histogram
+	size     *Histogram
+	inFlight *Gauge
+}
+
+// New returns a new Metrics.
+// Register its Handler through Application.UseRouter, so the not found requests are recorded too,
+// and its Expose handler to a route.
+//
+// Example Code:
+//
+//	m := metrics.New(metrics.Options{})
+//	jobs := m.Registry.NewCounter("jobs_processed_total", "The number of processed jobs.", "queue")
+//	app.UseRouter(m.Handler)
+//	app.Get("/metrics", m.Expose)
+//	// [...]
+//	jobs.Inc("emails")
+func New(opts Options) *Metrics {
+	if opts.Namespace == "" {
+		opts.Namespace = "iris"
+	}
+
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = DefaultBuckets
+	}
+
+	if len(opts.SizeBuckets) == 0 {
+		opts.SizeBuckets = DefaultSizeBuckets
+	}
+
+	if opts.Registry == nil {
+		opts.Registry = NewRegistry()
+	}
+
+	r := opts.Registry
+	prefix := opts.Namespace + "_http_"
+	return &Metrics{
+		opts:     opts,
+		Registry: r,
+		requests: r.NewCounter(prefix+"requests_total",
+			"The total number of HTTP requests.", "method", "status", "route"),
+		duration: r.NewHistogram(prefix+"request_duration_seconds",
+			"The HTTP request latencies in seconds.", opts.Buckets, "method", "status", "route"),
+		size: r.NewHistogram(prefix+"response_size_bytes",
+			"The HTTP response sizes in bytes.", opts.SizeBuckets, "method", "status", "route"),
+		inFlight: r.NewGauge(prefix+"requests_in_flight",
+			"The number of HTTP requests currently being served."),
+	}
+}
+
+// Handler is the metrics middleware, it records the request
+// after the execution of the next handlers.
+func (m *Metrics) Handler(ctx *context.Context) {
+	if m.opts.Skip != nil && m.opts.Skip(ctx) {
+		ctx.Next()
+		return
+	}
+
+	start := time.Now()
+	m.inFlight.Inc()
+	defer m.inFlight.Dec()
+
+	ctx.Next()
+
+	route := UnmatchedRoute
+	if r := ctx.GetCurrentRoute(); r != nil {
+		route = r.Path()
+	}
+
+	method := normalizeMethod(ctx.Method())
+	status := statusClass(ctx.GetStatusCode())
+
+	size := ctx.ResponseWriter().Written()
+	if size < 0 {
+		size = 0
+	}
+
+	m.requests.Inc(method, status, route)
+	m.duration.Observe(time.Since(start).Seconds(), method, status, route)
+	m.size.Observe(float64(size), method, status, route)
+}
+
+// Expose is a handler which writes the metrics of the Registry
+// in the Prometheus text exposition format.
+func (m *Metrics) Expose(ctx *context.Context) {
+	ctx.ContentType(ContentType)
+	if _, err := m.Registry.WriteTo(ctx); err != nil {
+		ctx.Application().Logger().Errorf("metrics: %v", err)
+	}
+}
+
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(code/100) + "xx"
+}
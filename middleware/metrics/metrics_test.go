@@ -0,0 +1,120 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New(metrics.Options{
+		Skip: func(ctx iris.Context) bool {
+			return ctx.Path() == "/metrics"
+		},
+	})
+	jobs := m.Registry.NewCounter("jobs_processed_total", "The number of processed jobs.", "queue")
+
+	app := iris.New()
+	app.UseRouter(m.Handler)
+	app.Get("/users/{id:uint64}", func(ctx iris.Context) {
+		jobs.Inc("emails")
+		ctx.WriteString("user")
+	})
+	app.Post("/users", func(ctx iris.Context) {
+		ctx.StopWithStatus(iris.StatusBadRequest)
+	})
+	app.Get("/metrics", m.Expose)
+
+	e := httptest.New(t, app)
+	e.GET("/users/1").Expect().Status(httptest.StatusOK)
+	e.GET("/users/2").Expect().Status(httptest.StatusOK)
+	e.POST("/users").Expect().Status(httptest.StatusBadRequest)
+	e.GET("/missing").Expect().Status(httptest.StatusNotFound)
+
+	body := e.GET("/metrics").Expect().Status(httptest.StatusOK).
+		HasContentType("text/plain", "utf-8").Body().Raw()
+
+	for _, expected := range []string{
+		`# HELP iris_http_requests_total The total number of HTTP requests.`,
+		`# TYPE iris_http_requests_total counter`,
+		`iris_http_requests_total{method="GET",status="2xx",route="/users/{id:uint64}"} 2`,
+		`iris_http_requests_total{method="POST",status="4xx",route="/users"} 1`,
+		`iris_http_requests_total{method="GET",status="4xx",route="unmatched"} 1`,
+		`# TYPE iris_http_request_duration_seconds histogram`,
+		`iris_http_request_duration_seconds_bucket{method="GET",status="2xx",route="/users/{id:uint64}",le="+Inf"} 2`,
+		`iris_http_request_duration_seconds_count{method="GET",status="2xx",route="/users/{id:uint64}"} 2`,
+		`iris_http_response_size_bytes_bucket{method="GET",status="2xx",route="/users/{id:uint64}",le="100"} 2`,
+		`iris_http_response_size_bytes_sum{method="GET",status="2xx",route="/users/{id:uint64}"} 8`,
+		`iris_http_requests_in_flight 0`,
+		`jobs_processed_total{queue="emails"} 2`,
+	} {
+		if !strings.Contains(body, expected+"\n") {
+			t.Fatalf("expected metrics to contain:\n%s\nbut got:\n%s", expected, body)
+		}
+	}
+
+	if strings.Contains(body, `route="/metrics"`) {
+		t.Fatalf("expected the metrics route to be skipped but got:\n%s", body)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounter("events_total", "Events\nwith \\ help.", "kind")
+	g := r.NewGauge("temperature", "The temperature.")
+	h := r.NewHistogram("sizes", "Sizes.", []float64{1, 10})
+	r.NewGaugeFunc("answer", "The answer.", func() float64 { return 42 })
+
+	c.Add(2, `quoted "value"`+"\n")
+	g.Set(-1.5)
+	h.Observe(1)
+	h.Observe(5)
+	h.Observe(100)
+
+	expected := `# HELP answer The answer.
+# TYPE answer gauge
+answer 42
+# HELP events_total Events\nwith \\ help.
+# TYPE events_total counter
+events_total{kind="quoted \"value\"\n"} 2
+# HELP sizes Sizes.
+# TYPE sizes histogram
+sizes_bucket{le="1"} 1
+sizes_bucket{le="10"} 2
+sizes_bucket{le="+Inf"} 3
+sizes_sum 106
+sizes_count 3
+# HELP temperature The temperature.
+# TYPE temperature gauge
+temperature -1.5
+`
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b.String(); got != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+
+	for _, register := range []func(){
+		func() { r.NewCounter("events_total", "") },         // already registered.
+		func() { r.NewCounter("invalid-name", "") },         // invalid name.
+		func() { r.NewGauge("valid_name", "", "le") },       // reserved label.
+		func() { r.NewHistogram("h", "", []float64{2, 1}) }, // unsorted buckets.
+		func() { c.Inc() }, // missing label value.
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected a panic")
+				}
+			}()
+			register()
+		}()
+	}
+}
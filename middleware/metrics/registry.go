@@ -0,0 +1,449 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds the metrics and writes them in the Prometheus text exposition format.
+// Initialize with the `NewRegistry` package-level function.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]collector
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]collector)}
+}
+
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+func (r *Registry) register(c collector, labelNames []string) {
+	if !metricNameRegex.MatchString(c.name()) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", c.name()))
+	}
+
+	for _, labelName := range labelNames {
+		if !labelNameRegex.MatchString(labelName) || strings.HasPrefix(labelName, "__") || labelName == "le" {
+			panic(fmt.Sprintf("metrics: %s: invalid label name %q", c.name(), labelName))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.metrics[c.name()]; exists {
+		panic(fmt.Sprintf("metrics: %s: already registered", c.name()))
+	}
+
+	r.metrics[c.name()] = c
+}
+
+// WriteTo writes all the metrics, sorted by name, in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	collectors := make([]collector, 0, len(r.metrics))
+	for _, c := range r.metrics {
+		collectors = append(collectors, c)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name() < collectors[j].name()
+	})
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// desc holds the common fields of the metrics.
+type desc struct {
+	metricName string
+	help       string
+	typ        string
+	labelNames []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	w.WriteString("# HELP ")
+	w.WriteString(d.metricName)
+	w.WriteByte(' ')
+	w.WriteString(helpReplacer.Replace(d.help))
+	w.WriteString("\n# TYPE ")
+	w.WriteString(d.metricName)
+	w.WriteByte(' ')
+	w.WriteString(d.typ)
+	w.WriteByte('\n')
+}
+
+// writeSample writes a "name{labels} value" line,
+// the "extraName" and "extraValue" is an extra label, e.g. the histogram's "le".
+func (d *desc) writeSample(w *bufio.Writer, name string, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+
+	if len(labelValues) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, labelValue := range labelValues {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, d.labelNames[i], labelValue)
+		}
+
+		if extraName != "" {
+			if len(labelValues) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func writeLabel(w *bufio.Writer, name, value string) {
+	w.WriteString(name)
+	w.WriteString(`="`)
+	w.WriteString(labelValueReplacer.Replace(value))
+	w.WriteByte('"')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// series holds the label values of a metric's time series.
+type series struct {
+	labelValues []string
+}
+
+// vec holds the time series of a metric by their label values.
+type vec[T any] struct {
+	desc
+
+	mu     sync.RWMutex
+	series map[string]*T
+	newT   func(labelValues []string) *T
+}
+
+func (v *vec[T]) get(labelValues []string) *T {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s: expected %d label values but got %d",
+			v.metricName, len(v.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if s, ok = v.series[key]; !ok {
+		s = v.newT(append([]string(nil), labelValues...))
+		v.series[key] = s
+	}
+
+	return s
+}
+
+// sorted returns the time series sorted by their label values.
+func (v *vec[T]) sorted() []*T {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := make([]*T, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, v.series[key])
+	}
+	v.mu.RUnlock()
+
+	return list
+}
+
+// atomicFloat is a float64 which can be updated atomically.
+// The atomic.Uint64 is 64-bit aligned on 32-bit platforms too.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+type valueSeries struct {
+	series
+	value atomicFloat
+}
+
+// Counter is a metric which only goes up, e.g. the number of served requests.
+// Create with the `Registry.NewCounter` method.
+type Counter struct {
+	vec[valueSeries]
+}
+
+// NewCounter registers and returns a new Counter.
+// The "labelNames" are the names of the labels,
+// each Inc and Add call should pass the same number of label values.
+// It panics on invalid or already registered names.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{vec: newValueVec(name, help, "counter", labelNames)}
+	r.register(c, labelNames)
+	return c
+}
+
+func newValueVec(name, help, typ string, labelNames []string) vec[valueSeries] {
+	return vec[valueSeries]{
+		desc:   desc{metricName: name, help: help, typ: typ, labelNames: labelNames},
+		series: make(map[string]*valueSeries),
+		newT: func(labelValues []string) *valueSeries {
+			return &valueSeries{series: series{labelValues: labelValues}}
+		},
+	}
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds the "delta" to the counter. It panics if "delta" is negative.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: %s: counter cannot decrease", c.metricName))
+	}
+
+	c.get(labelValues).value.add(delta)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.get(labelValues).value.load()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	for _, s := range c.sorted() {
+		c.writeSample(w, c.metricName, s.labelValues, "", "", s.value.load())
+	}
+}
+
+// Gauge is a metric which can go up and down, e.g. the number of in-flight requests.
+// Create with the `Registry.NewGauge` method.
+type Gauge struct {
+	vec[valueSeries]
+}
+
+// NewGauge registers and returns a new Gauge.
+// It panics on invalid or already registered names.
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{vec: newValueVec(name, help, "gauge", labelNames)}
+	r.register(g, labelNames)
+	return g
+}
+
+// Set sets the gauge's value.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.get(labelValues).value.set(value)
+}
+
+// Add adds the "delta", which can be negative, to the gauge.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.get(labelValues).value.add(delta)
+}
+
+// Inc increments the gauge by one.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec decrements the gauge by one.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.get(labelValues).value.load()
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	for _, s := range g.sorted() {
+		g.writeSample(w, g.metricName, s.labelValues, "", "", s.value.load())
+	}
+}
+
+type gaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc registers a gauge without labels which value is
+// the result of "fn" at the time of the exposition, e.g. the number of goroutines.
+// It panics on invalid or already registered names.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&gaugeFunc{desc: desc{metricName: name, help: help, typ: "gauge"}, fn: fn}, nil)
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	g.writeSample(w, g.metricName, nil, "", "", g.fn())
+}
+
+type histogramSeries struct {
+	series
+	counts []atomic.Uint64 // per bucket, not cumulative, the last one is the +Inf.
+	sum    atomicFloat
+}
+
+// Histogram samples observations, e.g. request durations,
+// and counts them in configurable buckets.
+// Create with the `Registry.NewHistogram` method.
+type Histogram struct {
+	vec[histogramSeries]
+	buckets []float64
+}
+
+// NewHistogram registers and returns a new Histogram.
+// The "buckets" are the upper inclusive bounds of the buckets,
+// the +Inf one is added automatically.
+// It panics on invalid or already registered names and on unsorted buckets.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s: buckets are not sorted", name))
+	}
+
+	buckets = append([]float64(nil), buckets...)
+	if n := len(buckets); n > 0 && math.IsInf(buckets[n-1], 1) {
+		buckets = buckets[:n-1]
+	}
+
+	h := &Histogram{
+		vec: vec[histogramSeries]{
+			desc:   desc{metricName: name, help: help, typ: "histogram", labelNames: labelNames},
+			series: make(map[string]*histogramSeries),
+			newT: func(labelValues []string) *histogramSeries {
+				return &histogramSeries{
+					series: series{labelValues: labelValues},
+					counts: make([]atomic.Uint64, len(buckets)+1),
+				}
+			},
+		},
+		buckets: buckets,
+	}
+	r.register(h, labelNames)
+	return h
+}
+
+// Observe adds a single observation to the histogram.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	s := h.get(labelValues)
+	i := sort.SearchFloat64s(h.buckets, value) // the first bucket >= value.
+	s.counts[i].Add(1)
+	s.sum.add(value)
+}
+
+// Count returns the number of the observations.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	var count uint64
+	counts := h.get(labelValues).counts
+	for i := range counts {
+		count += counts[i].Load()
+	}
+
+	return count
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.writeHeader(w)
+
+	for _, s := range h.sorted() {
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += s.counts[i].Load()
+			h.writeSample(w, h.metricName+"_bucket", s.labelValues, "le", formatFloat(upperBound), float64(cumulative))
+		}
+		cumulative += s.counts[len(h.buckets)].Load()
+		h.writeSample(w, h.metricName+"_bucket", s.labelValues, "le", "+Inf", float64(cumulative))
+		h.writeSample(w, h.metricName+"_sum", s.labelValues, "", "", s.sum.load())
+		h.writeSample(w, h.metricName+"_count", s.labelValues, "", "", float64(cumulative))
+	}
+}
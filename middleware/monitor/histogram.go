@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	histogramMin                = time.Microsecond
+	histogramBucketsPerDoubling = 8
+	// From 1µs up to 2^28µs (~4.5 minutes), larger values go to the last bucket.
+	histogramBuckets = 28*histogramBucketsPerDoubling + 1
+)
+
+// Histogram is a mergeable latency histogram with logarithmic buckets.
+// The estimated quantiles have a relative error of less than 5%.
+//
+// The zero value is ready to use. It's not safe for concurrent use.
+type Histogram struct {
+	counts [histogramBuckets]uint64
+	count  uint64
+	sum    time.Duration
+}
+
+func histogramBucket(d time.Duration) int {
+	if d <= histogramMin {
+		return 0
+	}
+
+	i := int(math.Ceil(math.Log2(float64(d)/float64(histogramMin)) * histogramBucketsPerDoubling))
+	if i >= histogramBuckets {
+		i = histogramBuckets - 1
+	}
+
+	return i
+}
+
+// histogramUpperBound returns the inclusive upper bound of the "i" bucket.
+func histogramUpperBound(i int) float64 {
+	return float64(histogramMin) * math.Exp2(float64(i)/histogramBucketsPerDoubling)
+}
+
+// Observe records a duration.
+func (h *Histogram) Observe(d time.Duration) {
+	h.counts[histogramBucket(d)]++
+	h.count++
+	h.sum += d
+}
+
+// Merge adds the observations of "other" to "h".
+func (h *Histogram) Merge(other *Histogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+
+	h.count += other.count
+	h.sum += other.sum
+}
+
+// Reset removes all the observations.
+func (h *Histogram) Reset() {
+	*h = Histogram{}
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Mean returns the average of the observations.
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	return h.sum / time.Duration(h.count)
+}
+
+// Quantile returns the estimated "q" quantile, e.g. 0.99 for the p99.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var cumulative uint64
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative < rank {
+			continue
+		}
+
+		if i == 0 {
+			return histogramMin
+		}
+
+		// The geometric middle of the bucket.
+		return time.Duration(math.Sqrt(histogramUpperBound(i-1) * histogramUpperBound(i)))
+	}
+
+	return time.Duration(histogramUpperBound(histogramBuckets - 1))
+}
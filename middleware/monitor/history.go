@@ -0,0 +1,229 @@
+package monitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sample is a point of the history time series.
+type Sample struct {
+	Time  time.Time `json:"time" yaml:"Time"`
+	Stats `yaml:",inline"`
+	// The number of requests and server errors (5xx) during the sample's interval.
+	Requests uint64 `json:"requests" yaml:"Requests"`
+	Errors   uint64 `json:"errors" yaml:"Errors"`
+}
+
+// RouteStats holds the statistics of a route.
+// The rates and latencies are calculated over the Options.RouteWindow,
+// the Requests and Errors are the totals since the monitor started.
+type RouteStats struct {
+	Method   string `json:"method" yaml:"Method"`
+	Route    string `json:"route" yaml:"Route"`
+	Requests uint64 `json:"requests" yaml:"Requests"`
+	Errors   uint64 `json:"errors" yaml:"Errors"`
+	// Rate is the requests per second.
+	Rate float64 `json:"rate" yaml:"Rate"`
+	// ErrorRate is the fraction of the requests which failed with a server error (5xx).
+	ErrorRate float64 `json:"error_rate" yaml:"ErrorRate"`
+	// The latency percentiles in milliseconds.
+	P50 float64 `json:"p50_ms" yaml:"P50"`
+	P95 float64 `json:"p95_ms" yaml:"P95"`
+	P99 float64 `json:"p99_ms" yaml:"P99"`
+}
+
+// maxRoutes is the maximum number of the tracked route keys (method and route),
+// the requests of any other route are only counted in the history samples.
+const maxRoutes = 1000
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type routeSlot struct {
+	requests uint64
+	errors   uint64
+	latency  Histogram
+}
+
+type routeRecorder struct {
+	requests uint64
+	errors   uint64
+	// A ring of the Options.RouteWindow, the current slot is the history's slot.
+	slots []routeSlot
+}
+
+// history holds the samples ring buffer and the per-route statistics.
+type history struct {
+	mu sync.Mutex
+
+	interval time.Duration
+	started  time.Time
+
+	samples []Sample
+	next    int
+	full    bool
+	// The requests and errors of the current interval.
+	requests uint64
+	errors   uint64
+
+	routes map[routeKey]*routeRecorder
+	slot   int
+	window int
+}
+
+func newHistory(size int, interval, routeWindow time.Duration) *history {
+	window := int(routeWindow / interval)
+	if window < 1 {
+		window = 1
+	}
+
+	return &history{
+		interval: interval,
+		started:  time.Now(),
+		samples:  make([]Sample, size),
+		routes:   make(map[routeKey]*routeRecorder),
+		window:   window,
+	}
+}
+
+// record records a finished request.
+func (h *history) record(method, route string, latency time.Duration, failed bool) {
+	key := routeKey{method: method, route: route}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests++
+	if failed {
+		h.errors++
+	}
+
+	r, ok := h.routes[key]
+	if !ok {
+		if len(h.routes) >= maxRoutes {
+			return
+		}
+
+		r = &routeRecorder{slots: make([]routeSlot, h.window)}
+		h.routes[key] = r
+	}
+
+	slot := &r.slots[h.slot]
+	slot.requests++
+	slot.latency.Observe(latency)
+	r.requests++
+	if failed {
+		slot.errors++
+		r.errors++
+	}
+}
+
+// tick stores a new sample and moves the routes window forward.
+func (h *history) tick(now time.Time, stats Stats) {
+	h.mu.Lock()
+	h.samples[h.next] = Sample{
+		Time:     now,
+		Stats:    stats,
+		Requests: h.requests,
+		Errors:   h.errors,
+	}
+	h.next++
+	if h.next == len(h.samples) {
+		h.next = 0
+		h.full = true
+	}
+	h.requests, h.errors = 0, 0
+
+	h.slot = (h.slot + 1) % h.window
+	for _, r := range h.routes {
+		s := &r.slots[h.slot]
+		s.requests, s.errors = 0, 0
+		s.latency.Reset()
+	}
+	h.mu.Unlock()
+}
+
+// last returns the samples of the last "d" duration, oldest first.
+// If "d" is zero or negative then all the samples are returned.
+func (h *history) last(d time.Duration) []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var ordered []Sample
+	if h.full {
+		ordered = append(ordered, h.samples[h.next:]...)
+	}
+	ordered = append(ordered, h.samples[:h.next]...)
+
+	if d > 0 && len(ordered) > 0 {
+		since := ordered[len(ordered)-1].Time.Add(-d)
+		i := sort.Search(len(ordered), func(i int) bool {
+			return !ordered[i].Time.Before(since)
+		})
+		ordered = ordered[i:]
+	}
+
+	return ordered
+}
+
+// routeStats returns the statistics of all routes, sorted by route and method.
+func (h *history) routeStats() []RouteStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The window may not be complete yet.
+	window := time.Duration(h.window) * h.interval
+	if elapsed := time.Since(h.started); elapsed < window {
+		window = elapsed
+	}
+	if window < h.interval {
+		window = h.interval
+	}
+	seconds := window.Seconds()
+
+	stats := make([]RouteStats, 0, len(h.routes))
+	for key, r := range h.routes {
+		var (
+			requests, errors uint64
+			latency          Histogram
+		)
+		for i := range r.slots {
+			requests += r.slots[i].requests
+			errors += r.slots[i].errors
+			latency.Merge(&r.slots[i].latency)
+		}
+
+		s := RouteStats{
+			Method:   key.method,
+			Route:    key.route,
+			Requests: r.requests,
+			Errors:   r.errors,
+			Rate:     float64(requests) / seconds,
+			P50:      milliseconds(latency.Quantile(0.50)),
+			P95:      milliseconds(latency.Quantile(0.95)),
+			P99:      milliseconds(latency.Quantile(0.99)),
+		}
+		if requests > 0 {
+			s.ErrorRate = float64(errors) / float64(requests)
+		}
+
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Route != stats[j].Route {
+			return stats[i].Route < stats[j].Route
+		}
+
+		return stats[i].Method < stats[j].Method
+	})
+
+	return stats
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
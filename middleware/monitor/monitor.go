@@ -3,7 +3,9 @@ package monitor
 import (
 	"bytes"
 	"fmt"
+	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/kataras/iris/v12/context"
@@ -26,17 +28,30 @@ type Options struct {
 	ViewAnimationInterval time.Duration `json:"view_animation_interval" yaml:"ViewAnimationInterval"`
 	// The title of the monitor HTML document.
 	ViewTitle string `json:"view_title" yaml:"ViewTitle"`
+
+	// The resolution of the history time series. Defaults to 1 second.
+	HistoryInterval time.Duration `json:"history_interval" yaml:"HistoryInterval"`
+	// The number of the history samples to keep. Defaults to 3600, the last hour at 1s resolution.
+	HistorySize int `json:"history_size" yaml:"HistorySize"`
+	// The period which the per-route request rate, error rate and latency percentiles
+	// are calculated over. Defaults to 1 minute.
+	RouteWindow time.Duration `json:"route_window" yaml:"RouteWindow"`
 }
 
-// Monitor tracks and renders the server's process and operating system statistics.
+// Monitor tracks and renders the server's process and operating system statistics,
+// their history and the per-route request statistics.
 //
-// Look its `Stats` and `View` methods.
+// Look its `Handler`, `Stats` and `View` methods.
 // Initialize with the `New` package-level function.
 type Monitor struct {
 	opts   Options
 	Holder *StatsHolder
 
 	viewBody []byte
+
+	history  *history
+	stopOnce sync.Once
+	closeCh  chan struct{}
 }
 
 // New returns a new Monitor.
@@ -58,15 +73,27 @@ func New(opts Options) *Monitor {
 	}
 
 	if opts.RefreshInterval <= 0 {
-		opts.RefreshInterval = 2 * opts.RefreshInterval
+		opts.RefreshInterval = 2 * time.Second
 	}
 
 	if opts.ViewRefreshInterval <= 0 {
 		opts.ViewRefreshInterval = opts.RefreshInterval
 	}
 
+	if opts.HistoryInterval <= 0 {
+		opts.HistoryInterval = time.Second
+	}
+
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = 3600
+	}
+
+	if opts.RouteWindow <= 0 {
+		opts.RouteWindow = time.Minute
+	}
+
 	viewRefreshIntervalBytes := []byte(fmt.Sprintf("%d", opts.ViewRefreshInterval.Milliseconds()))
-	viewBody := bytes.Replace(defaultViewBody, viewRefreshIntervalTmplVar, viewRefreshIntervalBytes, 1)
+	viewBody := bytes.Replace(defaultViewBody, viewRefreshIntervalTmplVar, viewRefreshIntervalBytes, -1)
 	viewAnimationIntervalBytes := []byte(fmt.Sprintf("%d", opts.ViewAnimationInterval.Milliseconds()))
 	viewBody = bytes.Replace(viewBody, viewAnimationIntervalTmplVar, viewAnimationIntervalBytes, 2)
 	viewTitleBytes := []byte(opts.ViewTitle)
@@ -81,21 +108,106 @@ func New(opts Options) *Monitor {
 		opts:     opts,
 		Holder:   sh,
 		viewBody: viewBody,
+		history:  newHistory(opts.HistorySize, opts.HistoryInterval, opts.RouteWindow),
+		closeCh:  make(chan struct{}),
 	}
 
+	go m.recordHistory()
+
 	return m
 }
 
+func (m *Monitor) recordHistory() {
+	ticker := time.NewTicker(m.opts.HistoryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.closeCh:
+			return
+		case now := <-ticker.C:
+			m.history.tick(now, m.Holder.GetStats())
+		}
+	}
+}
+
 // Stop terminates the retrieve stats loop for
-// the process and the operating system statistics.
+// the process and the operating system statistics and the history recording.
 // No other monitor instance should be initialized after the first Stop call.
 func (m *Monitor) Stop() {
 	m.Holder.Stop()
+	m.stopOnce.Do(func() {
+		close(m.closeCh)
+	})
+}
+
+// UnmatchedRoute is the route name of the requests which did not match a route.
+const UnmatchedRoute = "unmatched"
+
+// Handler is a middleware which records the per-route request rate,
+// error rate (5xx) and latency percentiles.
+// Register it through Application.UseRouter to track all requests.
+// The non-standard HTTP methods are recorded as "OTHER".
+func (m *Monitor) Handler(ctx *context.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	route := UnmatchedRoute
+	if r := ctx.GetCurrentRoute(); r != nil {
+		route = r.Path()
+	}
+
+	m.history.record(normalizeMethod(ctx.Method()), route, time.Since(start), ctx.GetStatusCode() >= 500)
+}
+
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// History returns the samples of the last "d" duration, oldest first.
+// If "d" is zero then all the kept samples are returned.
+func (m *Monitor) History(d time.Duration) []Sample {
+	return m.history.last(d)
+}
+
+// Routes returns the statistics of the routes tracked by the `Handler`.
+func (m *Monitor) Routes() []RouteStats {
+	return m.history.routeStats()
+}
+
+// Snapshot is the response of the `Stats` handler.
+type Snapshot struct {
+	Stats   `yaml:",inline"`
+	Routes  []RouteStats `json:"routes" yaml:"Routes"`
+	History []Sample     `json:"history,omitempty" yaml:"History,omitempty"`
 }
 
-// Stats sends the stats as json.
+// Stats sends the current stats and the per-route stats as json.
+// The "history" URL query parameter, e.g. "?history=5m",
+// includes the samples of that last duration too.
 func (m *Monitor) Stats(ctx *context.Context) {
-	ctx.JSON(m.Holder.GetStats())
+	snapshot := Snapshot{
+		Stats:  m.Holder.GetStats(),
+		Routes: m.Routes(),
+	}
+
+	if v := ctx.URLParam("history"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			ctx.StopWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		snapshot.History = m.History(d)
+	}
+
+	ctx.JSON(snapshot)
 }
 
 // View renders a default view for the stats.
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestHistogram(t *testing.T) {
+	var a, b Histogram
+	for i := 1; i <= 100; i++ {
+		a.Observe(time.Duration(i) * time.Millisecond)
+	}
+	for i := 0; i < 100; i++ {
+		b.Observe(time.Second)
+	}
+
+	expectQuantile := func(h *Histogram, q float64, expected time.Duration) {
+		t.Helper()
+
+		got := h.Quantile(q)
+		if math.Abs(float64(got-expected)) > 0.05*float64(expected) {
+			t.Fatalf("expected quantile %v to be ~%s but got: %s", q, expected, got)
+		}
+	}
+
+	expectQuantile(&a, 0.50, 50*time.Millisecond)
+	expectQuantile(&a, 0.99, 99*time.Millisecond)
+
+	a.Merge(&b)
+	if expected, got := uint64(200), a.Count(); expected != got {
+		t.Fatalf("expected count: %d but got: %d", expected, got)
+	}
+	expectQuantile(&a, 0.25, 50*time.Millisecond)
+	expectQuantile(&a, 0.95, time.Second)
+
+	a.Reset()
+	if a.Count() != 0 || a.Quantile(0.5) != 0 {
+		t.Fatalf("expected an empty histogram")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := newHistory(3, time.Second, 3*time.Second)
+	now := time.Now()
+
+	h.record("GET", "/users/{id}", 10*time.Millisecond, false)
+	h.record("GET", "/users/{id}", 20*time.Millisecond, true)
+	h.tick(now, Stats{PIDConns: 1})
+	h.tick(now.Add(time.Second), Stats{PIDConns: 2})
+	h.record("GET", "/users/{id}", 30*time.Millisecond, false)
+	h.tick(now.Add(2*time.Second), Stats{PIDConns: 3})
+	// The first interval is out of the 3s window now.
+	h.record("POST", "/users", time.Millisecond, false)
+	h.tick(now.Add(3*time.Second), Stats{PIDConns: 4})
+
+	samples := h.last(0)
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples but got: %d", len(samples))
+	}
+	for i, s := range samples {
+		if expected := int64(i + 2); s.PIDConns != expected {
+			t.Fatalf("[%d] expected sample: %d but got: %d", i, expected, s.PIDConns)
+		}
+	}
+	if samples[0].Requests != 0 || samples[1].Requests != 1 || samples[2].Requests != 1 {
+		t.Fatalf("unexpected sample requests: %#+v", samples)
+	}
+
+	if n := len(h.last(time.Second)); n != 2 {
+		t.Fatalf("expected 2 samples of the last second but got: %d", n)
+	}
+
+	routes := h.routeStats()
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes but got: %d", len(routes))
+	}
+
+	users, user := routes[0], routes[1]
+	if users.Method != "POST" || users.Route != "/users" || users.Requests != 1 {
+		t.Fatalf("unexpected route stats: %#+v", users)
+	}
+	if user.Route != "/users/{id}" || user.Requests != 3 || user.Errors != 1 {
+		t.Fatalf("unexpected route stats: %#+v", user)
+	}
+	// Only the 30ms request is in the window.
+	if user.ErrorRate != 0 || math.Abs(user.P99-30) > 1.5 {
+		t.Fatalf("unexpected route window stats: %#+v", user)
+	}
+}
+
+func TestHistoryMaxRoutes(t *testing.T) {
+	h := newHistory(1, time.Second, time.Second)
+	for i := 0; i <= maxRoutes; i++ {
+		h.record("GET", "/"+strconv.Itoa(i), time.Millisecond, false)
+	}
+
+	if n := len(h.routeStats()); n != maxRoutes {
+		t.Fatalf("expected %d routes but got: %d", maxRoutes, n)
+	}
+
+	h.tick(time.Now(), Stats{})
+	if expected, got := uint64(maxRoutes+1), h.last(0)[0].Requests; expected != got {
+		t.Fatalf("expected %d requests but got: %d", expected, got)
+	}
+}
+
+func TestMonitorStats(t *testing.T) {
+	m := New(Options{HistoryInterval: time.Hour, RouteWindow: 2 * time.Hour})
+	defer m.Stop()
+
+	app := iris.New()
+	app.UseRouter(m.Handler)
+	app.Get("/users/{id:uint64}", func(ctx iris.Context) {
+		ctx.WriteString("user")
+	})
+	app.Get("/fail", func(ctx iris.Context) {
+		ctx.StatusCode(iris.StatusInternalServerError)
+	})
+	app.Post("/monitor", m.Stats)
+
+	e := httptest.New(t, app)
+	e.GET("/users/1").Expect().Status(httptest.StatusOK)
+	e.GET("/users/2").Expect().Status(httptest.StatusOK)
+	e.GET("/fail").Expect().Status(httptest.StatusInternalServerError)
+
+	m.history.tick(time.Now(), m.Holder.GetStats())
+
+	obj := e.POST("/monitor").WithQuery("history", "1m").Expect().Status(httptest.StatusOK).JSON().Object()
+	obj.ContainsKey("pid_cpu")
+	obj.Value("history").Array().Length().IsEqual(1)
+
+	routes := obj.Value("routes").Array()
+	routes.Length().IsEqual(2)
+	routes.Value(0).Object().HasValue("route", "/fail").HasValue("errors", 1).HasValue("error_rate", 1)
+	routes.Value(1).Object().HasValue("route", "/users/{id:uint64}").HasValue("requests", 2).HasValue("errors", 0)
+
+	e.POST("/monitor").WithQuery("history", "invalid").Expect().Status(httptest.StatusBadRequest)
+
+	// Non-standard methods share a single route key.
+	e.Request("FOO", "/unknown").Expect().Status(httptest.StatusNotFound)
+	e.Request("BAR", "/unknown").Expect().Status(httptest.StatusNotFound)
+
+	routes = e.POST("/monitor").Expect().Status(httptest.StatusOK).JSON().Object().Value("routes").Array()
+	routes.Length().IsEqual(4)
+	routes.Value(3).Object().HasValue("method", "OTHER").HasValue("route", UnmatchedRoute).HasValue("requests", 2)
+}
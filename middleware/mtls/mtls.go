@@ -0,0 +1,405 @@
+// Package mtls implements a mutual TLS middleware which verifies the client certificates
+// against a CA pool, checks their revocation through CRL files and
+// maps them to a context.User, available through the Context.User method.
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/host"
+	"github.com/kataras/iris/v12/middleware/internal/filewatch"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/mtls.*", "iris.mtls")
+}
+
+var (
+	// ErrMissingCertificate is fired when the client did not send a certificate
+	// and the Optional field is false.
+	ErrMissingCertificate = errors.New("mtls: missing client certificate")
+	// ErrInvalidCertificate is fired when the client certificate
+	// is not signed by the ClientCAs or it's expired.
+	ErrInvalidCertificate = errors.New("mtls: invalid client certificate")
+	// ErrRevokedCertificate is fired when the client certificate,
+	// or one of its intermediates, is listed in a CRL.
+	ErrRevokedCertificate = errors.New("mtls: revoked client certificate")
+)
+
+// Authorization is the value of the User.GetAuthorization method.
+const Authorization = "mTLS"
+
+// Options holds the configuration for the mutual TLS middleware.
+// The only required value is the ClientCAs (or CAFiles) field.
+type Options struct {
+	// ClientCAs is the pool of the certificate authorities
+	// which sign the client certificates.
+	ClientCAs *x509.CertPool
+	// CAFiles is a list of PEM files which are appended to the ClientCAs.
+	CAFiles []string
+	// Optional, if true, allows requests without a client certificate,
+	// a certificate is still verified if it's given.
+	// The next handlers should check the ctx.User() against nil.
+	Optional bool
+	// CRLFiles is a list of certificate revocation lists, PEM or DER encoded.
+	// A CRL is used only if it's signed by the issuer of the checked certificate.
+	CRLFiles []string
+	// ReloadEvery, if greater than zero, checks the CRLFiles
+	// for changes at most once per "ReloadEvery" duration and reloads them on change.
+	ReloadEvery time.Duration
+	// GetRoles returns the roles of the User from the verified certificate.
+	// Defaults to the subject's organizational units (OU).
+	GetRoles func(cert *x509.Certificate) []string
+	// ErrorHandler handles the verification failures,
+	// the "err" is one of the package-level errors.
+	//
+	// Defaults to the DefaultErrorHandler.
+	ErrorHandler func(ctx *context.Context, err error)
+}
+
+// DefaultErrorHandler is the default error handler.
+// It sends 401 on missing certificate and 403 otherwise.
+// The error messages are not sent to the client.
+func DefaultErrorHandler(ctx *context.Context, err error) {
+	if errors.Is(err, ErrMissingCertificate) {
+		ctx.StopWithError(http.StatusUnauthorized, context.PrivateError(err))
+		return
+	}
+
+	ctx.StopWithError(http.StatusForbidden, context.PrivateError(err))
+}
+
+// User is the context.User of a verified client certificate.
+// The ID is the certificate's serial number (hex), the Username is the subject's common name,
+// the Email is the first email address of the subject alternative names and
+// the Roles are the result of the GetRoles option.
+// The fields are the "subject", "issuer", "dns_names", "uris" and "ip_addresses".
+type User struct {
+	context.SimpleUser
+	// Certificate is the verified client certificate.
+	Certificate *x509.Certificate
+	// Chain is the verified chain, from the client certificate to the root CA.
+	Chain []*x509.Certificate
+}
+
+var _ context.User = (*User)(nil)
+
+// GetRaw returns itself.
+func (u *User) GetRaw() (any, error) {
+	return u, nil
+}
+
+// Get returns the User of a verified client certificate.
+// It returns nil if the request was not authenticated by this middleware.
+func Get(ctx *context.Context) *User {
+	if u, ok := ctx.User().(*User); ok {
+		return u
+	}
+
+	return nil
+}
+
+// MTLS is the mutual TLS middleware.
+// Initialize with the `New` package-level function.
+type MTLS struct {
+	opts Options
+
+	crls atomic.Pointer[map[string][]*x509.RevocationList] // by raw issuer.
+
+	mu      sync.Mutex // protects the file loading.
+	watcher *filewatch.Watcher
+}
+
+// New returns a new mutual TLS middleware. It panics on missing ClientCAs
+// or invalid CA and CRL files.
+//
+// The TLS server should request the client certificates,
+// register the Configure method as a host configurator to do so.
+//
+// Example Code:
+//
+//	m := mtls.New(mtls.Options{
+//		CAFiles:  []string{"ca.pem"},
+//		CRLFiles: []string{"ca.crl"},
+//	})
+//	app.Use(m.Handler)
+//	app.Get("/", func(ctx iris.Context) {
+//		username, _ := ctx.User().GetUsername()
+//		ctx.Writef("Hello, %s", username)
+//	})
+//	app.Run(iris.TLS(":443", "server.crt", "server.key", m.Configure))
+func New(opts Options) *MTLS {
+	if len(opts.CAFiles) > 0 {
+		if opts.ClientCAs == nil {
+			opts.ClientCAs = x509.NewCertPool()
+		}
+
+		for _, filename := range opts.CAFiles {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				panic(err)
+			}
+
+			if !opts.ClientCAs.AppendCertsFromPEM(data) {
+				panic(fmt.Sprintf("mtls: no certificates found in %s", filename))
+			}
+		}
+	}
+
+	if opts.ClientCAs == nil {
+		panic("mtls: ClientCAs field is required")
+	}
+
+	if opts.GetRoles == nil {
+		opts.GetRoles = func(cert *x509.Certificate) []string {
+			return cert.Subject.OrganizationalUnit
+		}
+	}
+
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+
+	m := &MTLS{
+		opts:    opts,
+		watcher: filewatch.New(opts.ReloadEvery, opts.CRLFiles...),
+	}
+	if err := m.Reload(); err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+// ConfigureTLS makes the "cfg" to request and verify the client certificates
+// signed by the ClientCAs. The handshake fails on invalid certificates,
+// and on missing ones when the Optional field is false.
+func (m *MTLS) ConfigureTLS(cfg *tls.Config) {
+	cfg.ClientCAs = m.opts.ClientCAs
+	if m.opts.Optional {
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	} else {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+}
+
+// Configure is a host configurator which calls ConfigureTLS
+// on the server's tls.Config, it creates a new one if it's missing.
+// Register it through the iris.TLS or the Application.ConfigureHost method.
+func (m *MTLS) Configure(su *host.Supervisor) {
+	if su.Server.TLSConfig == nil {
+		su.Server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	m.ConfigureTLS(su.Server.TLSConfig)
+}
+
+// Handler is the mutual TLS middleware.
+// It verifies the client certificate, sets the User and fires the next handler.
+// When the TLS handshake already verified the certificate, it's not verified twice,
+// however the revocation is always checked.
+func (m *MTLS) Handler(ctx *context.Context) {
+	if m.opts.ReloadEvery > 0 {
+		m.checkFiles(ctx)
+	}
+
+	user, err := m.Verify(ctx.Request().TLS)
+	if err != nil {
+		if errors.Is(err, ErrMissingCertificate) && m.opts.Optional {
+			ctx.Next()
+			return
+		}
+
+		m.opts.ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.SetUser(user)
+	ctx.Next()
+}
+
+// Verify verifies the client certificate of the connection state
+// and returns its User.
+func (m *MTLS) Verify(state *tls.ConnectionState) (*User, error) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil, ErrMissingCertificate
+	}
+
+	cert := state.PeerCertificates[0]
+
+	var chain []*x509.Certificate
+	if len(state.VerifiedChains) > 0 {
+		chain = state.VerifiedChains[0]
+	} else {
+		intermediates := x509.NewCertPool()
+		for _, c := range state.PeerCertificates[1:] {
+			intermediates.AddCert(c)
+		}
+
+		chains, err := cert.Verify(x509.VerifyOptions{
+			Roots:         m.opts.ClientCAs,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+
+		chain = chains[0]
+	}
+
+	if m.revoked(chain) {
+		return nil, ErrRevokedCertificate
+	}
+
+	return m.newUser(cert, chain), nil
+}
+
+func (m *MTLS) newUser(cert *x509.Certificate, chain []*x509.Certificate) *User {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+
+	ips := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	var email string
+	if len(cert.EmailAddresses) > 0 {
+		email = cert.EmailAddresses[0]
+	}
+
+	return &User{
+		SimpleUser: context.SimpleUser{
+			Authorization: Authorization,
+			AuthorizedAt:  time.Now(),
+			ID:            strings.ToUpper(cert.SerialNumber.Text(16)),
+			Username:      cert.Subject.CommonName,
+			Email:         email,
+			Roles:         m.opts.GetRoles(cert),
+			Fields: context.Map{
+				"subject":      cert.Subject.String(),
+				"issuer":       cert.Issuer.String(),
+				"dns_names":    cert.DNSNames,
+				"uris":         uris,
+				"ip_addresses": ips,
+			},
+		},
+		Certificate: cert,
+		Chain:       chain,
+	}
+}
+
+// revoked reports whether a certificate of the chain, except the root, is revoked.
+func (m *MTLS) revoked(chain []*x509.Certificate) bool {
+	crls := *m.crls.Load()
+	if len(crls) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(chain)-1; i++ {
+		cert, issuer := chain[i], chain[i+1]
+		for _, crl := range crls[string(cert.RawIssuer)] {
+			if crl.CheckSignatureFrom(issuer) != nil {
+				continue
+			}
+
+			for _, entry := range crl.RevokedCertificateEntries {
+				if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// Reload reloads the CRLFiles.
+// On error the previous revocation lists are kept.
+func (m *MTLS) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.load()
+}
+
+func (m *MTLS) load() error {
+	crls := make(map[string][]*x509.RevocationList)
+	for _, filename := range m.opts.CRLFiles {
+		list, err := readCRLFile(filename)
+		if err != nil {
+			return fmt.Errorf("mtls: %s: %w", filename, err)
+		}
+
+		for _, crl := range list {
+			crls[string(crl.RawIssuer)] = append(crls[string(crl.RawIssuer)], crl)
+		}
+	}
+
+	m.crls.Store(&crls)
+	return nil
+}
+
+// checkFiles reloads the CRL files if they were modified.
+func (m *MTLS) checkFiles(ctx *context.Context) {
+	if err := m.watcher.Check(m.Reload); err != nil {
+		// keep the previous revocation lists.
+		ctx.Application().Logger().Errorf("mtls: reload: %v", err)
+	}
+}
+
+// readCRLFile reads the revocation lists of a PEM file, which may contain more than one,
+// or a DER one.
+func readCRLFile(filename string) ([]*x509.RevocationList, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*x509.RevocationList
+
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "X509 CRL" {
+			continue
+		}
+
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, crl)
+	}
+
+	if len(list) == 0 { // DER.
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, crl)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,291 @@
+package mtls_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	stdhttptest "net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/middleware/mtls"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCA(t *testing.T, cn string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, subject pkix.Name, usage x509.ExtKeyUsage, ips ...net.IP) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(serial),
+		Subject:        subject,
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{usage},
+		EmailAddresses: []string{strings.ToLower(subject.CommonName) + "@example.com"},
+		DNSNames:       []string{strings.ToLower(subject.CommonName) + ".svc"},
+		IPAddresses:    ips,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func (ca *testCA) writeCRL(t *testing.T, filename string, serials ...int64) {
+	t.Helper()
+
+	entries := make([]x509.RevocationListEntry, 0, len(serials))
+	for _, serial := range serials {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now(),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	if err = os.WriteFile(filename, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func newServer(t *testing.T, ca *testCA, m *mtls.MTLS) *stdhttptest.Server {
+	t.Helper()
+
+	app := iris.New()
+	app.Use(m.Handler)
+	app.Get("/", func(ctx iris.Context) {
+		user := mtls.Get(ctx)
+		if user == nil {
+			ctx.WriteString("anonymous")
+			return
+		}
+
+		id, _ := user.GetID()
+		username, _ := user.GetUsername()
+		email, _ := user.GetEmail()
+		roles, _ := user.GetRoles()
+		ctx.Writef("%s %s %s %s", id, username, email, strings.Join(roles, ","))
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := stdhttptest.NewUnstartedServer(app)
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, 100, pkix.Name{CommonName: "server"},
+			x509.ExtKeyUsageServerAuth, net.IPv4(127, 0, 0, 1))},
+	}
+	m.ConfigureTLS(srv.TLS)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func get(ca *testCA, url string, clientCerts ...tls.Certificate) (int, string, error) {
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      ca.pool(),
+		Certificates: clientCerts,
+	}}}
+
+	resp, err := c.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+func TestMTLS(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	crlFile := filepath.Join(t.TempDir(), "ca.crl")
+	ca.writeCRL(t, crlFile, 3)
+
+	m := mtls.New(mtls.Options{
+		ClientCAs:   ca.pool(),
+		CRLFiles:    []string{crlFile},
+		ReloadEvery: time.Millisecond,
+	})
+	srv := newServer(t, ca, m)
+
+	alice := ca.issue(t, 2, pkix.Name{CommonName: "Alice", OrganizationalUnit: []string{"admin"}},
+		x509.ExtKeyUsageClientAuth)
+	code, body, err := get(ca, srv.URL, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "2 Alice alice@example.com admin"; code != http.StatusOK || body != expected {
+		t.Fatalf("expected: 200 %q but got: %d %q", expected, code, body)
+	}
+
+	bob := ca.issue(t, 3, pkix.Name{CommonName: "Bob"}, x509.ExtKeyUsageClientAuth)
+	if code, _, err = get(ca, srv.URL, bob); err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusForbidden {
+		t.Fatalf("expected a revoked certificate to be forbidden but got: %d", code)
+	}
+
+	// The handshake fails without a client certificate.
+	if _, _, err = get(ca, srv.URL); err == nil {
+		t.Fatalf("expected a handshake error without a client certificate")
+	}
+
+	// Live reload of the CRL file.
+	ca.writeCRL(t, crlFile, 2)
+	future := time.Now().Add(time.Second)
+	if err = os.Chtimes(crlFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if code, _, _ = get(ca, srv.URL, alice); code != http.StatusForbidden {
+		t.Fatalf("expected a revoked certificate to be forbidden after reload but got: %d", code)
+	}
+	if code, _, _ = get(ca, srv.URL, bob); code != http.StatusOK {
+		t.Fatalf("expected: 200 after reload but got: %d", code)
+	}
+}
+
+func TestMTLSOptional(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	m := mtls.New(mtls.Options{
+		ClientCAs: ca.pool(),
+		Optional:  true,
+		GetRoles: func(cert *x509.Certificate) []string {
+			return []string{"service"}
+		},
+	})
+	srv := newServer(t, ca, m)
+
+	code, body, err := get(ca, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusOK || body != "anonymous" {
+		t.Fatalf("expected: 200 anonymous but got: %d %q", code, body)
+	}
+
+	client := ca.issue(t, 10, pkix.Name{CommonName: "billing"}, x509.ExtKeyUsageClientAuth)
+	if code, body, err = get(ca, srv.URL, client); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "A billing billing@example.com service"; code != http.StatusOK || body != expected {
+		t.Fatalf("expected: 200 %q but got: %d %q", expected, code, body)
+	}
+}
+
+func TestMTLSVerify(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	m := mtls.New(mtls.Options{ClientCAs: ca.pool()})
+
+	// Certificates requested without verification, e.g. tls.RequestClientCert.
+	client := ca.issue(t, 5, pkix.Name{CommonName: "Alice"}, x509.ExtKeyUsageClientAuth)
+	user, err := m.Verify(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{client.Leaf}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dnsNames, _ := user.GetField("dns_names"); dnsNames.([]string)[0] != "alice.svc" {
+		t.Fatalf("expected dns_names field: [alice.svc] but got: %v", dnsNames)
+	}
+	if len(user.Chain) != 2 || !user.Chain[1].Equal(ca.cert) {
+		t.Fatalf("expected the verified chain to end with the CA")
+	}
+
+	other := newCA(t, "Other CA")
+	untrusted := other.issue(t, 5, pkix.Name{CommonName: "Mallory"}, x509.ExtKeyUsageClientAuth)
+	_, err = m.Verify(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrusted.Leaf}})
+	if !errors.Is(err, mtls.ErrInvalidCertificate) {
+		t.Fatalf("expected ErrInvalidCertificate but got: %v", err)
+	}
+
+	server := ca.issue(t, 6, pkix.Name{CommonName: "server"}, x509.ExtKeyUsageServerAuth)
+	_, err = m.Verify(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{server.Leaf}})
+	if !errors.Is(err, mtls.ErrInvalidCertificate) {
+		t.Fatalf("expected ErrInvalidCertificate for a server certificate but got: %v", err)
+	}
+
+	if _, err = m.Verify(nil); !errors.Is(err, mtls.ErrMissingCertificate) {
+		t.Fatalf("expected ErrMissingCertificate but got: %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package rate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+type (
+	// Window declares a single limit of a Tier,
+	// e.g. Window{Requests: 10, Per: time.Second} for 10 requests per second.
+	Window struct {
+		Requests int
+		Per      time.Duration
+	}
+
+	// Tier declares a set of limit windows that a client should satisfy,
+	// e.g. 10 requests per second and 10000 requests per day.
+	Tier []Window
+
+	// Policy declares the limits of clients based on their tier (e.g. plan),
+	// see `LimitPolicy` package-level function.
+	Policy struct {
+		// Tiers maps a tier name to its windows.
+		Tiers map[string]Tier
+		// DefaultTier is the tier name to use when TierFunc returns
+		// an empty or an unknown tier name.
+		DefaultTier string
+		// TierFunc selects the tier name of the current request's client.
+		// The client's Data field is filled by the `ClientData` option.
+		// See `TierByRole` and `TierByHeader` too.
+		//
+		// Defaults to a function which always returns the DefaultTier.
+		TierFunc func(ctx *context.Context, client *Client) string
+	}
+)
+
+// PerSecond returns a Window of "n" requests per second.
+func PerSecond(n int) Window { return Window{Requests: n, Per: time.Second} }
+
+// PerMinute returns a Window of "n" requests per minute.
+func PerMinute(n int) Window { return Window{Requests: n, Per: time.Minute} }
+
+// PerHour returns a Window of "n" requests per hour.
+func PerHour(n int) Window { return Window{Requests: n, Per: time.Hour} }
+
+// PerDay returns a Window of "n" requests per day.
+func PerDay(n int) Window { return Window{Requests: n, Per: 24 * time.Hour} }
+
+// limit returns the tokens per second of the window.
+func (w Window) limit() float64 {
+	if w.Per <= 0 {
+		return Inf
+	}
+
+	return float64(w.Requests) / w.Per.Seconds()
+}
+
+// TierByRole returns a Policy.TierFunc which selects the tier
+// based on the authenticated user's roles (see `Context.User`).
+// The first of the given "roles" that the user has is used as the tier name,
+// so the order of the "roles" is the priority.
+//
+// Example:
+//
+//	TierByRole("paid", "free")
+func TierByRole(roles ...string) func(ctx *context.Context, client *Client) string {
+	return func(ctx *context.Context, _ *Client) string {
+		u := ctx.User()
+		if u == nil {
+			return ""
+		}
+
+		userRoles, err := u.GetRoles()
+		if err != nil {
+			return ""
+		}
+
+		for _, role := range roles {
+			for _, userRole := range userRoles {
+				if role == userRole {
+					return role
+				}
+			}
+		}
+
+		return ""
+	}
+}
+
+// TierByHeader returns a Policy.TierFunc which selects the tier
+// based on the value of a request header, e.g. an API Key.
+// The "tiers" maps a header value to a tier name.
+//
+// Note that the header value should be validated by a previous handler.
+func TierByHeader(headerKey string, tiers map[string]string) func(ctx *context.Context, client *Client) string {
+	return func(ctx *context.Context, _ *Client) string {
+		return tiers[ctx.GetHeader(headerKey)]
+	}
+}
+
+// LimitPolicy returns a new rate limiter handler which limits
+// the clients based on their tier of the given "policy".
+// Each tier may contain multiple windows (e.g. per second, per minute and per day)
+// and a request is allowed only if all of them allow it. The windows
+// are checked from the shortest to the longest one and the check stops
+// on the first window which denies the request, the cost is then given back
+// to the windows which allowed it. Each window of a tier must have a different duration.
+//
+// Example:
+//
+//	rate.LimitPolicy(rate.Policy{
+//		Tiers: map[string]rate.Tier{
+//			"free": {rate.PerSecond(10), rate.PerDay(10_000)},
+//			"paid": {rate.PerSecond(100), rate.PerDay(1_000_000)},
+//		},
+//		DefaultTier: "free",
+//		TierFunc:    rate.TierByRole("paid"),
+//	}, rate.Headers)
+//
+// The limits are stored in the process memory, unless the `UseStore` option is passed.
+// Use the `Cost` handler to charge a different cost per route.
+// The `Client.Limiter` field is not used by policy limiters.
+func LimitPolicy(policy Policy, options ...Option) context.Handler {
+	if policy.TierFunc == nil {
+		policy.TierFunc = func(*context.Context, *Client) string { return policy.DefaultTier }
+	}
+
+	tiers := make(map[string]*tier, len(policy.Tiers))
+	for name, windows := range policy.Tiers {
+		tiers[name] = newTier(name, windows)
+	}
+
+	l := newLimiter(Inf, 0)
+	l.policy = &policyLimiter{
+		tiers:       tiers,
+		defaultTier: tiers[policy.DefaultTier],
+		tierFunc:    policy.TierFunc,
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	return l.serveHTTP
+}
+
+type (
+	policyLimiter struct {
+		tiers       map[string]*tier
+		defaultTier *tier
+		tierFunc    func(ctx *context.Context, client *Client) string
+	}
+
+	tier struct {
+		name         string
+		windows      []Window // sorted by duration.
+		policyHeader string
+	}
+)
+
+func newTier(name string, windows Tier) *tier {
+	sorted := make([]Window, len(windows))
+	copy(sorted, windows)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Per < sorted[j].Per
+	})
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Per == sorted[i-1].Per {
+			panic(fmt.Sprintf("rate: tier %q: duplicate window of %s", name, sorted[i].Per))
+		}
+	}
+
+	policies := make([]string, 0, len(sorted))
+	for _, w := range sorted {
+		policies = append(policies, fmt.Sprintf("%d;w=%d", w.Requests, int(math.Ceil(w.Per.Seconds()))))
+	}
+
+	return &tier{
+		name:         name,
+		windows:      sorted,
+		policyHeader: strings.Join(policies, ", "),
+	}
+}
+
+// getTier returns the tier of the current client, it may return nil.
+func (p *policyLimiter) getTier(ctx *context.Context, client *Client) *tier {
+	if t, ok := p.tiers[p.tierFunc(ctx, client)]; ok {
+		return t
+	}
+
+	return p.defaultTier
+}
+
+// allowPolicy reports whether the client's request is allowed by its tier's windows.
+// The returned Result is the one of the first window which denies the request,
+// or, if all windows allow it, the one with the least remaining requests.
+// When a window denies the request, the cost charged by the previous windows is refunded.
+func (l *Limiter) allowPolicy(ctx *context.Context, client *Client, cost int) (Result, string, bool) {
+	t := l.policy.getTier(ctx, client)
+	if t == nil { // no tier, no limits.
+		return Result{Allowed: true, RetryAfter: -1}, "", true
+	}
+
+	var (
+		final   Result
+		charged = make([]Store, 0, len(t.windows))
+	)
+	for i, w := range t.windows {
+		res, store, ok := l.storeAllow(ctx, t.key(client, w), w.limit(), w.Requests, cost)
+		if !ok {
+			t.refund(ctx, client, charged, cost)
+			return Result{}, "", false
+		}
+
+		if !res.Allowed {
+			t.refund(ctx, client, charged, cost)
+			return res, t.policyHeader, true
+		}
+
+		charged = append(charged, store)
+		if i == 0 || res.Remaining < final.Remaining {
+			final = res
+		}
+	}
+
+	return final, t.policyHeader, true
+}
+
+// key returns the store key of the client's window.
+func (t *tier) key(client *Client, w Window) string {
+	return client.ID + ":" + t.name + ":" + w.Per.String()
+}
+
+// refund gives the "cost" back to the first windows of the tier,
+// "charged" holds the Store of each window which allowed the request.
+// Errors are ignored, the charged cost is restored over time anyway.
+func (t *tier) refund(ctx *context.Context, client *Client, charged []Store, cost int) {
+	for i, store := range charged {
+		w := t.windows[i]
+		store.Allow(ctx.Request().Context(), t.key(client, w), w.limit(), w.Requests, -cost)
+	}
+}
+
+const costContextKey = "iris.ratelimit.cost"
+
+// SetCost can be called manually from a handler or a middleware
+// to change the cost of the current request, before the rate limiter.
+// The default cost of a request is 1. See `Cost` too.
+func SetCost(ctx *context.Context, cost int) {
+	ctx.Values().Set(costContextKey, cost)
+}
+
+// Cost returns a handler which sets the cost of the current request.
+// It should be registered before the rate limiter,
+// e.g. app.Post("/reports", rate.Cost(10), limiter, handler).
+func Cost(cost int) context.Handler {
+	return func(ctx *context.Context) {
+		SetCost(ctx, cost)
+		ctx.Next()
+	}
+}
+
+func getCost(ctx *context.Context) int {
+	if cost, err := ctx.Values().GetInt(costContextKey); err == nil && cost > 0 {
+		return cost
+	}
+
+	return 1
+}
@@ -3,7 +3,10 @@
 package rate
 
 import (
+	"fmt"
 	"math"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -21,17 +24,49 @@ func init() {
 // * ExceedHandler
 // * ClientData
 // * PurgeEvery
+// * UseStore
+// * LocalFallback
+// * Headers
 type Option func(*Limiter)
 
 // ExceedHandler is an `Option` that can be passed at the `Limit` package-level function.
 // It accepts a handler that will be executed every time a client tries to reach a page/resource
 // which is not accessible for that moment.
+//
+// Defaults to the `ExceedProblem` handler.
 func ExceedHandler(handler context.Handler) Option {
 	return func(l *Limiter) {
 		l.exceedHandler = handler
 	}
 }
 
+// Headers is an `Option` that can be passed at the `Limit` package-level function.
+// It sends the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// response headers of the IETF RateLimit header fields draft
+// (https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/)
+// and the Retry-After response header when the client exceeds the limit.
+func Headers(l *Limiter) {
+	l.headers = true
+}
+
+// ExceedProblem is the default handler of the `ExceedHandler` option.
+// It sends a 429 Too Many Requests RFC 9457 Problem Details response with a Retry-After header.
+func ExceedProblem(ctx *context.Context) {
+	problem := context.NewProblem().
+		Type("/errors/too-many-requests").
+		Title("Too many requests").
+		Status(http.StatusTooManyRequests).
+		Detail("The rate limit has been exceeded. Try again later.")
+
+	var opts context.ProblemOptions
+	if res, ok := GetResult(ctx); ok && res.RetryAfter > 0 {
+		opts.RetryAfter = ceilSeconds(res.RetryAfter)
+	}
+
+	ctx.Problem(problem, opts)
+	ctx.StopExecution()
+}
+
 // ClientData is an `Option` that can be passed at the `Limit` package-level function.
 // It accepts a function which provides the Iris Context and should return custom data
 // that will be stored to the Client and be retrieved as `Get(ctx).Client.Data` later on.
@@ -64,6 +99,30 @@ func PurgeEvery(every time.Duration, maxLifetime time.Duration) Option {
 	}
 }
 
+// UseStore is an `Option` that can be passed at the `Limit` package-level function.
+// It sets a Store which keeps the limits of each client instead of the process memory,
+// so all instances (replicas) of the application share the same limits.
+// Note that the Store is keyed by the client's identifier, use different stores
+// (e.g. with a different key prefix) for different Limit middlewares.
+//
+// See `NewMemStore`, the "redis" subpackage and the `LocalFallback` option.
+func UseStore(store Store) Option {
+	return func(l *Limiter) {
+		l.store = store
+	}
+}
+
+// LocalFallback is an `Option` that can be passed at the `Limit` package-level function.
+// When the Store (see `UseStore`) is unreachable the Limiter falls back to
+// the process memory limiter of the client, instead of responding with 503 Service Unavailable.
+// The optional "onError" is fired on each store error, e.g. to log it.
+func LocalFallback(onError func(ctx *context.Context, err error)) Option {
+	return func(l *Limiter) {
+		l.localFallback = true
+		l.onStoreError = onError
+	}
+}
+
 // Every converts a minimum time interval between events to a limit.
 // Usage: Limit(Every(1*time.Minute), 3, options...)
 func Every(interval time.Duration) float64 {
@@ -81,9 +140,17 @@ type (
 	Limiter struct {
 		clientDataFunc func(ctx *context.Context) any // fill the Client's Data field.
 		exceedHandler  context.Handler                // when too many requests.
+		headers        bool                           // send the RateLimit response headers.
+
+		limit        rate.Limit
+		burstSize    int
+		policyHeader string         // the RateLimit-Policy header value.
+		policy       *policyLimiter // see LimitPolicy.
 
-		limit     rate.Limit
-		burstSize int
+		store         Store                                 // optional shared storage.
+		localFallback bool                                  // use the local limiter on store errors.
+		onStoreError  func(ctx *context.Context, err error) // when the store is unreachable.
+		localStore    Store                                 // used by policies when no store or on fallback.
 
 		clients map[string]*Client
 		mu      sync.RWMutex // mutex for clients.
@@ -110,15 +177,15 @@ const Inf = math.MaxFloat64
 //
 // E.g. Limit(1, 5) to allow 1 request per second, with a maximum burst size of 5.
 //
-// See `ExceedHandler`, `ClientData` and `PurgeEvery` for the available "options".
+// See `ExceedHandler`, `ClientData`, `PurgeEvery`, `UseStore` and `Headers` for the available "options".
+// See `LimitPolicy` for tiered quotas with multiple windows per client and the `Cost` handler
+// to charge a different cost per route.
 func Limit(limit float64, burst int, options ...Option) context.Handler {
-	l := &Limiter{
-		clients:   make(map[string]*Client),
-		limit:     rate.Limit(limit),
-		burstSize: burst,
-		exceedHandler: func(ctx *context.Context) {
-			ctx.StopWithStatus(429) // Too Many Requests.
-		},
+	l := newLimiter(limit, burst)
+	if limit > 0 && limit != Inf {
+		// The time window is the time to fully restore the quota.
+		window := int(math.Ceil(float64(burst) / limit))
+		l.policyHeader = fmt.Sprintf("%d;w=%d", burst, window)
 	}
 
 	for _, opt := range options {
@@ -128,6 +195,16 @@ func Limit(limit float64, burst int, options ...Option) context.Handler {
 	return l.serveHTTP
 }
 
+func newLimiter(limit float64, burst int) *Limiter {
+	return &Limiter{
+		clients:       make(map[string]*Client),
+		limit:         rate.Limit(limit),
+		burstSize:     burst,
+		localStore:    NewMemStore(),
+		exceedHandler: ExceedProblem,
+	}
+}
+
 // Purge removes client entries from the memory based on the given "condition".
 func (l *Limiter) Purge(condition func(*Client) bool) {
 	l.mu.Lock()
@@ -168,7 +245,29 @@ func (l *Limiter) serveHTTP(ctx *context.Context) {
 
 	ctx.Values().Set(clientContextKey, client)
 
-	if client.Limiter.Allow() {
+	var (
+		res          Result
+		policyHeader = l.policyHeader
+		cost         = getCost(ctx)
+	)
+
+	if l.policy != nil {
+		res, policyHeader, ok = l.allowPolicy(ctx, client, cost)
+	} else {
+		res, ok = l.allow(ctx, client, cost)
+	}
+
+	if !ok {
+		return
+	}
+
+	ctx.Values().Set(resultContextKey, res)
+
+	if l.headers {
+		setHeaders(ctx, res, policyHeader)
+	}
+
+	if res.Allowed {
 		ctx.Next()
 		return
 	}
@@ -178,6 +277,58 @@ func (l *Limiter) serveHTTP(ctx *context.Context) {
 	}
 }
 
+// allow reports whether the client's request is allowed.
+// The second return value is false when the store is unreachable
+// and the request was already stopped.
+func (l *Limiter) allow(ctx *context.Context, client *Client, cost int) (Result, bool) {
+	if l.store != nil {
+		res, err := l.store.Allow(ctx.Request().Context(), client.ID, float64(l.limit), l.burstSize, cost)
+		if err == nil {
+			return res, true
+		}
+
+		if !l.handleStoreError(ctx, err) {
+			return Result{}, false
+		}
+	}
+
+	return client.allow(cost), true
+}
+
+// storeAllow calls the Allow method of the store or of the local store
+// if the store is missing or it's unreachable and the LocalFallback option was passed.
+// It returns the Store which answered too.
+func (l *Limiter) storeAllow(ctx *context.Context, key string, limit float64, burst, cost int) (Result, Store, bool) {
+	if l.store != nil {
+		res, err := l.store.Allow(ctx.Request().Context(), key, limit, burst, cost)
+		if err == nil {
+			return res, l.store, true
+		}
+
+		if !l.handleStoreError(ctx, err) {
+			return Result{}, nil, false
+		}
+	}
+
+	res, _ := l.localStore.Allow(ctx.Request().Context(), key, limit, burst, cost)
+	return res, l.localStore, true
+}
+
+// handleStoreError reports whether the local limiter should be used instead.
+// If it returns false then the request was stopped with 503 Service Unavailable.
+func (l *Limiter) handleStoreError(ctx *context.Context, err error) bool {
+	if l.onStoreError != nil {
+		l.onStoreError(ctx, err)
+	}
+
+	if !l.localFallback {
+		ctx.StopWithError(http.StatusServiceUnavailable, context.PrivateError(err))
+		return false
+	}
+
+	return true
+}
+
 const identifierContextKey = "iris.ratelimit.identifier"
 
 // SetIdentifier can be called manually from a handler or a middleare
@@ -194,6 +345,21 @@ func getIdentifier(ctx *context.Context) string {
 	return ctx.RemoteAddr()
 }
 
+const resultContextKey = "iris.ratelimit.result"
+
+// GetResult returns the rate limit `Result` of the current request.
+// It can be used by a custom `ExceedHandler` or by the next handlers
+// to log or send custom response headers.
+func GetResult(ctx *context.Context) (Result, bool) {
+	if v := ctx.Values().Get(resultContextKey); v != nil {
+		if res, ok := v.(Result); ok {
+			return res, true
+		}
+	}
+
+	return Result{}, false
+}
+
 const clientContextKey = "iris.ratelimit.client"
 
 // Get returns the current rate limited `Client`.
@@ -212,6 +378,34 @@ func Get(ctx *context.Context) *Client {
 	return nil
 }
 
+// allow reports whether a request of "cost" tokens may happen now
+// based on the process memory limiter.
+func (c *Client) allow(cost int) Result {
+	now := time.Now()
+	allowed := c.Limiter.AllowN(now, cost)
+	tokens := c.Limiter.TokensAt(now)
+
+	res := Result{
+		Allowed:    allowed,
+		Limit:      c.Limiter.Burst(),
+		RetryAfter: -1,
+	}
+
+	if tokens > 0 {
+		res.Remaining = int(tokens)
+	}
+
+	if missing := float64(res.Limit) - tokens; missing > 0 {
+		res.ResetAfter = c.DurationFromTokens(missing)
+	}
+
+	if !allowed {
+		res.RetryAfter = c.DurationFromTokens(float64(cost) - tokens)
+	}
+
+	return res
+}
+
 // LastSeen reports the last Client's visit.
 func (c *Client) LastSeen() time.Time {
 	c.mu.RLock()
@@ -237,3 +431,35 @@ func (c *Client) DurationFromTokens(tokens float64) time.Duration {
 	seconds := tokens / float64(c.Limiter.Limit())
 	return time.Nanosecond * time.Duration(1e9*seconds)
 }
+
+const (
+	rateLimitLimitHeaderKey     = "RateLimit-Limit"
+	rateLimitRemainingHeaderKey = "RateLimit-Remaining"
+	rateLimitResetHeaderKey     = "RateLimit-Reset"
+	rateLimitPolicyHeaderKey    = "RateLimit-Policy"
+	retryAfterHeaderKey         = "Retry-After"
+)
+
+func setHeaders(ctx *context.Context, res Result, policy string) {
+	h := ctx.ResponseWriter().Header()
+	h.Set(rateLimitLimitHeaderKey, strconv.Itoa(res.Limit))
+	h.Set(rateLimitRemainingHeaderKey, strconv.Itoa(res.Remaining))
+	h.Set(rateLimitResetHeaderKey, strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+	if policy != "" {
+		h.Set(rateLimitPolicyHeaderKey, policy)
+	}
+
+	if !res.Allowed && res.RetryAfter > 0 {
+		h.Set(retryAfterHeaderKey, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	}
+}
+
+// ceilSeconds rounds up the duration to seconds.
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(d.Seconds()))
+}
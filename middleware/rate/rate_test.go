@@ -0,0 +1,162 @@
+package rate_test
+
+import (
+	stdContext "context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/rate"
+
+	"github.com/iris-contrib/httpexpect/v2"
+)
+
+type unreachableStore struct{}
+
+func (unreachableStore) Allow(stdContext.Context, string, float64, int, int) (rate.Result, error) {
+	return rate.Result{}, errors.New("store is unreachable")
+}
+
+func TestLimitStore(t *testing.T) {
+	app := iris.New()
+	handler := func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	}
+
+	// Two limiters (think of two replicas) sharing the same store.
+	store := rate.NewMemStore()
+	app.Get("/replica1", rate.Limit(1, 2, rate.UseStore(store)), handler)
+	app.Get("/replica2", rate.Limit(1, 2, rate.UseStore(store)), handler)
+
+	app.Get("/unreachable", rate.Limit(1, 1, rate.UseStore(unreachableStore{})), handler)
+
+	var storeErrors int
+	onStoreError := func(ctx iris.Context, err error) {
+		storeErrors++
+	}
+	app.Get("/fallback", rate.Limit(1, 1, rate.UseStore(unreachableStore{}), rate.LocalFallback(onStoreError)), handler)
+
+	e := httptest.New(t, app)
+
+	e.GET("/replica1").Expect().Status(httptest.StatusOK).Body().IsEqual("OK")
+	e.GET("/replica2").Expect().Status(httptest.StatusOK).Body().IsEqual("OK")
+	e.GET("/replica1").Expect().Status(httptest.StatusTooManyRequests).
+		Header("Content-Type").HasPrefix("application/problem+json")
+	e.GET("/replica2").Expect().Status(httptest.StatusTooManyRequests)
+
+	e.GET("/unreachable").Expect().Status(httptest.StatusServiceUnavailable).
+		Body().NotContains("unreachable")
+
+	e.GET("/fallback").Expect().Status(httptest.StatusOK).Body().IsEqual("OK")
+	e.GET("/fallback").Expect().Status(httptest.StatusTooManyRequests)
+	if expected := 2; storeErrors != expected {
+		t.Fatalf("expected %d store errors but got %d", expected, storeErrors)
+	}
+}
+
+func TestLimitHeaders(t *testing.T) {
+	app := iris.New()
+	app.Get("/", rate.Limit(1, 2, rate.Headers), func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	})
+
+	e := httptest.New(t, app)
+
+	resp := e.GET("/").Expect().Status(httptest.StatusOK)
+	resp.Header("RateLimit-Limit").IsEqual("2")
+	resp.Header("RateLimit-Remaining").IsEqual("1")
+	resp.Header("RateLimit-Reset").IsEqual("1")
+	resp.Header("RateLimit-Policy").IsEqual("2;w=2")
+
+	e.GET("/").Expect().Status(httptest.StatusOK).Header("RateLimit-Remaining").IsEqual("0")
+
+	resp = e.GET("/").Expect().Status(httptest.StatusTooManyRequests)
+	resp.Header("Retry-After").IsEqual("1")
+	resp.Header("Content-Type").HasPrefix("application/problem+json")
+	resp.Body().Contains(`"status":429`)
+}
+
+func TestLimitPolicy(t *testing.T) {
+	app := iris.New()
+	app.Use(func(ctx iris.Context) {
+		if plan := ctx.GetHeader("X-Plan"); plan != "" {
+			ctx.SetUser(&iris.SimpleUser{ID: ctx.GetHeader("X-User"), Roles: []string{plan}})
+		}
+		rate.SetIdentifier(ctx, ctx.GetHeader("X-User"))
+		ctx.Next()
+	})
+
+	limiter := rate.LimitPolicy(rate.Policy{
+		Tiers: map[string]rate.Tier{
+			"free": {rate.PerDay(3), rate.PerSecond(2)},
+			"paid": {rate.PerSecond(4), rate.PerDay(100)},
+		},
+		DefaultTier: "free",
+		TierFunc:    rate.TierByRole("paid"),
+	}, rate.Headers)
+
+	handler := func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	}
+	app.Get("/", limiter, handler)
+	app.Get("/expensive", rate.Cost(4), limiter, handler)
+
+	e := httptest.New(t, app)
+
+	free := func(path string) *httpexpect.Response {
+		return e.GET(path).WithHeader("X-User", "free_user").Expect()
+	}
+
+	resp := free("/").Status(httptest.StatusOK)
+	resp.Header("RateLimit-Policy").IsEqual("2;w=1, 3;w=86400")
+	resp.Header("RateLimit-Remaining").IsEqual("1")
+	free("/").Status(httptest.StatusOK)
+	// The per second window denies it.
+	free("/").Status(httptest.StatusTooManyRequests).Header("Retry-After").IsEqual("1")
+
+	paid := func(path string) *httpexpect.Response {
+		return e.GET(path).WithHeader("X-User", "paid_user").WithHeader("X-Plan", "paid").Expect()
+	}
+
+	resp = paid("/expensive").Status(httptest.StatusOK)
+	resp.Header("RateLimit-Policy").IsEqual("4;w=1, 100;w=86400")
+	resp.Header("RateLimit-Remaining").IsEqual("0")
+	paid("/").Status(httptest.StatusTooManyRequests)
+}
+
+func TestLimitPolicyRefund(t *testing.T) {
+	app := iris.New()
+	limiter := rate.LimitPolicy(rate.Policy{
+		Tiers:       map[string]rate.Tier{"free": {rate.PerSecond(3), rate.PerDay(2)}},
+		DefaultTier: "free",
+	}, rate.Headers)
+
+	handler := func(ctx iris.Context) {
+		ctx.WriteString("OK")
+	}
+	app.Get("/", limiter, handler)
+	app.Get("/expensive", rate.Cost(3), limiter, handler)
+
+	e := httptest.New(t, app)
+
+	// The per day window denies it, the per second window should not be charged.
+	e.GET("/expensive").Expect().Status(httptest.StatusTooManyRequests)
+	for i := 0; i < 2; i++ {
+		e.GET("/").Expect().Status(httptest.StatusOK)
+	}
+	e.GET("/").Expect().Status(httptest.StatusTooManyRequests)
+}
+
+func TestLimitPolicyDuplicateWindow(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected a panic on duplicate windows")
+		}
+	}()
+
+	rate.LimitPolicy(rate.Policy{
+		Tiers: map[string]rate.Tier{"free": {rate.PerSecond(1), {Requests: 5, Per: time.Second}}},
+	})
+}
@@ -0,0 +1,204 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/core/host"
+	"github.com/kataras/iris/v12/middleware/rate"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var defaultContext = context.Background()
+
+// ErrNotConnected is returned by the Store's Allow when its Connect method was not called.
+var ErrNotConnected = errors.New("redis: store is not connected")
+
+type (
+	// Options is just a type alias for the go-redis Client Options.
+	Options = redis.Options
+	// ClusterOptions is just a type alias for the go-redis Cluster Client Options.
+	ClusterOptions = redis.ClusterOptions
+)
+
+// Client is the interface which both
+// go-redis Client and Cluster Client implements.
+type Client interface {
+	redis.Cmdable // Commands.
+	io.Closer     // CloseConnection.
+}
+
+// gcraScript implements the Generic Cell Rate Algorithm atomically,
+// it's the same algorithm as the rate.GCRA function.
+// The current time is taken by the redis server itself
+// so all application instances share the same clock.
+//
+// KEYS[1]: the client's key.
+// ARGV[1]: limit (events per second), ARGV[2]: burst, ARGV[3]: cost.
+// Returns: {allowed, remaining, retry_after (seconds), reset_after (seconds)}.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local emission_interval = 1 / limit
+local increment = emission_interval * cost
+local burst_offset = emission_interval * burst
+
+local now = redis.call("TIME")
+now = tonumber(now[1]) + tonumber(now[2]) / 1000000
+
+local tat = redis.call("GET", key)
+if not tat then
+  tat = now
+else
+  tat = math.max(tonumber(tat), now)
+end
+
+local new_tat = tat + increment
+local allow_at = new_tat - burst_offset
+local diff = now - allow_at
+
+if diff < 0 then
+  return {0, 0, tostring(-diff), tostring(tat - now)}
+end
+
+local reset_after = new_tat - now
+if reset_after > 0 then
+  redis.call("SET", key, string.format("%.6f", new_tat), "PX", math.ceil(reset_after * 1000))
+else -- the quota was fully restored by a refund (negative cost).
+  redis.call("DEL", key)
+  reset_after = 0
+end
+
+return {1, math.floor(diff / emission_interval), "-1", tostring(reset_after)}
+`)
+
+// Store is a rate.Store backed by Redis.
+// The limits are calculated atomically through a Lua script,
+// so all the application instances share the same limits per client.
+type Store struct {
+	// Prefix the client key into the redis database.
+	// Use different prefixes for different rate.Limit middlewares.
+	// Note that you can also select a different database
+	// through ClientOptions (or ClusterOptions).
+	// Defaults to "ratelimit:".
+	Prefix string
+	// Both Client and ClusterClient implements this interface.
+	client    Client
+	connected uint32
+	// Customize any go-redis fields manually
+	// before Connect.
+	ClientOptions  Options
+	ClusterOptions ClusterOptions
+}
+
+var _ rate.Store = (*Store)(nil)
+
+// NewStore returns a new redis-based rate.Store.
+// Modify its ClientOptions or ClusterOptions depending the application needs
+// and call its Connect.
+//
+// Usage:
+//
+//	store := NewStore()
+//	store.ClientOptions.Addr = ...
+//	err := store.Connect()
+//
+// And register it:
+//
+//	rate.Limit(1, 5, rate.UseStore(store), rate.LocalFallback(nil))
+func NewStore() *Store {
+	return &Store{
+		Prefix: "ratelimit:",
+		ClientOptions: Options{
+			Addr: "127.0.0.1:6379",
+			// The rest are defaulted to good values already.
+		},
+		// If its Addrs > 0 before connect then cluster client is used instead.
+		ClusterOptions: ClusterOptions{},
+	}
+}
+
+// Connect prepares the redis client and fires a ping response to it.
+func (s *Store) Connect() error {
+	if len(s.ClusterOptions.Addrs) > 0 {
+		// Use cluster client.
+		s.client = redis.NewClusterClient(&s.ClusterOptions)
+	} else {
+		s.client = redis.NewClient(&s.ClientOptions)
+	}
+
+	_, err := s.client.Ping(defaultContext).Result()
+	if err != nil {
+		return err
+	}
+
+	host.RegisterOnInterrupt(func() {
+		atomic.StoreUint32(&s.connected, 0)
+		s.client.Close()
+	})
+	atomic.StoreUint32(&s.connected, 1)
+
+	return nil
+}
+
+// IsConnected reports whether the Connect function was called.
+func (s *Store) IsConnected() bool {
+	return atomic.LoadUint32(&s.connected) > 0
+}
+
+// Allow implements the rate.Store interface.
+func (s *Store) Allow(ctx context.Context, key string, limit float64, burst, cost int) (rate.Result, error) {
+	if limit == rate.Inf || limit <= 0 {
+		// No need to talk to the database.
+		res, _ := rate.GCRA(time.Time{}, time.Now(), limit, burst, cost)
+		return res, nil
+	}
+
+	if !s.IsConnected() {
+		return rate.Result{}, ErrNotConnected
+	}
+
+	values, err := gcraScript.Run(ctx, s.client, []string{s.Prefix + key}, limit, burst, cost).Slice()
+	if err != nil {
+		return rate.Result{}, err
+	}
+
+	res := rate.Result{
+		Allowed:    toInt(values[0]) == 1,
+		Limit:      burst,
+		Remaining:  toInt(values[1]),
+		RetryAfter: toDuration(values[2]),
+		ResetAfter: toDuration(values[3]),
+	}
+
+	return res, nil
+}
+
+func toInt(v any) int {
+	n, _ := v.(int64)
+	return int(n)
+}
+
+func toDuration(v any) time.Duration {
+	s, _ := v.(string)
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+
+	if seconds < 0 {
+		return -1
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,90 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/middleware/rate"
+	"github.com/kataras/iris/v12/middleware/rate/redis"
+)
+
+func TestStoreNotConnected(t *testing.T) {
+	store := redis.NewStore()
+	if _, err := store.Allow(context.Background(), "client", 1, 1, 1); !errors.Is(err, redis.ErrNotConnected) {
+		t.Fatalf("expected error: %v but got: %v", redis.ErrNotConnected, err)
+	}
+}
+
+// TestStoreAllow runs against the redis server of the REDIS_ADDR environment variable,
+// e.g. REDIS_ADDR=127.0.0.1:6379 go test ./middleware/rate/redis.
+func TestStoreAllow(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is missing")
+	}
+
+	store := redis.NewStore()
+	// A different prefix per run, the keys expire on their own.
+	store.Prefix = "ratelimit:test:" + strconv.FormatInt(time.Now().UnixNano(), 10) + ":"
+	store.ClientOptions.Addr = addr
+	if err := store.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	// One request is restored every 10 minutes,
+	// so the test's own latency does not restore any of them.
+	const limit = 1.0 / 600
+	allow := func(cost int) rate.Result {
+		t.Helper()
+
+		res, err := store.Allow(ctx, "client", limit, 3, cost)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	// Burst of 3 requests.
+	for i := 2; i >= 0; i-- {
+		res := allow(1)
+		if !res.Allowed || res.Remaining != i || res.Limit != 3 || res.RetryAfter != -1 {
+			t.Fatalf("expected an allowed result with %d remaining but got: %#+v", i, res)
+		}
+	}
+
+	res := allow(1)
+	if res.Allowed || res.RetryAfter <= 9*time.Minute || res.RetryAfter > 10*time.Minute ||
+		res.ResetAfter <= 29*time.Minute || res.ResetAfter > 30*time.Minute {
+		t.Fatalf("expected a denied result with 10m retry after but got: %#+v", res)
+	}
+
+	// The cost is greater than the remaining ones.
+	if res = allow(2); res.Allowed {
+		t.Fatalf("expected a denied result but got: %#+v", res)
+	}
+
+	// Refund.
+	if res = allow(-1); !res.Allowed {
+		t.Fatalf("expected a refund to be allowed but got: %#+v", res)
+	}
+	if res = allow(1); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected an allowed result after a refund but got: %#+v", res)
+	}
+
+	// A full refund restores the whole burst.
+	if res = allow(-3); !res.Allowed || res.ResetAfter != 0 {
+		t.Fatalf("expected a full refund to reset the client but got: %#+v", res)
+	}
+	if res = allow(4); res.Allowed {
+		t.Fatalf("expected a denied result for a cost greater than the burst but got: %#+v", res)
+	}
+	if res = allow(3); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected the full burst to be allowed but got: %#+v", res)
+	}
+}
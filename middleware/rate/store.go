@@ -0,0 +1,142 @@
+package rate
+
+import (
+	stdContext "context"
+	"math"
+	"sync"
+	"time"
+)
+
+type (
+	// Store is the interface which rate limiter storage backends should implement.
+	// A Store allows multiple instances (replicas) of the same application
+	// to share the same limits per client.
+	// See `UseStore` option and the "redis" subpackage.
+	Store interface {
+		// Allow reports whether "cost" events may happen now for the "key"
+		// which is limited by "limit" events per second with a maximum "burst" size.
+		// A negative "cost" gives back a previously allowed cost, e.g.
+		// when another window of a Policy denied the request.
+		// A non-nil error means that the store could not be reached.
+		Allow(ctx stdContext.Context, key string, limit float64, burst, cost int) (Result, error)
+	}
+
+	// Result holds the outcome of a Store's Allow call.
+	Result struct {
+		// Allowed reports whether the request is allowed.
+		Allowed bool
+		// Limit is the burst size (the maximum requests quota) of the key.
+		Limit int
+		// Remaining is the number of requests still allowed right now.
+		Remaining int
+		// RetryAfter is the time until the next request will be allowed,
+		// it's -1 when the current request was allowed.
+		RetryAfter time.Duration
+		// ResetAfter is the time until the quota is fully restored.
+		ResetAfter time.Duration
+	}
+)
+
+// GCRA implements the Generic Cell Rate Algorithm,
+// which is equivalent to a token bucket
+// (see https://en.wikipedia.org/wiki/Generic_cell_rate_algorithm).
+//
+// It accepts the stored theoretical arrival time ("tat", zero when the key is new),
+// the current time and the limitation parameters and
+// it returns the result along with the new theoretical arrival time which should be stored
+// for the returned ResetAfter duration. If the result is not allowed, then the "newTAT"
+// should not be stored. A negative "cost" is always allowed and it gives the cost back,
+// if the "newTAT" is not after "now" then the quota is fully restored.
+//
+// Store implementations can use it to calculate the result
+// in the application side, under a lock.
+func GCRA(tat, now time.Time, limit float64, burst, cost int) (res Result, newTAT time.Time) {
+	res.Limit = burst
+
+	if limit == Inf {
+		res.Allowed = true
+		res.Remaining = burst
+		res.RetryAfter = -1
+		return res, tat
+	}
+
+	if limit <= 0 {
+		res.RetryAfter = time.Duration(math.MaxInt64)
+		res.ResetAfter = time.Duration(math.MaxInt64)
+		return res, tat
+	}
+
+	emissionInterval := time.Duration(float64(time.Second) / limit)
+	increment := emissionInterval * time.Duration(cost)
+	burstOffset := emissionInterval * time.Duration(burst)
+
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTAT = tat.Add(increment)
+	allowAt := newTAT.Add(-burstOffset)
+	diff := now.Sub(allowAt)
+
+	if diff < 0 {
+		res.RetryAfter = -diff
+		res.ResetAfter = tat.Sub(now)
+		return res, tat
+	}
+
+	res.Allowed = true
+	res.Remaining = int(diff / emissionInterval)
+	res.RetryAfter = -1
+	res.ResetAfter = newTAT.Sub(now)
+	if res.ResetAfter < 0 { // refunded.
+		res.ResetAfter = 0
+	}
+	return res, newTAT
+}
+
+// memStore is the in-memory Store implementation.
+type memStore struct {
+	entries    map[string]time.Time // key: theoretical arrival time.
+	lastPurged time.Time
+	mu         sync.Mutex
+}
+
+var _ Store = (*memStore)(nil)
+
+// NewMemStore returns a new in-memory Store.
+// It's useful for testing and for single-instance applications
+// that want to share a Store across different Limit middlewares.
+// Note that expired entries are removed at most once per minute.
+func NewMemStore() Store {
+	return &memStore{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// Allow implements the Store interface.
+func (s *memStore) Allow(_ stdContext.Context, key string, limit float64, burst, cost int) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPurged) > time.Minute {
+		for k, tat := range s.entries {
+			if !tat.After(now) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastPurged = now
+	}
+
+	res, newTAT := GCRA(s.entries[key], now, limit, burst, cost)
+	if res.Allowed {
+		if newTAT.After(now) {
+			s.entries[key] = newTAT
+		} else { // the quota was fully restored by a refund.
+			delete(s.entries, key)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,132 @@
+package secure
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// maxReportSize is the maximum body size of a violation report request.
+const maxReportSize = 64 * 1024
+
+// Report is a Content-Security-Policy violation report.
+// It's filled from both the "report-uri" (application/csp-report)
+// and the Reporting API (application/reports+json) formats.
+type Report struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+	StatusCode         int    `json:"status-code"`
+	ScriptSample       string `json:"script-sample"`
+}
+
+// reportingAPIReport is the body of a "csp-violation" Reporting API report.
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		Referrer           string `json:"referrer"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		OriginalPolicy     string `json:"originalPolicy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		StatusCode         int    `json:"statusCode"`
+		Sample             string `json:"sample"`
+	} `json:"body"`
+}
+
+// ReportHandler returns a handler which collects the Content-Security-Policy
+// violation reports and calls "onReport" for each one of them.
+// If "onReport" is nil then the reports are logged through the application's logger.
+// Register it on the Policy.ReportURI path with the POST method.
+//
+// Example Code:
+//
+//	app.Post("/csp-report", secure.ReportHandler(func(ctx iris.Context, r secure.Report) {
+//		ctx.Application().Logger().Warnf("CSP: %s blocked %s", r.DocumentURI, r.BlockedURI)
+//	}))
+func ReportHandler(onReport func(ctx *context.Context, report Report)) context.Handler {
+	if onReport == nil {
+		onReport = func(ctx *context.Context, r Report) {
+			ctx.Application().Logger().Warnf("CSP violation: %s: %s blocked: %s (%s:%d:%d)",
+				r.DocumentURI, r.EffectiveDirective, r.BlockedURI, r.SourceFile, r.LineNumber, r.ColumnNumber)
+		}
+	}
+
+	return func(ctx *context.Context) {
+		ctx.SetMaxRequestBodySize(maxReportSize)
+
+		body, err := ctx.GetBody()
+		if err != nil {
+			ctx.StopWithError(http.StatusBadRequest, context.PrivateError(err))
+			return
+		}
+
+		reports, err := parseReports(ctx.GetContentTypeRequested(), body)
+		if err != nil {
+			ctx.StopWithError(http.StatusBadRequest, context.PrivateError(err))
+			return
+		}
+
+		for _, r := range reports {
+			onReport(ctx, r)
+		}
+
+		ctx.StatusCode(http.StatusNoContent)
+	}
+}
+
+func parseReports(contentType string, body []byte) ([]Report, error) {
+	if strings.HasPrefix(contentType, "application/reports+json") {
+		var reports []reportingAPIReport
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, err
+		}
+
+		result := make([]Report, 0, len(reports))
+		for _, r := range reports {
+			if r.Type != "csp-violation" {
+				continue
+			}
+
+			result = append(result, Report{
+				DocumentURI:        r.Body.DocumentURL,
+				Referrer:           r.Body.Referrer,
+				BlockedURI:         r.Body.BlockedURL,
+				ViolatedDirective:  r.Body.EffectiveDirective,
+				EffectiveDirective: r.Body.EffectiveDirective,
+				OriginalPolicy:     r.Body.OriginalPolicy,
+				Disposition:        r.Body.Disposition,
+				SourceFile:         r.Body.SourceFile,
+				LineNumber:         r.Body.LineNumber,
+				ColumnNumber:       r.Body.ColumnNumber,
+				StatusCode:         r.Body.StatusCode,
+				ScriptSample:       r.Body.Sample,
+			})
+		}
+
+		return result, nil
+	}
+
+	// application/csp-report (or application/json).
+	var report struct {
+		Report Report `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+
+	return []Report{report.Report}, nil
+}
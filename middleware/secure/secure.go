@@ -0,0 +1,217 @@
+// Package secure implements a middleware which sets the common security response headers,
+// including a Content-Security-Policy with per-request nonces.
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/view"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/secure.*", "iris.secure")
+	view.RegisterFunc("csp_nonce", TemplateNonce)
+}
+
+const (
+	nonceContextKey = "iris.secure.nonce"
+
+	// ViewDataNonceKey is the view data key of the per-request CSP nonce,
+	// it can be used as {{ .csp_nonce }} on templates.
+	ViewDataNonceKey = "csp_nonce"
+)
+
+// Options holds the security headers to set.
+// The empty fields are not sent.
+// See `DefaultOptions` package-level function.
+type Options struct {
+	// STSMaxAge is the max-age of the Strict-Transport-Security header,
+	// which is sent on secure (HTTPS) requests only. Zero disables it.
+	STSMaxAge time.Duration
+	// STSIncludeSubdomains adds the includeSubDomains directive to the Strict-Transport-Security header.
+	STSIncludeSubdomains bool
+	// STSPreload adds the preload directive to the Strict-Transport-Security header.
+	STSPreload bool
+	// ContentTypeNosniff sets the "X-Content-Type-Options: nosniff" header.
+	ContentTypeNosniff bool
+	// FrameOptions is the X-Frame-Options header value, e.g. "DENY" or "SAMEORIGIN".
+	// Prefer the Policy.FrameAncestors on modern browsers.
+	FrameOptions string
+	// ReferrerPolicy is the Referrer-Policy header value,
+	// e.g. "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+	// PermissionsPolicy is the Permissions-Policy header value,
+	// e.g. "camera=(), microphone=(), geolocation=()".
+	PermissionsPolicy string
+	// CrossOriginOpenerPolicy is the Cross-Origin-Opener-Policy header value, e.g. "same-origin".
+	CrossOriginOpenerPolicy string
+	// CrossOriginEmbedderPolicy is the Cross-Origin-Embedder-Policy header value, e.g. "require-corp".
+	CrossOriginEmbedderPolicy string
+	// CrossOriginResourcePolicy is the Cross-Origin-Resource-Policy header value, e.g. "same-origin".
+	CrossOriginResourcePolicy string
+	// Policy is the Content-Security-Policy. A nil value disables it.
+	// A per-request nonce is generated when its ScriptNonce or StyleNonce is true,
+	// it's available through the `Nonce` package-level function and on templates through
+	// the "csp_nonce" function, which is registered automatically to every view engine, or the view data:
+	//
+	//	<script nonce="{{ csp_nonce . }}"> or <script nonce="{{ .csp_nonce }}">
+	//
+	// Use the `Nonce` to fill a field of a struct view model instead.
+	Policy *Policy
+	// ReportOnly sends the Policy through the Content-Security-Policy-Report-Only header,
+	// the violations are reported but not enforced. Useful to test a new policy.
+	ReportOnly bool
+	// Skip, if not nil, reports whether the headers should not be sent
+	// for a specific request.
+	Skip func(ctx *context.Context) bool
+}
+
+// DefaultOptions returns a new Options filled with the recommended values.
+// Modify its fields and pass it to the `New` package-level function.
+func DefaultOptions() Options {
+	return Options{
+		STSMaxAge:               365 * 24 * time.Hour,
+		STSIncludeSubdomains:    true,
+		ContentTypeNosniff:      true,
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+		CrossOriginOpenerPolicy: "same-origin",
+		Policy: &Policy{
+			DefaultSrc:     []string{Self},
+			ScriptSrc:      []string{Self},
+			ScriptNonce:    true,
+			StyleSrc:       []string{Self},
+			StyleNonce:     true,
+			ObjectSrc:      []string{None},
+			FrameAncestors: []string{None},
+			BaseURI:        []string{Self},
+		},
+	}
+}
+
+// Secure holds the options of the security headers middleware.
+// Initialize with the `New` package-level function.
+type Secure struct {
+	opts Options
+
+	stsValue  string
+	cspHeader string
+	// the policy value when it does not depend on the nonce.
+	cspValue  string
+	withNonce bool
+}
+
+// New returns a new security headers middleware.
+//
+// Example Code:
+//
+//	opts := secure.DefaultOptions()
+//	opts.Policy.ReportURI = "/csp-report"
+//	app.UseRouter(secure.New(opts))
+//	app.Post("/csp-report", secure.ReportHandler(nil))
+func New(opts Options) context.Handler {
+	s := &Secure{opts: opts}
+
+	if opts.STSMaxAge > 0 {
+		s.stsValue = "max-age=" + strconv.FormatInt(int64(opts.STSMaxAge.Seconds()), 10)
+		if opts.STSIncludeSubdomains {
+			s.stsValue += "; includeSubDomains"
+		}
+		if opts.STSPreload {
+			s.stsValue += "; preload"
+		}
+	}
+
+	if opts.Policy != nil {
+		s.cspHeader = "Content-Security-Policy"
+		if opts.ReportOnly {
+			s.cspHeader = "Content-Security-Policy-Report-Only"
+		}
+
+		s.withNonce = opts.Policy.ScriptNonce || opts.Policy.StyleNonce
+		if !s.withNonce {
+			s.cspValue = opts.Policy.String()
+		}
+	}
+
+	return s.serveHTTP
+}
+
+func (s *Secure) serveHTTP(ctx *context.Context) {
+	if s.opts.Skip != nil && s.opts.Skip(ctx) {
+		ctx.Next()
+		return
+	}
+
+	header := ctx.ResponseWriter().Header()
+
+	if s.stsValue != "" && ctx.IsSSL() {
+		header.Set("Strict-Transport-Security", s.stsValue)
+	}
+
+	if s.opts.ContentTypeNosniff {
+		header.Set("X-Content-Type-Options", "nosniff")
+	}
+
+	setHeader := func(key, value string) {
+		if value != "" {
+			header.Set(key, value)
+		}
+	}
+
+	setHeader("X-Frame-Options", s.opts.FrameOptions)
+	setHeader("Referrer-Policy", s.opts.ReferrerPolicy)
+	setHeader("Permissions-Policy", s.opts.PermissionsPolicy)
+	setHeader("Cross-Origin-Opener-Policy", s.opts.CrossOriginOpenerPolicy)
+	setHeader("Cross-Origin-Embedder-Policy", s.opts.CrossOriginEmbedderPolicy)
+	setHeader("Cross-Origin-Resource-Policy", s.opts.CrossOriginResourcePolicy)
+
+	if s.withNonce {
+		nonce, err := newNonce()
+		if err != nil {
+			ctx.StopWithError(http.StatusInternalServerError, context.PrivateError(err))
+			return
+		}
+
+		ctx.Values().Set(nonceContextKey, nonce)
+		ctx.ViewData(ViewDataNonceKey, nonce)
+		header.Set(s.cspHeader, s.opts.Policy.Build(nonce))
+	} else if s.cspValue != "" {
+		header.Set(s.cspHeader, s.cspValue)
+	}
+
+	ctx.Next()
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Nonce returns the CSP nonce of the current request.
+// Returns empty if the Policy does not use nonces or the middleware was not executed.
+func Nonce(ctx *context.Context) string {
+	return ctx.Values().GetString(nonceContextKey)
+}
+
+// TemplateNonce is the "csp_nonce" template function,
+// it accepts the template's binding data, e.g. {{ csp_nonce . }}.
+// It's registered automatically to the application's view engine.
+func TemplateNonce(data any) string {
+	if m, ok := data.(map[string]any); ok {
+		if v, ok := m[ViewDataNonceKey].(string); ok {
+			return v
+		}
+	}
+
+	return ""
+}
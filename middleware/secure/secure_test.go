@@ -0,0 +1,114 @@
+package secure_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/secure"
+)
+
+func TestPolicy(t *testing.T) {
+	p := secure.Policy{
+		DefaultSrc:              []string{secure.Self},
+		ScriptSrc:               []string{secure.Self, secure.StrictDynamic},
+		ScriptNonce:             true,
+		ObjectSrc:               []string{secure.None},
+		UpgradeInsecureRequests: true,
+		ReportURI:               "/csp-report",
+	}
+
+	expected := "default-src 'self'; script-src 'self' 'strict-dynamic' 'nonce-abc'; object-src 'none'; upgrade-insecure-requests; report-uri /csp-report"
+	if got := p.Build("abc"); got != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+
+	expected = "default-src 'self'; script-src 'self' 'strict-dynamic'; object-src 'none'; upgrade-insecure-requests; report-uri /csp-report"
+	if got := p.String(); got != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestSecure(t *testing.T) {
+	app := iris.New()
+	app.RegisterView(iris.HTML(fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(`<script nonce="{{ csp_nonce . }}"></script>{{ .csp_nonce }}`)},
+	}, ".html"))
+
+	opts := secure.DefaultOptions()
+	opts.PermissionsPolicy = "camera=()"
+	app.UseRouter(secure.New(opts))
+	app.Get("/", func(ctx iris.Context) {
+		ctx.View("index.html")
+	})
+
+	e := httptest.New(t, app.Configure(iris.WithSSLProxyHeader("X-Forwarded-Proto", "https")))
+	resp := e.GET("/").Expect().Status(httptest.StatusOK)
+	resp.Header("Strict-Transport-Security").IsEmpty() // not a secure request.
+	resp.Header("X-Content-Type-Options").IsEqual("nosniff")
+	resp.Header("X-Frame-Options").IsEqual("DENY")
+	resp.Header("Referrer-Policy").IsEqual("strict-origin-when-cross-origin")
+	resp.Header("Permissions-Policy").IsEqual("camera=()")
+	resp.Header("Cross-Origin-Opener-Policy").IsEqual("same-origin")
+	resp.Header("Cross-Origin-Embedder-Policy").IsEmpty()
+
+	csp := resp.Header("Content-Security-Policy").Raw()
+	body := resp.Body().Raw()
+	nonce := body[strings.LastIndexByte(body, '>')+1:]
+	if nonce == "" {
+		t.Fatalf("expected a nonce")
+	}
+	if expected := `<script nonce="` + nonce + `"></script>` + nonce; body != expected {
+		t.Fatalf("expected body: %s but got: %s", expected, body)
+	}
+	if !strings.Contains(csp, "script-src 'self' 'nonce-"+nonce+"'") {
+		t.Fatalf("expected the nonce on the policy but got: %s", csp)
+	}
+
+	// A new nonce per request.
+	e.GET("/").Expect().Header("Content-Security-Policy").NotEqual(csp)
+	e.GET("/").WithHeader("X-Forwarded-Proto", "https").Expect().
+		Header("Strict-Transport-Security").IsEqual("max-age=31536000; includeSubDomains")
+}
+
+func TestSecureReportOnly(t *testing.T) {
+	var reports []secure.Report
+
+	app := iris.New()
+	app.UseRouter(secure.New(secure.Options{
+		Policy:     &secure.Policy{DefaultSrc: []string{secure.Self}, ReportURI: "/csp-report"},
+		ReportOnly: true,
+	}))
+	app.Get("/", func(ctx iris.Context) {})
+	app.Post("/csp-report", secure.ReportHandler(func(ctx iris.Context, r secure.Report) {
+		reports = append(reports, r)
+	}))
+
+	e := httptest.New(t, app)
+	resp := e.GET("/").Expect().Status(httptest.StatusOK)
+	resp.Header("Content-Security-Policy").IsEmpty()
+	resp.Header("Content-Security-Policy-Report-Only").IsEqual("default-src 'self'; report-uri /csp-report")
+
+	e.POST("/csp-report").WithHeader("Content-Type", "application/csp-report").
+		WithBytes([]byte(`{"csp-report":{"document-uri":"http://example.com/","blocked-uri":"inline","effective-directive":"script-src-elem"}}`)).
+		Expect().Status(httptest.StatusNoContent)
+	e.POST("/csp-report").WithHeader("Content-Type", "application/reports+json").
+		WithBytes([]byte(`[{"type":"csp-violation","body":{"documentURL":"http://example.com/page","blockedURL":"eval","effectiveDirective":"script-src"}},{"type":"deprecation","body":{}}]`)).
+		Expect().Status(httptest.StatusNoContent)
+	e.POST("/csp-report").WithHeader("Content-Type", "application/csp-report").
+		WithBytes([]byte(`invalid`)).Expect().Status(httptest.StatusBadRequest)
+
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports but got: %d", len(reports))
+	}
+
+	if r := reports[0]; r.DocumentURI != "http://example.com/" || r.BlockedURI != "inline" || r.EffectiveDirective != "script-src-elem" {
+		t.Fatalf("unexpected report: %#v", r)
+	}
+
+	if r := reports[1]; r.DocumentURI != "http://example.com/page" || r.BlockedURI != "eval" || r.EffectiveDirective != "script-src" {
+		t.Fatalf("unexpected report: %#v", r)
+	}
+}
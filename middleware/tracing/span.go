@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SpanKind is the kind of a span.
+type SpanKind string
+
+const (
+	// SpanKindServer is the span of an incoming request.
+	SpanKindServer SpanKind = "server"
+	// SpanKindClient is the span of an outgoing request.
+	SpanKindClient SpanKind = "client"
+)
+
+// Span is a finished operation, e.g. an incoming or outgoing request.
+type Span struct {
+	// Name is the "{method} {route template}" of the request, e.g. "GET /users/{id:uint64}".
+	Name string
+	Kind SpanKind
+	// SpanContext holds the trace and span IDs of the span.
+	SpanContext SpanContext
+	// ParentSpanID is the span ID of the caller, it's not valid on root spans.
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	// StatusCode is the response status code, zero on client errors.
+	StatusCode int
+	// Attributes holds extra information, e.g. the "http.method", "http.route" and "http.url".
+	Attributes map[string]string
+	// Error is the error message, if any.
+	Error string
+}
+
+// Duration returns the duration of the span.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Exporter records the finished and sampled spans.
+// See `NewWriterExporter` and `NewMemoryExporter`.
+type Exporter interface {
+	// ExportSpan records the span. It's called once per span
+	// and it should not block, it must be safe for concurrent use.
+	ExportSpan(span Span)
+}
+
+// WriterExporter is an Exporter which writes the spans as JSON lines.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ Exporter = (*WriterExporter)(nil)
+
+// NewWriterExporter returns a new Exporter which writes
+// one JSON object per span to "w".
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// NewStdoutExporter returns a new Exporter which writes the spans to the os.Stdout.
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+type jsonSpan struct {
+	Name         string            `json:"name"`
+	Kind         SpanKind          `json:"kind"`
+	TraceID      string            `json:"trace_id"`
+	SpanID       string            `json:"span_id"`
+	ParentSpanID string            `json:"parent_span_id,omitempty"`
+	Start        time.Time         `json:"start"`
+	DurationMS   float64           `json:"duration_ms"`
+	StatusCode   int               `json:"status_code,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// ExportSpan writes the span as a JSON line.
+func (e *WriterExporter) ExportSpan(span Span) {
+	s := jsonSpan{
+		Name:       span.Name,
+		Kind:       span.Kind,
+		TraceID:    span.SpanContext.TraceID.String(),
+		SpanID:     span.SpanContext.SpanID.String(),
+		Start:      span.Start,
+		DurationMS: float64(span.Duration()) / float64(time.Millisecond),
+		StatusCode: span.StatusCode,
+		Attributes: span.Attributes,
+		Error:      span.Error,
+	}
+	if span.ParentSpanID.IsValid() {
+		s.ParentSpanID = span.ParentSpanID.String()
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.w.Write(append(b, '\n'))
+	e.mu.Unlock()
+}
+
+// MemoryExporter is an Exporter which keeps the spans in memory, useful for tests.
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+var _ Exporter = (*MemoryExporter)(nil)
+
+// NewMemoryExporter returns a new MemoryExporter.
+func NewMemoryExporter() *MemoryExporter {
+	return new(MemoryExporter)
+}
+
+// ExportSpan stores the span.
+func (e *MemoryExporter) ExportSpan(span Span) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// Spans returns a copy of the stored spans, in the order they were finished.
+func (e *MemoryExporter) Spans() []Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]Span(nil), e.spans...)
+}
+
+// Reset removes the stored spans.
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	stdContext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// The W3C Trace Context header names, see https://www.w3.org/TR/trace-context/.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// ErrInvalidTraceparent is returned by ParseTraceparent on malformed values.
+var ErrInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// TraceID is the identifier of a trace, shared by all of its spans.
+type TraceID [16]byte
+
+// NewTraceID returns a new random TraceID.
+func NewTraceID() (id TraceID) {
+	mustRandom(id[:])
+	return
+}
+
+// IsValid reports whether the ID is not all zeros.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns the lowercase hex representation of the ID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText completes the encoding.TextMarshaler interface.
+func (id TraceID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// SpanID is the identifier of a span.
+type SpanID [8]byte
+
+// NewSpanID returns a new random SpanID.
+func NewSpanID() (id SpanID) {
+	mustRandom(id[:])
+	return
+}
+
+// IsValid reports whether the ID is not all zeros.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// String returns the lowercase hex representation of the ID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText completes the encoding.TextMarshaler interface.
+func (id SpanID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func mustRandom(b []byte) {
+	for {
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+
+		for _, c := range b {
+			if c != 0 {
+				return
+			}
+		}
+	}
+}
+
+// FlagSampled is the sampled trace flag.
+const FlagSampled byte = 0x01
+
+// SpanContext is the propagated part of a span: the trace and span IDs,
+// the trace flags and the vendor specific trace state.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+}
+
+// IsValid reports whether both the trace and span IDs are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled reports whether the sampled flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent returns the "traceparent" header value, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceparent parses a "traceparent" header value.
+// Future versions are accepted as long as they start with the version 00 fields.
+func ParseTraceparent(value string) (sc SpanContext, err error) {
+	value = strings.TrimSpace(value)
+
+	// version-traceid-spanid-flags: 2+1+32+1+16+1+2.
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+
+	version, ok := decodeLowerHex(value[0:2])
+	if !ok || version[0] == 0xff || (version[0] == 0 && len(value) != 55) || (len(value) > 55 && value[55] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+
+	traceID, ok := decodeLowerHex(value[3:35])
+	if !ok {
+		return sc, ErrInvalidTraceparent
+	}
+	spanID, ok := decodeLowerHex(value[36:52])
+	if !ok {
+		return sc, ErrInvalidTraceparent
+	}
+	flags, ok := decodeLowerHex(value[53:55])
+	if !ok {
+		return sc, ErrInvalidTraceparent
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	return sc, nil
+}
+
+// decodeLowerHex decodes "s", the upper case letters are not allowed.
+func decodeLowerHex(s string) ([]byte, bool) {
+	if strings.ToLower(s) != s {
+		return nil, false
+	}
+
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of "parent" which holds the "sc".
+func ContextWithSpanContext(parent stdContext.Context, sc SpanContext) stdContext.Context {
+	return stdContext.WithValue(parent, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the SpanContext of "ctx", if any.
+// An Iris Context can be passed too, its request's context is checked.
+func SpanContextFromContext(ctx stdContext.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
@@ -0,0 +1,157 @@
+// Package tracing implements the W3C Trace Context (https://www.w3.org/TR/trace-context/)
+// propagation. The incoming "traceparent" and "tracestate" headers are parsed, or a new trace is started,
+// and the span is stored to the request's context, to the accesslog fields and to the context values,
+// so the client Transport, which x/client Clients install through ClientOption,
+// the TraceIDGenerator and the request logger can use it.
+// The finished spans are recorded by a pluggable Exporter.
+package tracing
+
+import (
+	stdContext "context"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/middleware/accesslog"
+	"github.com/kataras/iris/v12/middleware/requestid"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/tracing.*", "iris.tracing")
+}
+
+// The accesslog fields and the Context.Values keys which are set on each request.
+// Pass them to the logger middleware's MessageContextKeys to include them in the request logs.
+const (
+	// TraceIDField is the hex trace ID.
+	TraceIDField = "trace_id"
+	// SpanIDField is the hex span ID of the server span.
+	SpanIDField = "span_id"
+)
+
+// Options holds the configuration for the tracing middleware.
+type Options struct {
+	// Exporter records the sampled server spans.
+	// Defaults to nil, the spans are propagated but not recorded.
+	Exporter Exporter
+	// Sample, if not nil, reports whether a new trace should be sampled.
+	// The incoming traces respect the caller's sampled flag.
+	// Defaults to nil, all new traces are sampled.
+	Sample func(ctx *context.Context) bool
+}
+
+// New returns a new W3C Trace Context middleware.
+// Register it through Application.UseRouter, so every request has a span.
+//
+// Example Code:
+//
+//	app.UseRouter(tracing.New(tracing.Options{Exporter: tracing.NewStdoutExporter()}))
+//	app.UseRouter(requestid.New(tracing.TraceIDGenerator))
+//	app.Get("/users/{id:uint64}", func(ctx iris.Context) {
+//		// The client sends the traceparent header of the span.
+//		var user User
+//		err := c.ReadJSON(ctx, &user, iris.MethodGet, "/users/"+ctx.Params().Get("id"), nil)
+//	})
+//
+// The server span's name is the "{method} {route template}", e.g. "GET /users/{id:uint64}".
+func New(opts Options) context.Handler {
+	return func(ctx *context.Context) {
+		start := time.Now()
+
+		parent, err := ParseTraceparent(ctx.GetHeader(TraceparentHeader))
+		sc := SpanContext{SpanID: NewSpanID()}
+		if err == nil {
+			sc.TraceID = parent.TraceID
+			sc.Flags = parent.Flags
+			sc.TraceState = ctx.GetHeader(TracestateHeader)
+		} else {
+			sc.TraceID = NewTraceID()
+			if opts.Sample == nil || opts.Sample(ctx) {
+				sc.Flags = FlagSampled
+			}
+		}
+
+		r := ctx.Request()
+		ctx.ResetRequest(r.WithContext(ContextWithSpanContext(r.Context(), sc)))
+
+		traceID, spanID := sc.TraceID.String(), sc.SpanID.String()
+		ctx.Values().Set(TraceIDField, traceID)
+		ctx.Values().Set(SpanIDField, spanID)
+		fields := accesslog.GetFields(ctx)
+		fields.Set(TraceIDField, traceID)
+		fields.Set(SpanIDField, spanID)
+
+		ctx.Next()
+
+		if opts.Exporter == nil || !sc.IsSampled() {
+			return
+		}
+
+		route := "unmatched"
+		if currentRoute := ctx.GetCurrentRoute(); currentRoute != nil {
+			route = currentRoute.Path()
+		}
+
+		span := Span{
+			Name:         ctx.Method() + " " + route,
+			Kind:         SpanKindServer,
+			SpanContext:  sc,
+			ParentSpanID: parent.SpanID,
+			Start:        start,
+			End:          time.Now(),
+			StatusCode:   ctx.GetStatusCode(),
+			Attributes: map[string]string{
+				"http.method": ctx.Method(),
+				"http.route":  route,
+				"http.target": ctx.Request().URL.RequestURI(),
+			},
+		}
+		if err := ctx.GetErr(); err != nil {
+			span.Error = err.Error()
+		}
+
+		opts.Exporter.ExportSpan(span)
+	}
+}
+
+// TraceIDGenerator is a requestid.Generator which uses the W3C trace ID as the Request ID,
+// so downstream services which support the Trace Context understand it.
+// The tracing middleware must be registered before the request id one.
+// It falls back to the requestid.DefaultGenerator when the request has no trace.
+var TraceIDGenerator requestid.Generator = func(ctx *context.Context) string {
+	if traceID := ctx.Values().GetString(TraceIDField); traceID != "" {
+		ctx.Header("X-Request-Id", traceID)
+	}
+
+	return requestid.DefaultGenerator(ctx)
+}
+
+// Get returns the span context of the current request.
+// It reports false if the tracing middleware was not executed.
+func Get(ctx *context.Context) (SpanContext, bool) {
+	return SpanContextFromContext(ctx.Request().Context())
+}
+
+// StartSpan starts a new span, a child of the "ctx"'s span context if any,
+// otherwise the first span of a new sampled trace.
+// The caller should set the End time and export the span.
+func StartSpan(ctx stdContext.Context, name string, kind SpanKind) *Span {
+	sc := SpanContext{SpanID: NewSpanID()}
+
+	parent, ok := SpanContextFromContext(ctx)
+	if ok {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = NewTraceID()
+		sc.Flags = FlagSampled
+	}
+
+	return &Span{
+		Name:         name,
+		Kind:         kind,
+		SpanContext:  sc,
+		ParentSpanID: parent.SpanID,
+		Start:        time.Now(),
+	}
+}
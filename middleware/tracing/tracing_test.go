@@ -0,0 +1,223 @@
+package tracing_test
+
+import (
+	"errors"
+	"net/http"
+	stdhttptest "net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/accesslog"
+	"github.com/kataras/iris/v12/middleware/requestid"
+	"github.com/kataras/iris/v12/middleware/tracing"
+	"github.com/kataras/iris/v12/x/client"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String(); expected != got {
+		t.Fatalf("expected trace id: %s but got: %s", expected, got)
+	}
+	if expected, got := "00f067aa0ba902b7", sc.SpanID.String(); expected != got {
+		t.Fatalf("expected span id: %s but got: %s", expected, got)
+	}
+	if !sc.IsSampled() {
+		t.Fatalf("expected a sampled span context")
+	}
+	if expected, got := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent(); expected != got {
+		t.Fatalf("expected traceparent: %s but got: %s", expected, got)
+	}
+
+	if _, err = tracing.ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Fatalf("expected a future version to be accepted but got: %v", err)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", // upper case.
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", // zero trace id.
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", // zero span id.
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", // forbidden version.
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b-701",
+	} {
+		if _, err = tracing.ParseTraceparent(invalid); err != tracing.ErrInvalidTraceparent {
+			t.Fatalf("[%s] expected error: %v but got: %v", invalid, tracing.ErrInvalidTraceparent, err)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+
+	app := iris.New()
+	app.UseRouter(tracing.New(tracing.Options{Exporter: exporter}))
+	app.UseRouter(requestid.New(tracing.TraceIDGenerator))
+	app.Get("/users/{id:uint64}", func(ctx iris.Context) {
+		ctx.WriteString(accesslog.GetFields(ctx).GetString(tracing.SpanIDField))
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/users/42").WithHeader(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+		WithHeader(tracing.TracestateHeader, "vendor=value").Expect().Status(httptest.StatusOK).
+		Header("X-Request-Id").IsEqual("4bf92f3577b34da6a3ce929d0e0e4736")
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span but got: %d", len(spans))
+	}
+
+	span := spans[0]
+	if expected, got := "GET /users/{id:uint64}", span.Name; expected != got {
+		t.Fatalf("expected span name: %s but got: %s", expected, got)
+	}
+	if span.Kind != tracing.SpanKindServer {
+		t.Fatalf("expected a server span but got: %s", span.Kind)
+	}
+	if expected, got := "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID.String(); expected != got {
+		t.Fatalf("expected trace id: %s but got: %s", expected, got)
+	}
+	if expected, got := "00f067aa0ba902b7", span.ParentSpanID.String(); expected != got {
+		t.Fatalf("expected parent span id: %s but got: %s", expected, got)
+	}
+	if expected, got := "vendor=value", span.SpanContext.TraceState; expected != got {
+		t.Fatalf("expected trace state: %s but got: %s", expected, got)
+	}
+	if expected, got := "/users/42", span.Attributes["http.target"]; expected != got {
+		t.Fatalf("expected target: %s but got: %s", expected, got)
+	}
+	if span.StatusCode != iris.StatusOK {
+		t.Fatalf("expected status code: %d but got: %d", iris.StatusOK, span.StatusCode)
+	}
+
+	// A new trace, the span id is written by the handler.
+	exporter.Reset()
+	body := e.GET("/users/1").Expect().Status(httptest.StatusOK).Body().Raw()
+	spans = exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span but got: %d", len(spans))
+	}
+	if expected, got := spans[0].SpanContext.SpanID.String(), body; expected != got {
+		t.Fatalf("expected span id field: %s but got: %s", expected, got)
+	}
+	if spans[0].ParentSpanID.IsValid() {
+		t.Fatalf("expected a root span")
+	}
+
+	// Not sampled by the caller.
+	exporter.Reset()
+	e.GET("/users/1").WithHeader(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00").
+		Expect().Status(httptest.StatusOK)
+	if n := len(exporter.Spans()); n != 0 {
+		t.Fatalf("expected no spans but got: %d", n)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+
+	downstream := iris.New()
+	downstream.UseRouter(tracing.New(tracing.Options{Exporter: exporter}))
+	downstream.Get("/users/{id:uint64}", func(ctx iris.Context) {
+		ctx.WriteString("user")
+	})
+	if err := downstream.Build(); err != nil {
+		t.Fatal(err)
+	}
+	srv := stdhttptest.NewServer(downstream)
+	defer srv.Close()
+
+	c := client.New(client.BaseURL(srv.URL), tracing.ClientOption(exporter))
+
+	app := iris.New()
+	app.UseRouter(tracing.New(tracing.Options{Exporter: exporter}))
+	app.Get("/", func(ctx iris.Context) {
+		body, err := c.GetPlainUnquote(ctx, iris.MethodGet, "/users/42", nil)
+		if err != nil {
+			ctx.StopWithError(iris.StatusBadGateway, err)
+			return
+		}
+
+		ctx.WriteString(body)
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(httptest.StatusOK).Body().IsEqual("user")
+
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans but got: %d", len(spans))
+	}
+
+	// Finished order: downstream server, client, upstream server.
+	downstreamSpan, clientSpan, serverSpan := spans[0], spans[1], spans[2]
+	for _, span := range spans {
+		if span.SpanContext.TraceID != serverSpan.SpanContext.TraceID {
+			t.Fatalf("expected all spans to share the trace id: %s but got: %s", serverSpan.SpanContext.TraceID, span.SpanContext.TraceID)
+		}
+	}
+
+	if expected, got := "GET /users/{id:uint64}", downstreamSpan.Name; expected != got {
+		t.Fatalf("expected downstream span name: %s but got: %s", expected, got)
+	}
+	if expected, got := "GET /users/42", clientSpan.Name; expected != got {
+		t.Fatalf("expected client span name: %s but got: %s", expected, got)
+	}
+	if clientSpan.Kind != tracing.SpanKindClient || clientSpan.StatusCode != iris.StatusOK {
+		t.Fatalf("unexpected client span: %#+v", clientSpan)
+	}
+	if clientSpan.ParentSpanID != serverSpan.SpanContext.SpanID {
+		t.Fatalf("expected the client span to be a child of the server span")
+	}
+	if downstreamSpan.ParentSpanID != clientSpan.SpanContext.SpanID {
+		t.Fatalf("expected the downstream span to be a child of the client span")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestTransportError(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+	transport := tracing.NewTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/fail" {
+			return nil, errors.New("connection refused")
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Request: req}, nil
+	}), exporter)
+
+	// Both requests share the same (background) context.
+	ok, _ := http.NewRequest(http.MethodGet, "http://localhost/ok", nil)
+	fail, _ := http.NewRequest(http.MethodGet, "http://localhost/fail", nil)
+
+	if _, err := transport.RoundTrip(fail); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if _, err := transport.RoundTrip(ok); err != nil {
+		t.Fatal(err)
+	}
+
+	if fail.Header.Get(tracing.TraceparentHeader) != "" {
+		t.Fatalf("expected the request to be unmodified")
+	}
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans but got: %d", len(spans))
+	}
+	if spans[0].Name != "GET /fail" || spans[0].Error != "connection refused" {
+		t.Fatalf("unexpected span of the failed request: %#+v", spans[0])
+	}
+	if spans[1].Name != "GET /ok" || spans[1].Error != "" || spans[1].StatusCode != http.StatusOK {
+		t.Fatalf("unexpected span of the successful request: %#+v", spans[1])
+	}
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kataras/iris/v12/x/client"
+)
+
+// Transport is an http.RoundTripper which starts a client span, a child of the request context's span, if any,
+// sends its W3C Trace Context headers and records it when the round trip is finished.
+// Each round trip, including the redirects, has its own span.
+// Pass the Iris Context, or its request's context, as the request's context
+// to continue the trace of the incoming request.
+//
+// Usage:
+//
+//	c := client.New(client.BaseURL("https://users.service"), tracing.ClientOption(exporter))
+//
+// Or wrap any other http.Client's transport:
+//
+//	httpClient.Transport = tracing.NewTransport(httpClient.Transport, exporter)
+type Transport struct {
+	// Base is the underline RoundTripper which sends the requests.
+	// Defaults to the http.DefaultTransport.
+	Base http.RoundTripper
+	// Exporter records the sampled client spans, it can be nil.
+	Exporter Exporter
+	// SpanName returns the name of the client span.
+	// Defaults to the "{method} {path}" of the request.
+	SpanName func(req *http.Request) string
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport returns a new tracing Transport.
+// Both "base" and "exporter" can be nil.
+func NewTransport(base http.RoundTripper, exporter Exporter) *Transport {
+	return &Transport{
+		Base:     base,
+		Exporter: exporter,
+		SpanName: func(req *http.Request) string {
+			return req.Method + " " + req.URL.Path
+		},
+	}
+}
+
+// ClientOption returns an x/client Option which wraps the Client's transport with
+// a tracing Transport, so each request of the Client continues the trace of its context
+// and sends the W3C Trace Context headers.
+// Pass it after any other Option which replaces the HTTPClient.Transport, e.g. client.Handler.
+func ClientOption(exporter Exporter) client.Option {
+	return func(c *client.Client) {
+		c.HTTPClient.Transport = NewTransport(c.HTTPClient.Transport, exporter)
+	}
+}
+
+// RoundTrip sends a copy of the request with the "traceparent" and "tracestate" headers of a new client span.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	name := req.Method + " " + req.URL.Path
+	if t.SpanName != nil {
+		name = t.SpanName(req)
+	}
+
+	span := StartSpan(req.Context(), name, SpanKindClient)
+	span.Attributes = map[string]string{
+		"http.method": req.Method,
+		"http.url":    req.URL.String(),
+	}
+
+	// A RoundTripper should not modify the request.
+	req = req.Clone(req.Context())
+	req.Header.Set(TraceparentHeader, span.SpanContext.Traceparent())
+	if span.SpanContext.TraceState != "" {
+		req.Header.Set(TracestateHeader, span.SpanContext.TraceState)
+	}
+
+	resp, err := base.RoundTrip(req)
+
+	if t.Exporter == nil || !span.SpanContext.IsSampled() {
+		return resp, err
+	}
+
+	span.End = time.Now()
+	if resp != nil {
+		span.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		span.Error = err.Error()
+	}
+
+	t.Exporter.ExportSpan(*span)
+	return resp, err
+}
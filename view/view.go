@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/kataras/iris/v12/context"
 
@@ -100,6 +101,33 @@ func (v *View) Load() error {
 	return v.Engine.Load()
 }
 
+var (
+	registeredFuncs   = make(template.FuncMap)
+	registeredFuncsMu sync.RWMutex
+)
+
+// RegisterFunc registers a template function which is added automatically
+// to the view engine of every Iris Application on its Build.
+// It's called by packages which provide template helpers, e.g.
+// the csrf middleware registers its "csrf_field" on its init function.
+func RegisterFunc(funcName string, funcBody any) {
+	registeredFuncsMu.Lock()
+	registeredFuncs[funcName] = funcBody
+	registeredFuncsMu.Unlock()
+}
+
+// RegisteredFuncs returns a copy of the functions registered through RegisterFunc.
+func RegisteredFuncs() template.FuncMap {
+	registeredFuncsMu.RLock()
+	funcs := make(template.FuncMap, len(registeredFuncs))
+	for k, v := range registeredFuncs {
+		funcs[k] = v
+	}
+	registeredFuncsMu.RUnlock()
+
+	return funcs
+}
+
 // NoLayout disables the configuration's layout for a specific execution.
 const NoLayout = "iris.nolayout"
 
@@ -0,0 +1,476 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/cache/entry"
+	"github.com/kataras/iris/v12/core/memstore"
+)
+
+// DefaultCacheRetention is the default duration that a stale response
+// which carries validators (ETag or Last-Modified) is kept
+// in the store in order to be revalidated with the server.
+// See `CacheRetention` option.
+var DefaultCacheRetention = 24 * time.Hour
+
+// Cache enables a private HTTP cache (RFC 9111) for this Client.
+// Cacheable GET responses are stored to the "store" and they are served
+// from it while they are fresh. Stale responses are revalidated
+// using the If-None-Match and If-Modified-Since request headers.
+//
+// Supported Cache-Control directives:
+// - max-age (request and response)
+// - no-store (request and response)
+// - no-cache (request and response)
+// - must-revalidate (response)
+// - stale-if-error (request and response)
+//
+// Responses which contain a Vary header (except Accept-Encoding)
+// are not stored. Successful unsafe requests (e.g. POST)
+// invalidate the stored response of the same URL.
+//
+// If "store" is nil then an in-memory store is used instead.
+func Cache(store entry.Store) Option {
+	return func(c *Client) {
+		if store == nil {
+			store = entry.NewMemStore()
+		}
+
+		c.cache = &httpCache{
+			store:     store,
+			pool:      entry.NewPool(),
+			retention: DefaultCacheRetention,
+		}
+	}
+}
+
+// CacheRetention sets the duration that a stale response with validators
+// is kept in the cache store to be revalidated.
+// Should be registered after the `Cache` option.
+//
+// Defaults to the `DefaultCacheRetention`.
+func CacheRetention(d time.Duration) Option {
+	return func(c *Client) {
+		if c.cache != nil {
+			c.cache.retention = d
+		}
+	}
+}
+
+// httpCache implements the Cache option.
+type httpCache struct {
+	store     entry.Store
+	pool      *entry.Pool
+	retention time.Duration
+}
+
+// cacheTransport is the http.RoundTripper which
+// serves and stores the cacheable responses.
+type cacheTransport struct {
+	cache *httpCache
+	next  http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil && !isSafeMethod(req.Method) && resp.StatusCode < http.StatusBadRequest {
+			t.invalidate(req, resp)
+		}
+
+		return resp, err
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := reqCC["no-store"]; ok {
+		return t.next.RoundTrip(req)
+	}
+
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		// The caller handles the revalidation itself.
+		return t.next.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	cached := newCachedResponse(t.cache.store.Get(key))
+	if cached == nil {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		return t.store(key, resp, reqCC)
+	}
+
+	if cached.isFresh(reqCC) {
+		return cached.response(req), nil
+	}
+
+	outreq := req
+	if etag, lastModified := cached.headers.Get("ETag"), cached.headers.Get("Last-Modified"); etag != "" || lastModified != "" {
+		outreq = req.Clone(req.Context())
+		if etag != "" {
+			outreq.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			outreq.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(outreq)
+	if err != nil || isServerError(resp.StatusCode) {
+		if cached.canServeStaleOnError(reqCC) {
+			if resp != nil {
+				drainBody(resp)
+			}
+
+			return cached.response(req), nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotModified && outreq != req {
+		drainBody(resp)
+
+		// Update the stored headers with the ones of the 304 response,
+		// see RFC 9111 section 4.3.4.
+		for k, v := range resp.Header {
+			if k == "Content-Length" {
+				continue
+			}
+			cached.headers[k] = v
+		}
+		cached.headers.Del("Age")
+
+		t.cache.set(key, entry.NewResponse(cached.statusCode, cached.headers, cached.body))
+
+		revalidated := &cachedResponse{
+			statusCode: cached.statusCode,
+			headers:    cached.headers,
+			body:       cached.body,
+			storedAt:   memstore.Clock(),
+		}
+		return revalidated.response(req), nil
+	}
+
+	return t.store(key, resp, reqCC)
+}
+
+// store reads and stores the "resp" if it's cacheable
+// and returns a new response to be sent back to the caller.
+func (t *cacheTransport) store(key string, resp *http.Response, reqCC map[string]string) (*http.Response, error) {
+	if !isStorable(resp, reqCC) {
+		if resp.StatusCode < http.StatusBadRequest {
+			// A non-cacheable response replaces any previously stored one.
+			t.cache.store.Delete(key)
+		}
+
+		return resp, nil
+	}
+
+	life := t.cache.lifeTime(resp.Header, resp.StatusCode)
+	if life <= 0 {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.set(key, entry.NewResponse(resp.StatusCode, resp.Header, body))
+	return resp, nil
+}
+
+// invalidate removes the stored responses of the request's URL
+// and its Location and Content-Location response headers,
+// see RFC 9111 section 4.4.
+func (t *cacheTransport) invalidate(req *http.Request, resp *http.Response) {
+	t.cache.store.Delete(http.MethodGet + " " + req.URL.String())
+
+	for _, headerKey := range []string{"Location", "Content-Location"} {
+		loc := resp.Header.Get(headerKey)
+		if loc == "" {
+			continue
+		}
+
+		u, err := req.URL.Parse(loc)
+		if err != nil || u.Host != req.URL.Host {
+			continue
+		}
+
+		t.cache.store.Delete(http.MethodGet + " " + u.String())
+	}
+}
+
+// set stores the "r" response for at least its freshness lifetime
+// plus any stale-if-error and revalidation period.
+func (c *httpCache) set(key string, r *entry.Response) {
+	life := c.lifeTime(r.Headers(), r.StatusCode())
+
+	var e *entry.Entry
+	e = c.pool.Acquire(life, r, func() {
+		// Do not remove a newer entry which replaced this one.
+		if c.store.Get(key) == e {
+			c.store.Delete(key)
+		}
+	})
+
+	c.store.Set(key, e)
+}
+
+// lifeTime returns the duration that a response
+// with the given headers should be kept in the store.
+func (c *httpCache) lifeTime(header http.Header, statusCode int) time.Duration {
+	cc := parseCacheControl(header)
+	life := freshnessLifetime(header, cc, statusCode)
+
+	if v, ok := cc["stale-if-error"]; ok {
+		if staleIfError := parseSeconds(v); staleIfError > 0 {
+			life += staleIfError
+		}
+	}
+
+	if header.Get("ETag") != "" || header.Get("Last-Modified") != "" {
+		life += c.retention
+	}
+
+	return life
+}
+
+// cachedResponse holds a copy of a stored entry's response.
+type cachedResponse struct {
+	statusCode int
+	headers    http.Header
+	body       []byte
+	storedAt   time.Time
+}
+
+// newCachedResponse returns nil if the entry is missing or it's already released.
+func newCachedResponse(e *entry.Entry) *cachedResponse {
+	if e == nil {
+		return nil
+	}
+
+	r := e.Response()
+	if r == nil {
+		return nil
+	}
+
+	return &cachedResponse{
+		statusCode: r.StatusCode(),
+		headers:    r.Headers().Clone(),
+		body:       r.Bytes(),
+		storedAt:   e.LastModified,
+	}
+}
+
+// age returns the current age of the response, see RFC 9111 section 4.2.3.
+func (r *cachedResponse) age() time.Duration {
+	age := memstore.Clock().Sub(r.storedAt)
+	if initialAge := parseSeconds(r.headers.Get("Age")); initialAge > 0 {
+		age += initialAge
+	}
+
+	if age < 0 {
+		return 0
+	}
+
+	return age
+}
+
+func (r *cachedResponse) isFresh(reqCC map[string]string) bool {
+	if _, ok := reqCC["no-cache"]; ok {
+		return false
+	}
+
+	cc := parseCacheControl(r.headers)
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+
+	age := r.age()
+	if v, ok := reqCC["max-age"]; ok && age >= parseSeconds(v) {
+		return false
+	}
+
+	return age < freshnessLifetime(r.headers, cc, r.statusCode)
+}
+
+// canServeStaleOnError reports whether the stale response
+// can be used because of a stale-if-error directive, see RFC 5861.
+func (r *cachedResponse) canServeStaleOnError(reqCC map[string]string) bool {
+	cc := parseCacheControl(r.headers)
+	if _, ok := cc["must-revalidate"]; ok {
+		return false
+	}
+
+	v, ok := reqCC["stale-if-error"]
+	if !ok {
+		if v, ok = cc["stale-if-error"]; !ok {
+			return false
+		}
+	}
+
+	staleness := r.age() - freshnessLifetime(r.headers, cc, r.statusCode)
+	return staleness <= parseSeconds(v)
+}
+
+func (r *cachedResponse) response(req *http.Request) *http.Response {
+	header := r.headers.Clone()
+	header.Set("Age", strconv.FormatInt(int64(r.age()/time.Second), 10))
+
+	return &http.Response{
+		Status:        strconv.Itoa(r.statusCode) + " " + http.StatusText(r.statusCode),
+		StatusCode:    r.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+func cacheKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func isServerError(statusCode int) bool {
+	switch statusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isHeuristicallyCacheable reports whether a response status code
+// is cacheable by default, see RFC 9110 section 15.1.
+func isHeuristicallyCacheable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusPermanentRedirect,
+		http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusGone,
+		http.StatusRequestURITooLong, http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+func isStorable(resp *http.Response, reqCC map[string]string) bool {
+	if _, ok := reqCC["no-store"]; ok {
+		return false
+	}
+
+	cc := parseCacheControl(resp.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+
+	for _, vary := range resp.Header.Values("Vary") {
+		for _, field := range strings.Split(vary, ",") {
+			if field = strings.TrimSpace(field); field != "" && !strings.EqualFold(field, "Accept-Encoding") {
+				return false
+			}
+		}
+	}
+
+	if isHeuristicallyCacheable(resp.StatusCode) {
+		return true
+	}
+
+	_, hasMaxAge := cc["max-age"]
+	return hasMaxAge || resp.Header.Get("Expires") != ""
+}
+
+// freshnessLifetime calculates the freshness lifetime of a response,
+// see RFC 9111 section 4.2.1.
+func freshnessLifetime(header http.Header, cc map[string]string, statusCode int) time.Duration {
+	if v, ok := cc["max-age"]; ok {
+		return parseSeconds(v)
+	}
+
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = memstore.Clock()
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil { // invalid dates represent a time in the past.
+			return 0
+		}
+
+		return expiresAt.Sub(date)
+	}
+
+	if !isHeuristicallyCacheable(statusCode) {
+		return 0
+	}
+
+	// Heuristic freshness, see RFC 9111 section 4.2.2.
+	if lastModified, err := http.ParseTime(header.Get("Last-Modified")); err == nil {
+		if life := date.Sub(lastModified) / 10; life > 0 {
+			return min(life, 24*time.Hour)
+		}
+	}
+
+	return 0
+}
+
+// parseCacheControl returns the directives of the Cache-Control header.
+// Directive names are lowercased and their values are unquoted.
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+
+	for _, line := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			name, value, _ := strings.Cut(part, "=")
+			directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+
+	return directives
+}
+
+func parseSeconds(s string) time.Duration {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return time.Duration(n) * time.Second
+}
+
+func drainBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
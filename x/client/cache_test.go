@@ -0,0 +1,124 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientCache(t *testing.T) {
+	var (
+		hits         int32
+		revalidated  int32
+		failNextCall atomic.Bool
+	)
+
+	app := http.NewServeMux()
+	app.HandleFunc("/max-age", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("max-age"))
+	})
+	app.HandleFunc("/etag", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidated, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("etag"))
+	})
+	app.HandleFunc("/no-store", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("no-store"))
+	})
+	app.HandleFunc("/stale-if-error", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if failNextCall.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0, stale-if-error=60")
+		w.Write([]byte("stale-if-error"))
+	})
+
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	client := New(BaseURL(srv.URL), Cache(nil))
+
+	get := func(path string) (int, string) {
+		t.Helper()
+
+		resp, err := client.Do(defaultCtx, http.MethodGet, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp.StatusCode, string(b)
+	}
+
+	tests := []struct {
+		path                string
+		expectedBody        string
+		expectedHits        int32
+		expectedRevalidated int32
+	}{
+		{"/max-age", "max-age", 1, 0},
+		{"/etag", "etag", 2, 1},
+		{"/no-store", "no-store", 2, 0},
+	}
+
+	for i, tt := range tests {
+		atomic.StoreInt32(&hits, 0)
+		atomic.StoreInt32(&revalidated, 0)
+
+		for j := 0; j < 2; j++ {
+			if statusCode, body := get(tt.path); statusCode != http.StatusOK || body != tt.expectedBody {
+				t.Fatalf("[%d:%d] %s: expected status code: %d and body: %q but got: %d and %q",
+					i, j, tt.path, http.StatusOK, tt.expectedBody, statusCode, body)
+			}
+		}
+
+		if got := atomic.LoadInt32(&hits); got != tt.expectedHits {
+			t.Fatalf("[%d] %s: expected %d server hits but got %d", i, tt.path, tt.expectedHits, got)
+		}
+
+		if got := atomic.LoadInt32(&revalidated); got != tt.expectedRevalidated {
+			t.Fatalf("[%d] %s: expected %d revalidations but got %d", i, tt.path, tt.expectedRevalidated, got)
+		}
+	}
+
+	if _, body := get("/stale-if-error"); body != "stale-if-error" {
+		t.Fatalf("expected body: %q but got: %q", "stale-if-error", body)
+	}
+
+	failNextCall.Store(true)
+	if statusCode, body := get("/stale-if-error"); statusCode != http.StatusOK || body != "stale-if-error" {
+		t.Fatalf("expected stale response on server error but got: %d and %q", statusCode, body)
+	}
+
+	// Unsafe methods invalidate the stored response.
+	atomic.StoreInt32(&hits, 0)
+	resp, err := client.Do(defaultCtx, http.MethodPost, "/max-age", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.DrainResponseBody(resp)
+
+	get("/max-age")
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("expected cache invalidation after POST: 2 server hits but got %d", got)
+	}
+}
@@ -35,6 +35,9 @@ type Client struct {
 	// Optional handlers that are being fired before and after each new request.
 	requestHandlers []RequestHandler
 
+	// Optional private HTTP cache initialized by the Cache option.
+	cache *httpCache
+
 	// store it here for future use.
 	keepAlive bool
 }
@@ -45,6 +48,7 @@ type Client struct {
 // - Timeout
 // - PersistentRequestOptions
 // - RateLimit
+// - Cache
 //
 // Look the Client.Do/JSON/... methods to send requests and
 // ReadXXX methods to read responses.
@@ -67,6 +71,15 @@ func New(opts ...Option) *Client {
 		c.keepAlive = !transport.DisableKeepAlives
 	}
 
+	if c.cache != nil {
+		next := c.HTTPClient.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+
+		c.HTTPClient.Transport = &cacheTransport{cache: c.cache, next: next}
+	}
+
 	return c
 }
 